@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config holds the runtime settings of the book club server, read from the environment.
+type config struct {
+	DBPath         string
+	MigrationsPath string
+	Addr           string
+	AdminToken     string
+	BackupDir      string
+	BlobDir        string
+	BackupInterval time.Duration
+	BackupMaxAge   time.Duration
+	BackupKeep     int
+
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	EventRetention     time.Duration
+	EventSinks         []string
+	OutboxInterval     time.Duration
+
+	WebhookInterval  time.Duration
+	WebhookRetention time.Duration
+
+	SMTPAddr              string
+	SMTPUsername          string
+	SMTPPassword          string
+	SMTPFrom              string
+	NotifyInterval        time.Duration
+	NotificationRetention time.Duration
+
+	MetadataProvider string
+	MetadataURL      string
+	MetadataAPIKey   string
+	MetadataFakeData string
+	MetadataCacheTTL time.Duration
+
+	OTLPEndpoint string
+
+	CORSOrigins    []string
+	ReadRateLimit  int
+	ReadRateBurst  int
+	WriteRateLimit int
+	WriteRateBurst int
+}
+
+// loadConfig reads the configuration from the BOOK_CLUB_* environment
+// variables, falling back to defaults suitable for local development.
+func loadConfig() (*config, error) {
+	var err error
+	cfg := &config{
+		DBPath:         envString("BOOK_CLUB_DB_PATH", "database/data/books.db"),
+		MigrationsPath: envString("BOOK_CLUB_MIGRATIONS_PATH", "database/migrations"),
+		Addr:           envString("BOOK_CLUB_ADDR", ":8080"),
+		AdminToken:     envString("BOOK_CLUB_ADMIN_TOKEN", ""),
+		BackupDir:      envString("BOOK_CLUB_BACKUP_DIR", "database/backups"),
+		BlobDir:        envString("BOOK_CLUB_BLOB_DIR", "database/blobs"),
+
+		EventSinks: envList("BOOK_CLUB_EVENT_SINKS", "sse,webhook"),
+
+		SMTPAddr:     envString("BOOK_CLUB_SMTP_ADDR", ""),
+		SMTPUsername: envString("BOOK_CLUB_SMTP_USERNAME", ""),
+		SMTPPassword: envString("BOOK_CLUB_SMTP_PASSWORD", ""),
+		SMTPFrom:     envString("BOOK_CLUB_SMTP_FROM", "Book Club <book-club@localhost>"),
+
+		MetadataProvider: envString("BOOK_CLUB_METADATA_PROVIDER", ""),
+		MetadataURL:      envString("BOOK_CLUB_METADATA_URL", ""),
+		MetadataAPIKey:   envString("BOOK_CLUB_METADATA_API_KEY", ""),
+		MetadataFakeData: envString("BOOK_CLUB_METADATA_FAKE_DATA", ""),
+
+		OTLPEndpoint: envString("BOOK_CLUB_OTLP_ENDPOINT", ""),
+
+		CORSOrigins: envList("BOOK_CLUB_CORS_ORIGINS", "http://localhost:3000"),
+	}
+
+	cfg.BackupInterval, err = envDuration("BOOK_CLUB_BACKUP_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BackupMaxAge, err = envDuration("BOOK_CLUB_BACKUP_MAX_AGE", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BackupKeep, err = envInt("BOOK_CLUB_BACKUP_KEEP", 14)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TrashRetention, err = envDuration("BOOK_CLUB_TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TrashPurgeInterval, err = envDuration("BOOK_CLUB_TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.EventRetention, err = envDuration("BOOK_CLUB_EVENT_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.OutboxInterval, err = envDuration("BOOK_CLUB_OUTBOX_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WebhookInterval, err = envDuration("BOOK_CLUB_WEBHOOK_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WebhookRetention, err = envDuration("BOOK_CLUB_WEBHOOK_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.NotifyInterval, err = envDuration("BOOK_CLUB_NOTIFY_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.NotificationRetention, err = envDuration("BOOK_CLUB_NOTIFICATION_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.MetadataCacheTTL, err = envDuration("BOOK_CLUB_METADATA_CACHE_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ReadRateLimit, err = envInt("BOOK_CLUB_READ_RATE_LIMIT", 600)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ReadRateBurst, err = envInt("BOOK_CLUB_READ_RATE_BURST", 100)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WriteRateLimit, err = envInt("BOOK_CLUB_WRITE_RATE_LIMIT", 60)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WriteRateBurst, err = envInt("BOOK_CLUB_WRITE_RATE_BURST", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func envString(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+// envList reads a comma separated list, ignoring empty items.
+func envList(key, def string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(envString(key, def), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func envInt(key string, def int) (int, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
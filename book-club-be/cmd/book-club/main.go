@@ -2,41 +2,179 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"time"
 
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/blob"
 	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+	"github.com/Michela-DC/book-club/internal/infrastructure/email"
+	"github.com/Michela-DC/book-club/internal/infrastructure/events"
+	"github.com/Michela-DC/book-club/internal/infrastructure/metadata"
+	"github.com/Michela-DC/book-club/internal/infrastructure/metrics"
+	"github.com/Michela-DC/book-club/internal/infrastructure/tracing"
+	"github.com/Michela-DC/book-club/internal/infrastructure/webhook"
 	"github.com/Michela-DC/book-club/internal/infrastructure/webservice"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
 	"github.com/Michela-DC/book-club/internal/interfaces/controller"
+	"github.com/Michela-DC/book-club/internal/interfaces/importer"
 	"github.com/Michela-DC/book-club/internal/usecase/interactor"
 )
 
+const usage = `usage: book-club [command]
+
+commands:
+  serve                 start the HTTP server (default)
+  restore <snapshot>    replace the database with a backup snapshot
+  import <file.csv>     import books from a Goodreads or StoryGraph CSV export
+`
+
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		AddSource: true,
 		Level:     slog.LevelInfo,
 	}))
 
-	// TODO: read db path from config
-	repo, err := db.NewSQLiteBookRepository("database/data/books.db", logger)
+	cfg, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	cmd, args := "serve", []string(nil)
+	if len(os.Args) > 1 {
+		cmd, args = os.Args[1], os.Args[2:]
+	}
+
+	switch cmd {
+	case "serve":
+		serve(cfg, logger)
+	case "restore":
+		restore(cfg, logger, args)
+	case "import":
+		importBooks(cfg, logger, args)
+	default:
+		_, _ = os.Stderr.WriteString(usage)
+		os.Exit(2)
+	}
+}
+
+// serve starts the HTTP server and the scheduled backups.
+func serve(cfg *config, logger *slog.Logger) {
+	ctx := context.Background()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.OTLPEndpoint)
+	if err != nil {
+		panic(err)
+	}
+
+	repo, err := db.NewSQLiteBookRepository(cfg.DBPath, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	err = repo.ApplyMigrations(ctx, cfg.MigrationsPath)
+	if err != nil {
+		panic(err)
+	}
+
+	snapshots, err := db.NewSQLiteSnapshotRepository(repo, cfg.BackupDir, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	provider, err := newMetadataProvider(cfg, repo, logger)
 	if err != nil {
 		panic(err)
 	}
 
-	err = repo.ApplyMigrations(context.Background(), "database/migrations")
+	blobs, err := blob.NewLocalStore(cfg.BlobDir, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	tags := db.NewSQLiteTagRepository(repo, logger)
+	members := db.NewSQLiteMemberRepository(repo, logger)
+	eventLog := db.NewSQLiteEventLog(repo, cfg.EventRetention, logger)
+	bus := events.NewBus(eventLog, logger)
+	wi := interactor.NewWebhookInteractor(
+		db.NewSQLiteWebhookRepository(repo, cfg.WebhookRetention, logger),
+		webhook.NewHTTPSender(),
+		logger,
+	)
+	ni, err := newNotifier(cfg, repo, members, eventLog, logger)
+	if err != nil {
+		panic(err)
+	}
+	sinks, err := newEventSinks(cfg, bus, wi, ni, logger)
 	if err != nil {
 		panic(err)
 	}
+	dispatcher := events.NewDispatcher(eventLog, logger, sinks...)
+	go dispatcher.Run(ctx, cfg.OutboxInterval)
 
-	i := interactor.NewBookInteractor(repo, logger)
-	ctl := controller.NewBookController(i, logger)
-	h := webservice.NewHandler(ctl)
+	i := interactor.NewBookInteractor(repo, tags, members, provider, blobs, dispatcher, logger)
+	bi := interactor.NewBackupInteractor(snapshots, interactor.RetentionPolicy{
+		Keep:   cfg.BackupKeep,
+		MaxAge: cfg.BackupMaxAge,
+	}, logger)
+	go bi.Schedule(ctx, cfg.BackupInterval)
+	go i.SchedulePurge(ctx, cfg.TrashPurgeInterval, cfg.TrashRetention)
+
+	bc := controller.NewBookController(i, logger)
+	ai := interactor.NewAuthorInteractor(db.NewSQLiteAuthorRepository(repo, logger), repo, logger)
+	si := interactor.NewStatsInteractor(db.NewSQLiteStatsRepository(repo, logger), logger)
+	controllers := webservice.Controllers{
+		Books:       bc,
+		BookItems:   bc,
+		BookImports: bc,
+		BookBatches: bc,
+		BookTrash:   bc,
+		BookExports: bc,
+		BookISBNs:   bc,
+		BookCovers:  controller.NewCoverController(interactor.NewCoverInteractor(repo, blobs, dispatcher, logger), logger),
+		Authors:     controller.NewAuthorController(ai, logger),
+		Tags:        controller.NewTagController(interactor.NewTagInteractor(tags, logger), logger),
+		Stats:       controller.NewStatsController(si, logger),
+		Backups:     controller.NewBackupController(bi, logger),
+		Members:     controller.NewMemberController(interactor.NewMemberInteractor(members, logger), logger),
+	}
+	if provider != nil {
+		controllers.BookEnrich = bc
+	}
+	if slices.Contains(cfg.EventSinks, "sse") {
+		controllers.Events = controller.NewEventController(bus, logger)
+	}
+	if slices.Contains(cfg.EventSinks, "webhook") {
+		controllers.Webhooks = controller.NewWebhookController(wi, logger)
+		go wi.Run(ctx, cfg.WebhookInterval)
+	}
+	if slices.Contains(cfg.EventSinks, "email") {
+		go ni.Run(ctx, cfg.NotifyInterval)
+	}
+
+	h := webservice.NewHandler(controllers, webservice.Config{
+		AdminToken:  cfg.AdminToken,
+		Logger:      logger,
+		Metrics:     metrics.New(repo.DB(), repo, logger),
+		Idempotency: db.NewSQLiteIdempotencyRepository(repo, 24*time.Hour, logger),
+		CORS: webservice.CORSConfig{
+			AllowedOrigins: cfg.CORSOrigins,
+			MaxAge:         10 * time.Minute,
+		},
+		ReadLimit:  webservice.RateLimit{PerMinute: cfg.ReadRateLimit, Burst: cfg.ReadRateBurst},
+		WriteLimit: webservice.RateLimit{PerMinute: cfg.WriteRateLimit, Burst: cfg.WriteRateBurst},
+	})
 
 	s := &http.Server{
-		Addr:              ":8080",
+		Addr:              cfg.Addr,
 		Handler:           h,
 		ReadTimeout:       time.Second,
 		WriteTimeout:      2 * time.Second,
@@ -44,5 +182,177 @@ func main() {
 		ReadHeaderTimeout: time.Second,
 	}
 
-	log.Fatal(s.ListenAndServe())
+	err = s.ListenAndServe()
+	_ = shutdownTracing(ctx)
+	log.Fatal(err)
+}
+
+// newMetadataProvider returns the configured metadata provider, wrapped in
+// the SQLite response cache, or nil if book enrichment is disabled.
+func newMetadataProvider(
+	cfg *config,
+	repo *db.SQLiteBookRepository,
+	logger *slog.Logger,
+) (domain.MetadataProvider, error) {
+	baseURL := func(def string) string {
+		if cfg.MetadataURL != "" {
+			return cfg.MetadataURL
+		}
+		return def
+	}
+
+	var provider domain.MetadataProvider
+	switch cfg.MetadataProvider {
+	case "":
+		return nil, nil
+	case "openlibrary":
+		provider = metadata.NewOpenLibraryProvider(baseURL(metadata.OpenLibraryURL))
+	case "googlebooks":
+		provider = metadata.NewGoogleBooksProvider(baseURL(metadata.GoogleBooksURL), cfg.MetadataAPIKey)
+	case "fake":
+		if cfg.MetadataFakeData == "" {
+			return metadata.NewFakeProvider(), nil
+		}
+		return metadata.LoadFakeProvider(cfg.MetadataFakeData)
+	default:
+		return nil, fmt.Errorf("unknown metadata provider %q", cfg.MetadataProvider)
+	}
+
+	return db.NewCachedMetadataProvider(repo, provider, cfg.MetadataCacheTTL, logger), nil
+}
+
+// newNotifier returns the interactor emailing the members through the
+// configured SMTP server, or nil if no server is configured.
+func newNotifier(
+	cfg *config,
+	repo *db.SQLiteBookRepository,
+	members domain.MemberRepository,
+	eventLog domain.EventLog,
+	logger *slog.Logger,
+) (*interactor.NotificationInteractor, error) {
+	if cfg.SMTPAddr == "" {
+		return nil, nil
+	}
+
+	mailer, err := email.NewSMTPMailer(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := email.NewTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	return interactor.NewNotificationInteractor(
+		members,
+		db.NewSQLiteNotificationRepository(repo, cfg.NotificationRetention, logger),
+		eventLog,
+		templates,
+		mailer,
+		logger,
+	), nil
+}
+
+// newEventSinks returns the sinks the events are dispatched to, as
+// configured: the event stream hub, the webhooks, the email notifications
+// and the log. Each sink is named as in the configuration.
+func newEventSinks(
+	cfg *config,
+	bus *events.Bus,
+	webhooks *interactor.WebhookInteractor,
+	notifier *interactor.NotificationInteractor,
+	logger *slog.Logger,
+) ([]events.Sink, error) {
+	sinks := make([]events.Sink, 0, len(cfg.EventSinks))
+	for _, name := range cfg.EventSinks {
+		var handler domain.EventHandler
+		switch name {
+		case "sse":
+			handler = bus
+		case "webhook":
+			handler = webhooks
+		case "email":
+			if notifier == nil {
+				return nil, errors.New("the email event sink requires BOOK_CLUB_SMTP_ADDR")
+			}
+			handler = notifier
+		case "log":
+			handler = events.NewLogSink(logger)
+		default:
+			return nil, fmt.Errorf("unknown event sink %q", name)
+		}
+		sinks = append(sinks, events.Sink{Name: name, Handler: handler})
+	}
+
+	return sinks, nil
+}
+
+// restore swaps the database with the snapshot given as argument.
+// The server must be stopped while restoring.
+func restore(cfg *config, logger *slog.Logger, args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		_, _ = os.Stderr.WriteString(usage)
+		os.Exit(2)
+	}
+
+	err := db.RestoreSnapshot(context.Background(), fs.Arg(0), cfg.DBPath, cfg.MigrationsPath, logger)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// importBooks imports the CSV export given as argument and writes the import report to stdout.
+func importBooks(cfg *config, logger *slog.Logger, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		_, _ = os.Stderr.WriteString(usage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	repo, err := db.NewSQLiteBookRepository(cfg.DBPath, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	err = repo.ApplyMigrations(ctx, cfg.MigrationsPath)
+	if err != nil {
+		panic(err)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		logger.With("error", err).Error("unable to open export")
+		os.Exit(1)
+	}
+	records, err := importer.ParseCSV(f)
+	_ = f.Close()
+	if err != nil {
+		logger.With("error", err).Error("unable to parse export")
+		os.Exit(1)
+	}
+
+	// The events of the imported books are left in the outbox for the server to dispatch.
+	outbox := events.NewDispatcher(db.NewSQLiteEventLog(repo, cfg.EventRetention, logger), logger)
+	i := interactor.NewBookInteractor(
+		repo,
+		db.NewSQLiteTagRepository(repo, logger),
+		db.NewSQLiteMemberRepository(repo, logger),
+		nil,
+		nil,
+		outbox,
+		logger,
+	)
+	report, err := i.ImportBooks(ctx, records)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v1.NewImportReport(report))
 }
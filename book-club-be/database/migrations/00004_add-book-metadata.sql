@@ -0,0 +1,12 @@
+ALTER TABLE books ADD COLUMN cover_url TEXT;
+ALTER TABLE books ADD COLUMN page_count INTEGER;
+ALTER TABLE books ADD COLUMN description TEXT;
+
+CREATE TABLE metadata_cache (
+    provider TEXT NOT NULL,
+    query TEXT NOT NULL,
+    -- NULL when the provider does not know the book
+    response TEXT,
+    fetched_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (provider, query)
+);
@@ -0,0 +1,18 @@
+CREATE TABLE authors (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    -- normalized name, see domain.AuthorKey
+    name_key TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE book_authors (
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    author_id TEXT NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (book_id, author_id)
+);
+
+CREATE INDEX book_authors_author_idx ON book_authors(author_id);
+
+-- books.author is kept as the display form of the linked authors,
+-- the split of the existing rows is done by the 00006_split-authors migration.
@@ -0,0 +1,19 @@
+CREATE TABLE tags (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    -- normalized name, see domain.TagKey
+    name_key TEXT NOT NULL UNIQUE,
+    parent_id TEXT REFERENCES tags(id)
+);
+
+CREATE INDEX tags_parent_idx ON tags(parent_id);
+
+CREATE TABLE book_tags (
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, tag_id)
+);
+
+CREATE INDEX book_tags_tag_idx ON book_tags(tag_id);
+
+-- the existing genres are turned into tags by the 00008_genre-tags migration.
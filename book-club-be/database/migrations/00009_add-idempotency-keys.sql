@@ -0,0 +1,13 @@
+CREATE TABLE idempotency_keys (
+    -- route and idempotency key of the request
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    -- 0 while the request is being served
+    status INTEGER NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    body BLOB,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idempotency_keys_created_idx ON idempotency_keys(created_at);
@@ -0,0 +1,6 @@
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX books_deleted_at_idx ON books(deleted_at);
+
+-- Trashed books must not prevent other books from taking their ISBN.
+DROP INDEX books_isbn_idx;
+CREATE UNIQUE INDEX books_isbn_idx ON books(isbn) WHERE deleted_at IS NULL;
@@ -0,0 +1,10 @@
+CREATE TABLE events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    book_id TEXT NOT NULL,
+    -- JSON encoded book after the change, NULL for deletions
+    book TEXT,
+    occurred_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX events_occurred_idx ON events(occurred_at);
@@ -0,0 +1,31 @@
+-- Status of the book before an update, NULL for the other events.
+ALTER TABLE events ADD COLUMN previous_status TEXT;
+
+CREATE TABLE webhooks (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- JSON encoded array of the subscribed events
+    events TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    book_id TEXT NOT NULL,
+    -- JSON encoded book at the time of the event
+    book TEXT NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries(webhook_id, created_at);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries(status, next_attempt_at);
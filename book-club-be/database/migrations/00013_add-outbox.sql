@@ -0,0 +1,13 @@
+-- The events table becomes the transactional outbox: events are stored in the
+-- transaction of the change they describe and dispatched to the sinks once
+-- committed. The existing events were already delivered.
+ALTER TABLE events ADD COLUMN dispatched_at TIMESTAMP;
+UPDATE events SET dispatched_at = occurred_at;
+CREATE INDEX events_undispatched_idx ON events(id) WHERE dispatched_at IS NULL;
+
+-- The event a delivery notifies, which a webhook receives once however many
+-- times the event is dispatched, except when redelivered on demand.
+ALTER TABLE webhook_deliveries ADD COLUMN event_id INTEGER;
+ALTER TABLE webhook_deliveries ADD COLUMN redelivery_of TEXT;
+CREATE UNIQUE INDEX webhook_deliveries_event_idx ON webhook_deliveries(webhook_id, event_id)
+    WHERE redelivery_of IS NULL;
@@ -0,0 +1,31 @@
+CREATE TABLE members (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    notify_suggestions BOOLEAN NOT NULL DEFAULT 1,
+    notify_decisions BOOLEAN NOT NULL DEFAULT 1,
+    notify_digest BOOLEAN NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX members_email_idx ON members(email COLLATE NOCASE);
+
+CREATE TABLE notifications (
+    id TEXT PRIMARY KEY,
+    member_id TEXT NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    -- identifies what the notification is about, e.g. the event or the week of a digest
+    dedup_key TEXT NOT NULL UNIQUE,
+    recipient TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX notifications_due_idx ON notifications(status, next_attempt_at);
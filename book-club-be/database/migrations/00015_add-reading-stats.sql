@@ -0,0 +1,28 @@
+-- member who suggested the book, NULL when unknown
+ALTER TABLE books ADD COLUMN suggested_by TEXT REFERENCES members(id) ON DELETE SET NULL;
+
+CREATE INDEX books_suggested_by_idx ON books(suggested_by);
+
+-- every status a book went through, kept for the reading statistics
+CREATE TABLE book_status_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    -- status before the change, NULL when the book was created
+    previous_status TEXT,
+    status TEXT NOT NULL,
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX book_status_changes_book_idx ON book_status_changes(book_id, status);
+CREATE INDEX book_status_changes_status_idx ON book_status_changes(status, changed_at);
+
+-- the history before this migration is only known from the events still stored,
+-- whose book is encoded with the Go field names until 00017_event-books
+INSERT INTO book_status_changes (book_id, previous_status, status, changed_at)
+SELECT e.book_id, e.previous_status, json_extract(e.book, '$.Status'), e.occurred_at
+FROM events e
+JOIN books b ON b.id = e.book_id
+WHERE e.book IS NOT NULL
+  AND (e.type = 'book.created'
+       OR (e.type = 'book.updated' AND e.previous_status IS NOT json_extract(e.book, '$.Status')))
+ORDER BY e.id;
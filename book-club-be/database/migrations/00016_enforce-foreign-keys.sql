@@ -0,0 +1,11 @@
+-- Foreign keys are enforced from now on: the rows left behind while they were
+-- not, which point to deleted rows, are removed or unlinked.
+DELETE FROM book_authors WHERE book_id NOT IN (SELECT id FROM books)
+    OR author_id NOT IN (SELECT id FROM authors);
+DELETE FROM book_tags WHERE book_id NOT IN (SELECT id FROM books)
+    OR tag_id NOT IN (SELECT id FROM tags);
+UPDATE tags SET parent_id = NULL WHERE parent_id NOT IN (SELECT id FROM tags);
+DELETE FROM webhook_deliveries WHERE webhook_id NOT IN (SELECT id FROM webhooks);
+DELETE FROM notifications WHERE member_id NOT IN (SELECT id FROM members);
+UPDATE books SET suggested_by = NULL WHERE suggested_by NOT IN (SELECT id FROM members);
+DELETE FROM book_status_changes WHERE book_id NOT IN (SELECT id FROM books);
@@ -0,0 +1,9 @@
+-- The progress of each sink through the outbox, so that a sink failing to
+-- handle an event does not hold back the others. An event is dispatched once
+-- every sink went past it.
+CREATE TABLE event_sinks (
+    name TEXT PRIMARY KEY,
+    -- ID of the last event handled by the sink
+    position INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
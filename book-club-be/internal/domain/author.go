@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Author is a person who wrote one or more books.
+type Author struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AuthorRepository defines the interface for persisting and retrieving authors.
+// Authors are created and linked to books by the [BookRepository].
+type AuthorRepository interface {
+	// List retrieves the authors of the books that are not in the trash, ordered by name.
+	List(ctx context.Context) ([]*Author, error)
+	// Merge moves the books of the source authors to the target author and
+	// deletes the source authors.
+	Merge(ctx context.Context, targetID string, sourceIDs []string) error
+}
+
+// authorSeparators matches the separators between the authors of a co-authored book.
+var authorSeparators = regexp.MustCompile(`\s*(?:&|;|\band\b)\s*`)
+
+// ParseAuthors splits the free-text author of a book into the names of its
+// authors, e.g. "Alan A. A. Donovan & Brian W. Kernighan". Authors can be
+// separated by "&", ";" or "and". A name written as "Last, First" is turned
+// into "First Last".
+func ParseAuthors(s string) []string {
+	names := make([]string, 0)
+	for _, n := range authorSeparators.Split(s, -1) {
+		n = strings.Join(strings.Fields(n), " ")
+		if last, first, ok := strings.Cut(n, ","); ok && !strings.Contains(first, ",") {
+			n = strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+		}
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+
+	return names
+}
+
+// AuthorKey returns the normalized form of an author name used to recognize
+// duplicates, so that "Alan A.A. Donovan" and "alan a. a. donovan" are the same author.
+func AuthorKey(name string) string {
+	return nameKey(name)
+}
+
+// nameKey lowercases name and keeps only its letters and digits, separated by single spaces.
+func nameKey(name string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, name)), " ")
+}
+
+// JoinAuthors returns the free-text author of a book written by the given authors.
+func JoinAuthors(authors []*Author) string {
+	names := make([]string, len(authors))
+	for i, a := range authors {
+		names[i] = a.Name
+	}
+
+	return strings.Join(names, " & ")
+}
@@ -0,0 +1,38 @@
+package domain
+
+// BatchOp is the kind of a [BatchOperation].
+type BatchOp string
+
+const (
+	// BatchOpCreate creates the book of the operation.
+	BatchOpCreate BatchOp = "create"
+	// BatchOpUpdate applies the book of the operation as a patch to the book with its ID.
+	BatchOpUpdate BatchOp = "update"
+	// BatchOpDelete deletes the book with the ID of the book of the operation.
+	BatchOpDelete BatchOp = "delete"
+)
+
+// BatchOperation is a single create, update or delete in a batch of book operations.
+type BatchOperation struct {
+	Op   BatchOp
+	Book *Book
+}
+
+// BatchResult describes the outcome of a single [BatchOperation].
+type BatchResult struct {
+	Op BatchOp
+	// Book is the created or updated book. It is nil for deletions and failed operations.
+	Book *Book
+	// Err reports why the operation failed.
+	Err error
+}
+
+// BatchReport summarizes a batch of book operations.
+type BatchReport struct {
+	// Results holds the outcome of the operations, in order. An atomic batch
+	// stops at the first failure, so the following operations have no result.
+	Results []BatchResult
+	// Committed reports whether the changes of the successful operations were
+	// stored. It is false for an atomic batch with a failed operation.
+	Committed bool
+}
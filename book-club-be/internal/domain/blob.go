@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// BlobInfo describes a stored blob.
+type BlobInfo struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// BlobStore defines the interface for storing binary objects, such as cover
+// images, identified by slash-separated keys. It abstracts the underlying
+// storage mechanism (e.g., local filesystem, object storage, etc.).
+type BlobStore interface {
+	// Put stores the content of r under key, replacing any previous blob.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the blob stored under key. It returns [ErrorNotFound] if there is none.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, *BlobInfo, error)
+	// Delete removes the blobs whose key starts with prefix.
+	Delete(ctx context.Context, prefix string) error
+}
@@ -1,15 +1,28 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Book is the main entity.
 type Book struct {
-	ID            string     `json:"id"`
-	Title         string     `json:"author"`
-	Author        string     `json:"title"`
-	Genre         *string    `json:"genre"`
-	PublishedYear *int       `json:"year"`
-	Status        BookStatus `json:"status"`
+	ID            string
+	Title         string
+	Author        string
+	Authors       []*Author
+	Genre         *string
+	Tags          []*Tag
+	PublishedYear *int
+	ISBN          *string
+	CoverURL      *string
+	PageCount     *int
+	Description   *string
+	Status        BookStatus
+	// SuggestedBy is the ID of the member who suggested the book, or nil if unknown.
+	SuggestedBy *string
+	// DeletedAt is when the book was moved to the trash, or nil if it was not.
+	DeletedAt *time.Time
 }
 
 // BookStatus defines the current book status for the book club.
@@ -43,9 +56,16 @@ type BookFilters struct {
 	ID            *string
 	Title         *string
 	Author        *string
+	AuthorID      *string
 	Genre         *string
 	PublishedYear *int
+	ISBN          *string
 	Status        *BookStatus
+	// Tags holds groups of tag names: a book matches when, for every group,
+	// it has at least one of the tags of the group or of their descendants.
+	Tags [][]string
+	// Trashed selects the books in the trash instead of the other ones.
+	Trashed bool
 }
 
 // BookRepository is the book persistency repository.
@@ -56,8 +76,21 @@ type BookRepository interface {
 	Create(ctx context.Context, book *Book) (*Book, error)
 	// List retrieves all books matching the provided filters.
 	List(ctx context.Context, filters *BookFilters) ([]*Book, error)
+	// Stream calls fn for each book matching the provided filters without
+	// loading them all in memory. It stops at the first error returned by fn.
+	Stream(ctx context.Context, filters *BookFilters, fn func(*Book) error) error
 	// Update modifies an existing book in the repository.
 	Update(ctx context.Context, book *Book) error
-	// Delete removes a book identified by its unique ID from the repository.
+	// Delete moves a book identified by its unique ID to the trash.
 	Delete(ctx context.Context, id string) error
+	// Restore moves a book identified by its unique ID out of the trash.
+	Restore(ctx context.Context, id string) error
+	// Purge permanently removes the books moved to the trash before the given
+	// time, and returns the IDs of the removed books.
+	Purge(ctx context.Context, before time.Time) ([]string, error)
+	// WithinTransaction calls fn with a context bound to a transaction: the
+	// calls made with it, to this repository and to the ones sharing its
+	// storage, are stored together if fn succeeds and discarded otherwise.
+	// Within a transaction, fn joins it.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
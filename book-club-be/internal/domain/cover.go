@@ -0,0 +1,11 @@
+package domain
+
+// CoverSizeOriginal is the name of the uploaded cover image, served unchanged.
+const CoverSizeOriginal = "original"
+
+// CoverSizes maps the names of the cover thumbnail sizes to their maximum width in pixels.
+var CoverSizes = map[string]int{
+	"small":  96,
+	"medium": 240,
+	"large":  480,
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorNotFound is the sentinel error when the requested entity does not exist.
+var ErrorNotFound = errors.New("not found")
+
+// ErrorForbidden is returned when an operation is not allowed on an entity in its current state.
+var ErrorForbidden = errors.New("forbidden")
+
+// ErrorUnsupportedImage is returned when an uploaded image is not in a supported format.
+var ErrorUnsupportedImage = errors.New("unsupported image: expected a JPEG, PNG, GIF or WebP image")
+
+// ErrorImageTooLarge is returned when an uploaded image exceeds the allowed dimensions.
+var ErrorImageTooLarge = errors.New("image too large")
+
+// ErrorUnknownTag is returned when a tag is not part of the tag vocabulary.
+var ErrorUnknownTag = errors.New("unknown tag")
+
+// ErrorDuplicateTag is returned when a tag is given the name of another tag.
+var ErrorDuplicateTag = errors.New("a tag with the same name already exists")
+
+// ErrorTagCycle is returned when a tag would become a descendant of itself.
+var ErrorTagCycle = errors.New("a tag cannot be nested under itself or its descendants")
+
+// DuplicateISBNError is returned when a book is stored with the ISBN of another book.
+type DuplicateISBNError struct {
+	ISBN       string
+	ExistingID string
+}
+
+func (e *DuplicateISBNError) Error() string {
+	return fmt.Sprintf("a book with ISBN %s already exists with id %s", e.ISBN, e.ExistingID)
+}
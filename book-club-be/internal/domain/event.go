@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// EventType is the kind of change described by an [Event].
+type EventType string
+
+const (
+	// EventBookCreated is published when a book is created.
+	EventBookCreated EventType = "book.created"
+	// EventBookUpdated is published when the fields of a book change.
+	EventBookUpdated EventType = "book.updated"
+	// EventBookDeleted is published when a book is moved to the trash.
+	EventBookDeleted EventType = "book.deleted"
+	// EventBookRestored is published when a book is moved out of the trash.
+	EventBookRestored EventType = "book.restored"
+)
+
+// Event describes a change to a book.
+type Event struct {
+	// ID orders the events: it is greater than the ID of every event
+	// published before. It is set when the event is stored.
+	ID     int64
+	Type   EventType
+	BookID string
+	// Book is the book after the change. It is nil for deletions.
+	Book *Book
+	// PreviousStatus is the status of the book before an update, empty for
+	// the other events.
+	PreviousStatus BookStatus
+	OccurredAt     time.Time
+}
+
+// EventPublisher defines the interface for publishing the changes to books
+// through a transactional outbox, so that an event is dispatched if and only
+// if the change it describes is stored.
+type EventPublisher interface {
+	// Publish stores event in the outbox, setting its ID. When ctx is bound
+	// to a transaction, the event is stored in it together with the change.
+	Publish(ctx context.Context, event *Event) error
+	// Notify signals that published events were committed and can be dispatched.
+	Notify()
+}
+
+// EventHandler defines the interface of the sinks the dispatched events are
+// delivered to. Events are delivered at least once: a sink must recognize
+// the events it already handled by their ID.
+type EventHandler interface {
+	// HandleEvent is called with each dispatched event, in order.
+	HandleEvent(ctx context.Context, event *Event) error
+}
+
+// EventLog defines the interface for persisting the published events. It
+// is the outbox the events are dispatched from, and lets the subscribers of
+// the event stream resume from the last event they received.
+type EventLog interface {
+	// Append stores event and sets its ID.
+	Append(ctx context.Context, event *Event) error
+	// ListFrom retrieves up to limit events with an ID greater than or equal
+	// to id, ordered by ID.
+	ListFrom(ctx context.Context, id int64, limit int) ([]*Event, error)
+	// ListSince retrieves the events that occurred at or after since, ordered by ID.
+	ListSince(ctx context.Context, since time.Time) ([]*Event, error)
+	// ListUndispatched retrieves up to limit events not yet dispatched, ordered by ID.
+	ListUndispatched(ctx context.Context, limit int) ([]*Event, error)
+	// MarkDispatched records that the events up to the one identified by id
+	// were delivered to every sink.
+	MarkDispatched(ctx context.Context, id int64) error
+	// SinkPosition retrieves the ID of the last event handled by the sink
+	// named sink. A sink never seen starts before the first event not yet dispatched.
+	SinkPosition(ctx context.Context, sink string) (int64, error)
+	// SetSinkPosition records that the sink named sink handled the events up
+	// to the one identified by id.
+	SetSinkPosition(ctx context.Context, sink string, id int64) error
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the outcome of a request sent with an idempotency
+// key, kept to answer the retries of the request without repeating it.
+type IdempotencyRecord struct {
+	// Key is the idempotency key, scoped to the route of the request.
+	Key string
+	// Fingerprint identifies the request, so that the key cannot be reused
+	// for a different one.
+	Fingerprint string
+	// Status is the status code of the response, or zero while the request
+	// is being served.
+	Status      int
+	ContentType string
+	Location    string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// IdempotencyRepository defines the interface for storing the outcome of
+// the requests sent with an idempotency key.
+type IdempotencyRepository interface {
+	// Reserve stores record, which is in progress, unless a record with the
+	// same key exists and has not expired. It returns the existing record,
+	// or nil if record was stored. A record left in progress, by a request
+	// that never completed, expires after a short lease.
+	Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the response of the reserved record.
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+	// Release deletes the record identified by key, so that the request can be retried.
+	Release(ctx context.Context, key string) error
+}
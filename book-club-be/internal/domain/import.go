@@ -0,0 +1,30 @@
+package domain
+
+// ImportRecord is a book read from an external library export, such as the
+// Goodreads or StoryGraph CSV files.
+type ImportRecord struct {
+	// Book is the book described by the record. It is nil when Err is set.
+	Book *Book
+	// Err reports why the record could not be read.
+	Err error
+	// ISBN is the ISBN found in the export, if any, in its normalized ISBN-13 form.
+	ISBN string
+	// Line is the line of the record in the export.
+	Line int
+}
+
+// ImportResult describes the outcome of importing a single [ImportRecord].
+type ImportResult struct {
+	BookID string
+	Title  string
+	Author string
+	Reason string
+	Line   int
+}
+
+// ImportReport summarizes an import, grouping the results by outcome.
+type ImportReport struct {
+	Created []ImportResult
+	Skipped []ImportResult
+	Failed  []ImportResult
+}
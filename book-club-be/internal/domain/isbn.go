@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorInvalidISBN is returned when a string is not a valid ISBN-10 or ISBN-13.
+var ErrorInvalidISBN = errors.New("invalid ISBN: expected 10 or 13 digits with a valid check digit")
+
+// NormalizeISBN validates an ISBN-10 or ISBN-13, ignoring hyphens and spaces,
+// and returns it in its canonical ISBN-13 form.
+func NormalizeISBN(s string) (string, error) {
+	s = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+
+	switch len(s) {
+	case 10:
+		if !isValidISBN10(s) {
+			return "", ErrorInvalidISBN
+		}
+		isbn := "978" + s[:9]
+		return isbn + string(isbn13CheckDigit(isbn)), nil
+	case 13:
+		if !isDigits(s) || isbn13CheckDigit(s[:12]) != s[12] {
+			return "", ErrorInvalidISBN
+		}
+		return s, nil
+	}
+
+	return "", ErrorInvalidISBN
+}
+
+// isValidISBN10 reports whether s is an ISBN-10 with a valid check digit,
+// which may be X for 10.
+func isValidISBN10(s string) bool {
+	if !isDigits(s[:9]) {
+		return false
+	}
+
+	sum := 0
+	for i := range 9 {
+		sum += (10 - i) * int(s[i]-'0')
+	}
+	switch c := s[9]; {
+	case c == 'X':
+		sum += 10
+	case c >= '0' && c <= '9':
+		sum += int(c - '0')
+	default:
+		return false
+	}
+
+	return sum%11 == 0
+}
+
+// isbn13CheckDigit computes the check digit for the first 12 digits of an ISBN-13.
+func isbn13CheckDigit(s string) byte {
+	sum := 0
+	for i := range 12 {
+		d := int(s[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
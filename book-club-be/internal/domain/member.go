@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrorDuplicateEmail is returned when a member is given the email of another member.
+var ErrorDuplicateEmail = errors.New("a member with the same email already exists")
+
+// Member is a person taking part in the book club, who can be notified by email.
+type Member struct {
+	ID            string
+	Name          string
+	Email         string
+	Notifications NotificationPreferences
+	CreatedAt     time.Time
+}
+
+// NotificationPreferences lists the emails a member wants to receive.
+type NotificationPreferences struct {
+	// Suggestions is set to be notified of each book suggested to the club.
+	Suggestions bool
+	// Decisions is set to be notified when the club accepts or discards a suggested book.
+	Decisions bool
+	// Digest is set to receive the weekly digest of the activity of the club.
+	Digest bool
+}
+
+// Wants reports whether the preferences include the notifications of the given kind.
+func (p NotificationPreferences) Wants(kind NotificationKind) bool {
+	switch kind {
+	case NotificationSuggestion:
+		return p.Suggestions
+	case NotificationDecision:
+		return p.Decisions
+	case NotificationDigest:
+		return p.Digest
+	default:
+		return false
+	}
+}
+
+// MemberPatch holds the changes to a member: nil fields are left unchanged.
+type MemberPatch struct {
+	Name        *string
+	Email       *string
+	Suggestions *bool
+	Decisions   *bool
+	Digest      *bool
+}
+
+// Apply copies the fields set in the patch to member.
+func (p *MemberPatch) Apply(member *Member) {
+	if p.Name != nil {
+		member.Name = *p.Name
+	}
+	if p.Email != nil {
+		member.Email = *p.Email
+	}
+	if p.Suggestions != nil {
+		member.Notifications.Suggestions = *p.Suggestions
+	}
+	if p.Decisions != nil {
+		member.Notifications.Decisions = *p.Decisions
+	}
+	if p.Digest != nil {
+		member.Notifications.Digest = *p.Digest
+	}
+}
+
+// MemberRepository defines the interface for managing the members of the club.
+type MemberRepository interface {
+	// Create stores a new member, setting its ID.
+	Create(ctx context.Context, member *Member) (*Member, error)
+	// Get retrieves the member identified by id.
+	Get(ctx context.Context, id string) (*Member, error)
+	// List retrieves all the members, ordered by name.
+	List(ctx context.Context) ([]*Member, error)
+	// Update stores the name, email and notification preferences of an existing member.
+	Update(ctx context.Context, member *Member) error
+	// Delete removes the member identified by id together with their pending
+	// notifications, and unlinks the books they suggested.
+	Delete(ctx context.Context, id string) error
+}
+
+// NormalizeEmail trims email and lowercases its domain, which is case
+// insensitive, so that the same address is always stored the same way.
+func NormalizeEmail(email string) string {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+
+	return email[:at+1] + strings.ToLower(email[at+1:])
+}
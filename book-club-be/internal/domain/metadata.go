@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+)
+
+// BookMetadata is the bibliographic information known about a book by an external catalog.
+type BookMetadata struct {
+	PublishedYear *int   `json:"published_year,omitempty"`
+	PageCount     *int   `json:"page_count,omitempty"`
+	ISBN          string `json:"isbn,omitempty"`
+	Title         string `json:"title,omitempty"`
+	Author        string `json:"author,omitempty"`
+	Genre         string `json:"genre,omitempty"`
+	CoverURL      string `json:"cover_url,omitempty"`
+	Description   string `json:"description,omitempty"`
+}
+
+// MetadataQuery identifies the book to look up. The ISBN, when set, takes
+// precedence over the title and author.
+type MetadataQuery struct {
+	ISBN   string
+	Title  string
+	Author string
+}
+
+// MetadataProvider defines the interface of an external catalog, such as
+// Open Library or Google Books, used to complete the information of a book.
+type MetadataProvider interface {
+	// Name identifies the provider.
+	Name() string
+	// Lookup retrieves the metadata of the book matching the query.
+	// It returns [ErrorNotFound] if the catalog does not know the book.
+	Lookup(ctx context.Context, query MetadataQuery) (*BookMetadata, error)
+}
+
+// Enrich fills the fields of book that are empty with the metadata. Fields
+// already set are never overwritten. It reports whether book was changed.
+func (book *Book) Enrich(m *BookMetadata) bool {
+	changed := false
+	setString := func(dst *string, v string) {
+		if *dst == "" && v != "" {
+			*dst, changed = v, true
+		}
+	}
+	setOptional := func(dst **string, v string) {
+		if *dst == nil && v != "" {
+			*dst, changed = &v, true
+		}
+	}
+
+	setString(&book.Title, m.Title)
+	setString(&book.Author, m.Author)
+	setOptional(&book.Genre, m.Genre)
+	setOptional(&book.ISBN, m.ISBN)
+	setOptional(&book.CoverURL, m.CoverURL)
+	setOptional(&book.Description, m.Description)
+
+	if book.PublishedYear == nil && m.PublishedYear != nil {
+		book.PublishedYear, changed = m.PublishedYear, true
+	}
+	if book.PageCount == nil && m.PageCount != nil {
+		book.PageCount, changed = m.PageCount, true
+	}
+
+	return changed
+}
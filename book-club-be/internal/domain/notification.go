@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// NotificationKind is the reason a member is sent an email.
+type NotificationKind string
+
+const (
+	// NotificationSuggestion is sent when a book is suggested to the club.
+	NotificationSuggestion NotificationKind = "suggestion"
+	// NotificationDecision is sent when the club closes the vote on a
+	// suggested book, accepting it for reading or discarding it.
+	NotificationDecision NotificationKind = "decision"
+	// NotificationDigest is the weekly digest of the activity of the club.
+	NotificationDigest NotificationKind = "digest"
+)
+
+// Email is a message with a plain text and an HTML version of its body.
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notification is an email sent, or to be sent, to a member.
+type Notification struct {
+	ID       string
+	MemberID string
+	Kind     NotificationKind
+	// DedupKey identifies what the notification is about, so that a member
+	// is not notified twice of the same thing.
+	DedupKey string
+	Email    *Email
+	Status   DeliveryStatus
+	Attempts int
+	// NextAttemptAt is when a pending notification is tried again.
+	NextAttemptAt *time.Time
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
+
+// NotificationRepository defines the interface for the queue of the emails
+// to send to the members.
+type NotificationRepository interface {
+	// Enqueue stores a new pending notification, setting its ID. It is
+	// ignored if a notification with the same DedupKey exists.
+	Enqueue(ctx context.Context, notification *Notification) error
+	// Due retrieves up to limit pending notifications whose next attempt is
+	// due at now, oldest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]*Notification, error)
+	// Update stores the outcome of an attempt of sending a notification.
+	Update(ctx context.Context, notification *Notification) error
+}
+
+// Digest is the activity of the club over a period, from From included to
+// To excluded.
+type Digest struct {
+	From      time.Time
+	To        time.Time
+	Suggested []*Book
+	Started   []*Book
+	Completed []*Book
+	Discarded []*Book
+}
+
+// Empty reports whether nothing happened over the period of the digest.
+func (d *Digest) Empty() bool {
+	return len(d.Suggested)+len(d.Started)+len(d.Completed)+len(d.Discarded) == 0
+}
+
+// EmailTemplates defines the interface for rendering the emails sent to the members.
+type EmailTemplates interface {
+	// Suggestion renders the email telling member that book was suggested.
+	Suggestion(member *Member, book *Book) (*Email, error)
+	// Decision renders the email telling member that the suggested book was
+	// accepted for reading or discarded, as its status tells.
+	Decision(member *Member, book *Book) (*Email, error)
+	// Digest renders the weekly digest sent to member.
+	Digest(member *Member, digest *Digest) (*Email, error)
+}
+
+// Mailer defines the interface for sending emails.
+type Mailer interface {
+	// Send delivers email to its recipient.
+	Send(ctx context.Context, email *Email) error
+}
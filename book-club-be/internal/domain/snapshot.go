@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Snapshot is a point-in-time copy of the whole database.
+type Snapshot struct {
+	CreatedAt time.Time
+	Name      string
+	Size      int64
+}
+
+// SnapshotRepository defines the interface for taking and managing database snapshots.
+type SnapshotRepository interface {
+	// Create takes a new consistent snapshot of the database while it is online.
+	Create(ctx context.Context) (*Snapshot, error)
+	// List retrieves all the stored snapshots, newest first.
+	List(ctx context.Context) ([]*Snapshot, error)
+	// Delete removes the snapshot identified by name.
+	Delete(ctx context.Context, name string) error
+}
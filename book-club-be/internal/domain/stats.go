@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ReadingStats summarizes the reading history of the club. The books in the
+// trash are left out.
+type ReadingStats struct {
+	// Books is the number of books for each status.
+	Books map[BookStatus]int
+	// CompletedByYear and CompletedByMonth count the times a book was
+	// completed, oldest period first.
+	CompletedByYear  []PeriodCount
+	CompletedByMonth []PeriodCount
+	// Reading is the time the club took to read a book, from the last time
+	// it started reading it to its completion.
+	Reading ReadingTime
+	// Genres and Authors count the books of each genre and author, most
+	// common first. Books without a genre are counted under an empty genre.
+	Genres  []GenreCount
+	Authors []AuthorCount
+	// Suggestions counts the decisions on the suggested books.
+	Suggestions SuggestionStats
+	// Members lists the books suggested by each member, most active first.
+	Members []MemberContribution
+}
+
+// PeriodCount is a number of events over a year, or over a month when Month is not zero.
+type PeriodCount struct {
+	Year  int
+	Month time.Month
+	Count int
+}
+
+// ReadingTime is the time taken to read the Completed books.
+type ReadingTime struct {
+	Completed int
+	Average   time.Duration
+	Shortest  time.Duration
+	Longest   time.Duration
+}
+
+// GenreCount is the number of books of a genre.
+type GenreCount struct {
+	Genre string
+	Count int
+}
+
+// AuthorCount is the number of books written by an author.
+type AuthorCount struct {
+	Author *Author
+	Count  int
+}
+
+// SuggestionStats counts the suggested books accepted for reading and the
+// discarded ones, and the ones still waiting for a decision.
+type SuggestionStats struct {
+	Pending   int
+	Accepted  int
+	Discarded int
+}
+
+// AcceptanceRate returns the share of the decided suggestions that were
+// accepted, and false if no suggestion was decided.
+func (s SuggestionStats) AcceptanceRate() (float64, bool) {
+	decided := s.Accepted + s.Discarded
+	if decided == 0 {
+		return 0, false
+	}
+
+	return float64(s.Accepted) / float64(decided), true
+}
+
+// MemberContribution is the number of books suggested by a member, and how
+// many of them the club read.
+type MemberContribution struct {
+	Member    *Member
+	Suggested int
+	// Accepted counts the suggested books the club is reading or completed.
+	Accepted  int
+	Completed int
+}
+
+// StatsRepository defines the interface for computing the reading statistics.
+type StatsRepository interface {
+	// ReadingStats computes the statistics of the books not in the trash.
+	ReadingStats(ctx context.Context) (*ReadingStats, error)
+}
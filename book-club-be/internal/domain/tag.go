@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tag is an entry of the vocabulary used to classify books, such as a genre.
+// Tags form a hierarchy, e.g. "Historical Fiction" under "Fiction".
+type Tag struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+// TagRepository defines the interface for managing the tag vocabulary.
+// Tags are linked to books by the [BookRepository].
+type TagRepository interface {
+	// List retrieves all tags, ordered by name.
+	List(ctx context.Context) ([]*Tag, error)
+	// Create adds a new tag to the vocabulary.
+	Create(ctx context.Context, tag *Tag) (*Tag, error)
+	// Update renames or moves an existing tag.
+	Update(ctx context.Context, tag *Tag) error
+	// Merge moves the books and the children of the source tags to the
+	// target tag and deletes the source tags.
+	Merge(ctx context.Context, targetID string, sourceIDs []string) error
+}
+
+// TagKey returns the normalized form of a tag name used to recognize
+// duplicates, so that "Sci-Fi" and "sci fi" are the same tag.
+func TagKey(name string) string {
+	return nameKey(name)
+}
+
+// TagVocabulary indexes the known tags by their [TagKey].
+type TagVocabulary map[string]*Tag
+
+// NewTagVocabulary creates a TagVocabulary with the given tags.
+func NewTagVocabulary(tags []*Tag) TagVocabulary {
+	v := make(TagVocabulary, len(tags))
+	for _, t := range tags {
+		v[TagKey(t.Name)] = t
+	}
+
+	return v
+}
+
+// Resolve returns the tags with the given names, without repetitions.
+// It returns [ErrorUnknownTag] if a name is not part of the vocabulary.
+func (v TagVocabulary) Resolve(names []string) ([]*Tag, error) {
+	tags := make([]*Tag, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		t, ok := v[TagKey(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrorUnknownTag, name)
+		}
+		if _, ok := seen[t.ID]; ok {
+			continue
+		}
+		seen[t.ID] = struct{}{}
+		tags = append(tags, t)
+	}
+
+	return tags, nil
+}
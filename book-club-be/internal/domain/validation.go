@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ValidationCode identifies the rule broken by an invalid field.
+type ValidationCode string
+
+const (
+	// ValidationRequired means a required field is missing.
+	ValidationRequired ValidationCode = "required"
+	// ValidationEmpty means an optional field is set but empty.
+	ValidationEmpty ValidationCode = "empty"
+	// ValidationInvalid means a field does not have an acceptable value.
+	ValidationInvalid ValidationCode = "invalid"
+	// ValidationFuture means a date or year is in the future.
+	ValidationFuture ValidationCode = "future"
+	// ValidationUnknown means a field refers to an entity that does not exist.
+	ValidationUnknown ValidationCode = "unknown"
+	// ValidationCycle means a field would make a hierarchy loop on itself.
+	ValidationCycle ValidationCode = "cycle"
+)
+
+// FieldError reports that a field breaks a validation rule. Fields are named
+// as in the JSON representation of their entity.
+type FieldError struct {
+	Field   string
+	Code    ValidationCode
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationError lists all the invalid fields of an entity or request.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Message
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors, so that they can be inspected with [errors.As].
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f
+	}
+
+	return errs
+}
+
+// Add records that field breaks the rule identified by code.
+func (e *ValidationError) Add(field string, code ValidationCode, message string) {
+	e.Fields = append(e.Fields, &FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns e if any invalid field was recorded, nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Normalize trims the title and author of book and converts a valid ISBN-10
+// to its ISBN-13 form. Invalid values are left for [Book.Validate] to report.
+func (book *Book) Normalize() {
+	book.Title = strings.TrimSpace(book.Title)
+	book.Author = strings.TrimSpace(book.Author)
+
+	if book.ISBN != nil {
+		if isbn, err := NormalizeISBN(*book.ISBN); err == nil {
+			book.ISBN = &isbn
+		}
+	}
+}
+
+// Validate checks the rules every stored book must satisfy. It returns a
+// [ValidationError] listing all the invalid fields, or nil.
+func (book *Book) Validate() error {
+	var v ValidationError
+	if book.Title == "" {
+		v.Add("title", ValidationRequired, "title cannot be empty")
+	}
+	if book.Author == "" {
+		v.Add("author", ValidationRequired, "author cannot be empty")
+	}
+	if book.Genre != nil && *book.Genre == "" {
+		v.Add("genre", ValidationEmpty, "if specified, genre cannot be empty")
+	}
+	if book.PublishedYear != nil && *book.PublishedYear > time.Now().Year() {
+		v.Add("published_year", ValidationFuture, "if specified, published_year cannot be in the future")
+	}
+	if book.PageCount != nil && *book.PageCount <= 0 {
+		v.Add("page_count", ValidationInvalid, "if specified, page_count must be positive")
+	}
+	if book.ISBN != nil {
+		if _, err := NormalizeISBN(*book.ISBN); err != nil {
+			v.Add("isbn", ValidationInvalid, fmt.Sprintf("if specified, isbn must be valid: %v", err))
+		}
+	}
+	if book.SuggestedBy != nil && *book.SuggestedBy == "" {
+		v.Add("suggested_by", ValidationEmpty, "if specified, suggested_by cannot be empty")
+	}
+	if _, ok := StringToBookStatusMap[string(book.Status)]; !ok {
+		validStatuses := slices.Sorted(maps.Keys(StringToBookStatusMap))
+		v.Add("status", ValidationInvalid, fmt.Sprintf("status must be one of %v", validStatuses))
+	}
+
+	return v.Err()
+}
+
+// Validate checks the rules every tag must satisfy. It returns a
+// [ValidationError] listing all the invalid fields, or nil.
+func (t *Tag) Validate() error {
+	var v ValidationError
+	if TagKey(t.Name) == "" {
+		v.Add("name", ValidationRequired, "name cannot be empty")
+	}
+	if t.ParentID != nil && *t.ParentID == "" {
+		v.Add("parent_id", ValidationEmpty, "if specified, parent_id cannot be empty")
+	}
+
+	return v.Err()
+}
+
+// Validate checks that the member has a name and a valid email address. It
+// returns a [ValidationError] listing all the invalid fields.
+func (m *Member) Validate() error {
+	var v ValidationError
+	if strings.TrimSpace(m.Name) == "" {
+		v.Add("name", ValidationRequired, "name cannot be empty")
+	}
+	if m.Email == "" {
+		v.Add("email", ValidationRequired, "email is required")
+	} else if addr, err := mail.ParseAddress(m.Email); err != nil || addr.Address != m.Email {
+		v.Add("email", ValidationInvalid, "email must be a valid email address, without display name")
+	}
+
+	return v.Err()
+}
@@ -0,0 +1,326 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// validBook returns a book satisfying every rule of [Book.Validate].
+func validBook() *Book {
+	return &Book{
+		Title:  "Dune",
+		Author: "Frank Herbert",
+		Status: BookStatusSuggested,
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// bookCase is a case of a table testing one rule of [Book.Validate]: change
+// is applied to a valid book, which is then normalized as the interactors
+// do, and want is the expected field error, or nil if the book stays valid.
+type bookCase struct {
+	name   string
+	change func(*Book)
+	want   *FieldError
+}
+
+// runBookCases runs the cases of a table testing one rule of [Book.Validate].
+func runBookCases(t *testing.T, cases []bookCase) {
+	t.Helper()
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			book := validBook()
+			tc.change(book)
+			book.Normalize()
+			assertFieldError(t, book.Validate(), tc.want)
+		})
+	}
+}
+
+// assertFieldError checks that err is nil when want is nil, and a
+// [ValidationError] holding only want otherwise.
+func assertFieldError(t *testing.T, err error, want *FieldError) {
+	t.Helper()
+
+	if want == nil {
+		if err != nil {
+			t.Fatalf("Validate() error = %v, want nil", err)
+		}
+		return
+	}
+
+	var v *ValidationError
+	if !errors.As(err, &v) {
+		t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+	}
+	if len(v.Fields) != 1 {
+		t.Fatalf("Validate() fields = %d (%v), want 1", len(v.Fields), err)
+	}
+
+	got := v.Fields[0]
+	if got.Field != want.Field {
+		t.Errorf("Field = %q, want %q", got.Field, want.Field)
+	}
+	if got.Code != want.Code {
+		t.Errorf("Code = %q, want %q", got.Code, want.Code)
+	}
+	if got.Message != want.Message {
+		t.Errorf("Message = %q, want %q", got.Message, want.Message)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr != got {
+		t.Errorf("errors.As(err, *FieldError) = %v, want the field error", fieldErr)
+	}
+}
+
+func TestBookValidateTitle(t *testing.T) {
+	runBookCases(t, []bookCase{
+		{name: "set", change: func(*Book) {}},
+		{name: "single character", change: func(b *Book) { b.Title = "Z" }},
+		{
+			name:   "empty",
+			change: func(b *Book) { b.Title = "" },
+			want:   &FieldError{Field: "title", Code: ValidationRequired, Message: "title cannot be empty"},
+		},
+		{
+			name:   "only spaces",
+			change: func(b *Book) { b.Title = " \t " },
+			want:   &FieldError{Field: "title", Code: ValidationRequired, Message: "title cannot be empty"},
+		},
+	})
+}
+
+func TestBookValidateAuthor(t *testing.T) {
+	runBookCases(t, []bookCase{
+		{name: "single character", change: func(b *Book) { b.Author = "X" }},
+		{
+			name:   "empty",
+			change: func(b *Book) { b.Author = "" },
+			want:   &FieldError{Field: "author", Code: ValidationRequired, Message: "author cannot be empty"},
+		},
+		{
+			name:   "only spaces",
+			change: func(b *Book) { b.Author = "   " },
+			want:   &FieldError{Field: "author", Code: ValidationRequired, Message: "author cannot be empty"},
+		},
+	})
+}
+
+func TestBookValidateGenre(t *testing.T) {
+	runBookCases(t, []bookCase{
+		{name: "unset", change: func(b *Book) { b.Genre = nil }},
+		{name: "set", change: func(b *Book) { b.Genre = ptr("Science Fiction") }},
+		{
+			name:   "empty",
+			change: func(b *Book) { b.Genre = ptr("") },
+			want: &FieldError{
+				Field: "genre", Code: ValidationEmpty, Message: "if specified, genre cannot be empty",
+			},
+		},
+	})
+}
+
+func TestBookValidateYear(t *testing.T) {
+	year := time.Now().Year()
+	runBookCases(t, []bookCase{
+		{name: "unset", change: func(b *Book) { b.PublishedYear = nil }},
+		{name: "past", change: func(b *Book) { b.PublishedYear = ptr(1965) }},
+		{name: "current", change: func(b *Book) { b.PublishedYear = ptr(year) }},
+		{
+			name:   "future",
+			change: func(b *Book) { b.PublishedYear = ptr(year + 1) },
+			want: &FieldError{
+				Field:   "published_year",
+				Code:    ValidationFuture,
+				Message: "if specified, published_year cannot be in the future",
+			},
+		},
+	})
+}
+
+func TestBookValidatePageCount(t *testing.T) {
+	want := &FieldError{
+		Field: "page_count", Code: ValidationInvalid, Message: "if specified, page_count must be positive",
+	}
+	runBookCases(t, []bookCase{
+		{name: "unset", change: func(b *Book) { b.PageCount = nil }},
+		{name: "positive", change: func(b *Book) { b.PageCount = ptr(412) }},
+		{name: "zero", change: func(b *Book) { b.PageCount = ptr(0) }, want: want},
+		{name: "negative", change: func(b *Book) { b.PageCount = ptr(-1) }, want: want},
+	})
+}
+
+func TestBookValidateISBN(t *testing.T) {
+	want := &FieldError{
+		Field:   "isbn",
+		Code:    ValidationInvalid,
+		Message: "if specified, isbn must be valid: " + ErrorInvalidISBN.Error(),
+	}
+	runBookCases(t, []bookCase{
+		{name: "unset", change: func(b *Book) { b.ISBN = nil }},
+		{name: "ISBN-13", change: func(b *Book) { b.ISBN = ptr("9780441172719") }},
+		{name: "ISBN-13 with hyphens", change: func(b *Book) { b.ISBN = ptr("978-0-441-17271-9") }},
+		{name: "ISBN-10", change: func(b *Book) { b.ISBN = ptr("0441172717") }},
+		{name: "ISBN-10 with X check digit", change: func(b *Book) { b.ISBN = ptr("080442957X") }},
+		{name: "empty", change: func(b *Book) { b.ISBN = ptr("") }, want: want},
+		{name: "wrong check digit", change: func(b *Book) { b.ISBN = ptr("9780441172718") }, want: want},
+		{name: "wrong length", change: func(b *Book) { b.ISBN = ptr("97804411727") }, want: want},
+		{name: "letters", change: func(b *Book) { b.ISBN = ptr("97804411727AB") }, want: want},
+	})
+}
+
+func TestBookValidateSuggestedBy(t *testing.T) {
+	runBookCases(t, []bookCase{
+		{name: "unset", change: func(b *Book) { b.SuggestedBy = nil }},
+		{name: "set", change: func(b *Book) { b.SuggestedBy = ptr("member-1") }},
+		{
+			name:   "empty",
+			change: func(b *Book) { b.SuggestedBy = ptr("") },
+			want: &FieldError{
+				Field: "suggested_by", Code: ValidationEmpty, Message: "if specified, suggested_by cannot be empty",
+			},
+		},
+	})
+}
+
+func TestBookValidateStatus(t *testing.T) {
+	want := &FieldError{
+		Field:   "status",
+		Code:    ValidationInvalid,
+		Message: "status must be one of [COMPLETED DISCARDED READING SAVED SUGGESTED]",
+	}
+	cases := make([]bookCase, 0)
+	for name, status := range StringToBookStatusMap {
+		cases = append(cases, bookCase{name: name, change: func(b *Book) { b.Status = status }})
+	}
+	cases = append(cases,
+		bookCase{name: "empty", change: func(b *Book) { b.Status = "" }, want: want},
+		bookCase{name: "lowercase", change: func(b *Book) { b.Status = "reading" }, want: want},
+		bookCase{name: "unknown", change: func(b *Book) { b.Status = "ABANDONED" }, want: want},
+	)
+	runBookCases(t, cases)
+}
+
+func TestBookValidateListsEveryInvalidField(t *testing.T) {
+	book := &Book{PublishedYear: ptr(time.Now().Year() + 1), Status: "UNKNOWN"}
+
+	var v *ValidationError
+	if !errors.As(book.Validate(), &v) {
+		t.Fatalf("Validate() error is not a *ValidationError")
+	}
+
+	want := []string{"title", "author", "published_year", "status"}
+	if len(v.Fields) != len(want) {
+		t.Fatalf("Validate() fields = %d (%v), want %d", len(v.Fields), v, len(want))
+	}
+	for i, f := range v.Fields {
+		if f.Field != want[i] {
+			t.Errorf("Fields[%d] = %q, want %q", i, f.Field, want[i])
+		}
+	}
+}
+
+func TestBookNormalizeISBN(t *testing.T) {
+	book := validBook()
+	book.ISBN = ptr("0-441-17271-7")
+	book.Normalize()
+
+	if book.ISBN == nil || *book.ISBN != "9780441172719" {
+		t.Errorf("ISBN = %v, want 9780441172719", book.ISBN)
+	}
+}
+
+func TestTagValidateName(t *testing.T) {
+	want := &FieldError{Field: "name", Code: ValidationRequired, Message: "name cannot be empty"}
+	tests := []struct {
+		name string
+		tag  string
+		want *FieldError
+	}{
+		{name: "set", tag: "Science Fiction"},
+		{name: "single character", tag: "Y"},
+		{name: "surrounded by spaces", tag: "  Fantasy  "},
+		{name: "empty", tag: "", want: want},
+		{name: "only spaces", tag: "   ", want: want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := &Tag{Name: tt.tag}
+			assertFieldError(t, tag.Validate(), tt.want)
+		})
+	}
+}
+
+func TestTagValidateParentID(t *testing.T) {
+	tests := []struct {
+		name     string
+		parentID *string
+		want     *FieldError
+	}{
+		{name: "unset"},
+		{name: "set", parentID: ptr("tag-1")},
+		{
+			name:     "empty",
+			parentID: ptr(""),
+			want: &FieldError{
+				Field: "parent_id", Code: ValidationEmpty, Message: "if specified, parent_id cannot be empty",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := &Tag{Name: "Fantasy", ParentID: tt.parentID}
+			assertFieldError(t, tag.Validate(), tt.want)
+		})
+	}
+}
+
+func TestMemberValidate(t *testing.T) {
+	invalidEmail := &FieldError{
+		Field:   "email",
+		Code:    ValidationInvalid,
+		Message: "email must be a valid email address, without display name",
+	}
+	tests := []struct {
+		name   string
+		member Member
+		want   *FieldError
+	}{
+		{name: "valid", member: Member{Name: "Ada", Email: "ada@example.com"}},
+		{
+			name:   "empty name",
+			member: Member{Name: "", Email: "ada@example.com"},
+			want:   &FieldError{Field: "name", Code: ValidationRequired, Message: "name cannot be empty"},
+		},
+		{
+			name:   "name of only spaces",
+			member: Member{Name: "  ", Email: "ada@example.com"},
+			want:   &FieldError{Field: "name", Code: ValidationRequired, Message: "name cannot be empty"},
+		},
+		{
+			name:   "empty email",
+			member: Member{Name: "Ada"},
+			want:   &FieldError{Field: "email", Code: ValidationRequired, Message: "email is required"},
+		},
+		{name: "email without domain", member: Member{Name: "Ada", Email: "ada"}, want: invalidEmail},
+		{
+			name:   "email with display name",
+			member: Member{Name: "Ada", Email: "Ada <ada@example.com>"},
+			want:   invalidEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFieldError(t, tt.member.Validate(), tt.want)
+		})
+	}
+}
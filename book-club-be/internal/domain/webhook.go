@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// WebhookEvent is a step of the life of a book notified to the webhooks.
+type WebhookEvent string
+
+const (
+	// WebhookBookSuggested is sent when a book is suggested to the club.
+	WebhookBookSuggested WebhookEvent = "book.suggested"
+	// WebhookBookReading is sent when the club starts reading a book.
+	WebhookBookReading WebhookEvent = "book.reading"
+	// WebhookBookCompleted is sent when the club finishes reading a book.
+	WebhookBookCompleted WebhookEvent = "book.completed"
+)
+
+// WebhookEvents maps the statuses starting a step of the life of a book to
+// the [WebhookEvent] notifying it.
+var WebhookEvents = map[BookStatus]WebhookEvent{
+	BookStatusSuggested: WebhookBookSuggested,
+	BookStatusReading:   WebhookBookReading,
+	BookStatusCompleted: WebhookBookCompleted,
+}
+
+// MinWebhookSecretLength is the minimum length of a webhook secret chosen by the admin.
+const MinWebhookSecretLength = 16
+
+// Webhook is a URL receiving the events of the book club.
+type Webhook struct {
+	ID  string
+	URL string
+	// Secret is the key signing the payloads, so that the receiver can
+	// verify they come from the book club.
+	Secret    string
+	Events    []WebhookEvent
+	CreatedAt time.Time
+}
+
+// Validate checks that the webhook has an absolute HTTP URL and known events,
+// and that its secret, if set, is long enough. It returns a [ValidationError]
+// listing all the invalid fields.
+func (w *Webhook) Validate() error {
+	var v ValidationError
+
+	if w.URL == "" {
+		v.Add("url", ValidationRequired, "url is required")
+	} else if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.Add("url", ValidationInvalid, "url must be an absolute http or https URL")
+	}
+
+	if len(w.Events) == 0 {
+		v.Add("events", ValidationRequired, "events is required")
+	}
+	for _, e := range w.Events {
+		switch e {
+		case WebhookBookSuggested, WebhookBookReading, WebhookBookCompleted:
+		default:
+			v.Add("events", ValidationInvalid, fmt.Sprintf("unknown event %q", e))
+		}
+	}
+
+	if w.Secret != "" && len(w.Secret) < MinWebhookSecretLength {
+		v.Add("secret", ValidationInvalid,
+			fmt.Sprintf("secret must be at least %d characters long", MinWebhookSecretLength))
+	}
+
+	return v.Err()
+}
+
+// Subscribed reports whether the webhook receives event.
+func (w *Webhook) Subscribed(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeliveryStatus is the state of a [WebhookDelivery].
+type DeliveryStatus string
+
+const (
+	// DeliveryPending means the delivery is waiting for its next attempt.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded means the receiver accepted the delivery.
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed means the delivery was given up after too many attempts.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook.
+type WebhookDelivery struct {
+	ID        string
+	WebhookID string
+	// EventID is the ID of the [Event] notified, which is delivered once to
+	// each webhook however many times it is dispatched.
+	EventID int64
+	// RedeliveryOf is the ID of the delivery this one sends again on demand.
+	RedeliveryOf string
+	Event        WebhookEvent
+	BookID       string
+	Book         *Book
+	OccurredAt   time.Time
+	Status       DeliveryStatus
+	Attempts     int
+	// NextAttemptAt is when a pending delivery is tried again.
+	NextAttemptAt *time.Time
+	LastAttemptAt *time.Time
+	// ResponseStatus is the status code answered by the receiver to the
+	// last attempt, or zero if it could not be reached.
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+}
+
+// WebhookRepository defines the interface for managing the webhooks and
+// their queue of deliveries.
+type WebhookRepository interface {
+	// Create stores a new webhook, setting its ID.
+	Create(ctx context.Context, hook *Webhook) (*Webhook, error)
+	// Get retrieves the webhook identified by id.
+	Get(ctx context.Context, id string) (*Webhook, error)
+	// List retrieves all the webhooks, oldest first.
+	List(ctx context.Context) ([]*Webhook, error)
+	// Delete removes the webhook identified by id together with its deliveries.
+	Delete(ctx context.Context, id string) error
+	// Enqueue stores a new pending delivery, setting its ID. A delivery of an
+	// event already queued for the webhook is ignored, unless it is a redelivery.
+	Enqueue(ctx context.Context, delivery *WebhookDelivery) error
+	// ListDeliveries retrieves the latest deliveries of the webhook
+	// identified by webhookID, newest first.
+	ListDeliveries(ctx context.Context, webhookID string) ([]*WebhookDelivery, error)
+	// GetDelivery retrieves the delivery identified by id.
+	GetDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+	// DueDeliveries retrieves up to limit pending deliveries whose next
+	// attempt is due at now, oldest first.
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)
+	// UpdateDelivery stores the outcome of an attempt of delivery.
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+}
+
+// WebhookSender defines the interface for sending a delivery to its webhook.
+type WebhookSender interface {
+	// Send posts the signed delivery to hook. It returns the status code
+	// of the response, or zero if the receiver could not be reached, and
+	// an error unless the receiver accepted the delivery.
+	Send(ctx context.Context, hook *Webhook, delivery *WebhookDelivery) (int, error)
+}
@@ -0,0 +1,137 @@
+// Package blob implements [domain.BlobStore] backends.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// LocalStore stores blobs as files below a root directory.
+// It implements [domain.BlobStore].
+type LocalStore struct {
+	root   string
+	logger *slog.Logger
+}
+
+// NewLocalStore creates a new LocalStore rooted at dir, creating it if needed.
+func NewLocalStore(dir string, logger *slog.Logger) (*LocalStore, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		logger.With("error", err, "dir", dir).Error("unable to create blob directory")
+		return nil, err
+	}
+
+	return &LocalStore{
+		root:   dir,
+		logger: logger,
+	}, nil
+}
+
+// Put writes r to a temporary file and renames it in place, so that readers
+// never observe a partially written blob.
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader) (err error) {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), 0o750)
+	if err != nil {
+		s.logger.With("error", err, "key", key).Error("unable to create blob directory")
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		s.logger.With("error", err, "key", key).Error("unable to create blob")
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		_ = tmp.Close()
+		s.logger.With("error", err, "key", key).Error("unable to write blob")
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		s.logger.With("error", err, "key", key).Error("unable to close blob")
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), p)
+	if err != nil {
+		s.logger.With("error", err, "key", key).Error("unable to store blob")
+		return err
+	}
+
+	return nil
+}
+
+// Get opens the file of the blob stored under key.
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadSeekCloser, *domain.BlobInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//nolint:gosec // the path is sanitized by s.path
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, domain.ErrorNotFound
+	}
+	if err != nil {
+		s.logger.With("error", err, "key", key).Error("unable to open blob")
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		s.logger.With("error", err, "key", key).Error("unable to stat blob")
+		return nil, nil, err
+	}
+
+	return f, &domain.BlobInfo{ModTime: info.ModTime(), Size: info.Size()}, nil
+}
+
+// Delete removes the blob stored under prefix, or the directory of blobs below it.
+func (s *LocalStore) Delete(_ context.Context, prefix string) error {
+	p, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(p)
+	if err != nil {
+		s.logger.With("error", err, "prefix", prefix).Error("unable to delete blobs")
+		return err
+	}
+
+	return nil
+}
+
+// path maps key to a file below the root directory, rejecting keys that would escape it.
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// bookAuthorsColumn selects the authors of a book, in order, as a JSON array.
+const bookAuthorsColumn = `(SELECT json_group_array(json_object('id', a.id, 'name', a.name) ORDER BY ba.position)
+	FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+	WHERE ba.book_id = books.id)`
+
+// splitAuthors links every book to the authors parsed from its free-text
+// author, creating each distinct author once.
+func splitAuthors(ctx context.Context, tx *sql.Tx) error {
+	type bookAuthor struct {
+		id, author string
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, author FROM books;`)
+	if err != nil {
+		return err
+	}
+	books := make([]bookAuthor, 0)
+	for rows.Next() {
+		var b bookAuthor
+		err = rows.Scan(&b.id, &b.author)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		books = append(books, b)
+	}
+	err = errors.Join(rows.Err(), rows.Close())
+	if err != nil {
+		return err
+	}
+
+	for _, b := range books {
+		_, err = setBookAuthors(ctx, tx, b.id, b.author)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setBookAuthors replaces the authors of the book identified by bookID with
+// the ones parsed from author, creating the authors that do not exist yet,
+// and stores the normalized free-text author in the book. It returns the
+// linked authors, in order.
+func setBookAuthors(ctx context.Context, tx *sql.Tx, bookID, author string) ([]*domain.Author, error) {
+	_, err := tx.ExecContext(ctx, `DELETE FROM book_authors WHERE book_id = ?;`, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := make([]*domain.Author, 0)
+	linked := make(map[string]struct{})
+	for _, name := range domain.ParseAuthors(author) {
+		key := domain.AuthorKey(name)
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO authors (id, name, name_key) VALUES (?, ?, ?) ON CONFLICT (name_key) DO NOTHING;`,
+			uuid.NewString(), name, key,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var a domain.Author
+		err = tx.QueryRowContext(ctx, `SELECT id, name FROM authors WHERE name_key = ?;`, key).Scan(&a.ID, &a.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := linked[a.ID]; ok {
+			continue
+		}
+		linked[a.ID] = struct{}{}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO book_authors (book_id, author_id, position) VALUES (?, ?, ?);`,
+			bookID, a.ID, len(authors),
+		)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, &a)
+	}
+
+	if len(authors) > 0 {
+		_, err = tx.ExecContext(ctx, `UPDATE books SET author = ? WHERE id = ?;`, domain.JoinAuthors(authors), bookID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return authors, deleteOrphanAuthors(ctx, tx)
+}
+
+// deleteOrphanAuthors removes the authors no longer linked to any book.
+func deleteOrphanAuthors(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx,
+		`DELETE FROM authors WHERE NOT EXISTS (SELECT 1 FROM book_authors WHERE author_id = authors.id);`,
+	)
+	return err
+}
+
+// SQLiteAuthorRepository provides access to the authors stored in a SQLite
+// database. It implements [domain.AuthorRepository].
+type SQLiteAuthorRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewSQLiteAuthorRepository creates a new SQLiteAuthorRepository sharing the database of repo.
+func NewSQLiteAuthorRepository(repo *SQLiteBookRepository, logger *slog.Logger) *SQLiteAuthorRepository {
+	return &SQLiteAuthorRepository{
+		db:     repo.db,
+		logger: logger,
+	}
+}
+
+// List retrieves the authors of the books that are not in the trash, ordered by name.
+func (repo *SQLiteAuthorRepository) List(ctx context.Context) ([]*domain.Author, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	rows, err := conn(ctx, repo.db).QueryContext(ctx,
+		`SELECT id, name FROM authors
+		 WHERE EXISTS (
+			SELECT 1 FROM book_authors ba JOIN books b ON b.id = ba.book_id
+			WHERE ba.author_id = authors.id AND b.deleted_at IS NULL
+		 )
+		 ORDER BY name_key, id;`,
+	)
+	if err != nil {
+		logger.With("error", err).Error("failed to list authors")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	authors := make([]*domain.Author, 0)
+	for rows.Next() {
+		var a domain.Author
+		err = rows.Scan(&a.ID, &a.Name)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan author")
+			return nil, err
+		}
+		authors = append(authors, &a)
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read authors")
+		return nil, err
+	}
+
+	return authors, nil
+}
+
+// Merge moves the books of the source authors to the target author, deletes
+// the source authors and refreshes the free-text author of the affected books.
+// It returns [ErrorNotFound] if any of the authors does not exist.
+func (repo *SQLiteAuthorRepository) Merge(ctx context.Context, targetID string, sourceIDs []string) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.With("error", err).Error("failed to start transaction")
+		return err
+	}
+
+	err = mergeAuthors(ctx, tx, targetID, sourceIDs)
+	if err != nil {
+		_ = tx.Rollback()
+		if !errors.Is(err, ErrorNotFound) {
+			logger.With("error", err, "id", targetID).Error("failed to merge authors")
+		}
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.With("error", err).Error("failed to commit transaction")
+		return err
+	}
+
+	return nil
+}
+
+func mergeAuthors(ctx context.Context, tx *sql.Tx, targetID string, sourceIDs []string) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM authors WHERE id = ?);`, targetID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrorNotFound
+	}
+
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			continue
+		}
+
+		// Books written by both authors keep their link to the target: the
+		// link to the source is left in place and deleted with the source.
+		_, err = tx.ExecContext(ctx,
+			`UPDATE OR IGNORE book_authors SET author_id = ? WHERE author_id = ?;`, targetID, sourceID,
+		)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM authors WHERE id = ?;`, sourceID)
+		if err != nil {
+			return err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrorNotFound
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE books SET author = (
+			SELECT group_concat(a.name, ' & ' ORDER BY ba.position)
+			FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+			WHERE ba.book_id = books.id
+		 )
+		 WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = ?);`,
+		targetID,
+	)
+
+	return err
+}
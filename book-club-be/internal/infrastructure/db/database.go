@@ -3,23 +3,34 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
 
 	"github.com/Michela-DC/book-club/internal/domain"
-
-	_ "github.com/mattn/go-sqlite3" // init sql driver
+	"github.com/Michela-DC/book-club/pkg/logctx"
 )
 
 // ErrorNotFound is the sentinel error when no rows are found.
-var ErrorNotFound = errors.New("not found")
+var ErrorNotFound = domain.ErrorNotFound
+
+// goMigrations are the migrations that cannot be expressed in SQL, keyed by
+// name. They are applied in order together with the .sql migration files.
+var goMigrations = map[string]func(ctx context.Context, tx *sql.Tx) error{
+	"00006_split-authors": splitAuthors,
+	"00008_genre-tags":    genreTags,
+	"00017_event-books":   rewriteEventBooks,
+}
 
 // SQLiteBookRepository provides access to book data stored in a SQLite database.
 // It implements [domain.BookRepository].
@@ -30,8 +41,10 @@ type SQLiteBookRepository struct {
 
 // NewSQLiteBookRepository creates a new SQLiteBookRepository using the provided database
 // file path and logger. It opens the SQLite connection but does not apply migrations.
+// Every statement executed on the connection is traced, and foreign keys are
+// enforced, so that the ON DELETE actions of the schema apply.
 func NewSQLiteBookRepository(dbPath string, logger *slog.Logger) (*SQLiteBookRepository, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(tracedDriverName, dataSourceName(dbPath))
 	if err != nil {
 		logger.With("error", err).Error("unable to open db connection")
 		return nil, err
@@ -43,173 +56,624 @@ func NewSQLiteBookRepository(dbPath string, logger *slog.Logger) (*SQLiteBookRep
 	}, nil
 }
 
-// ApplyMigrations executes all .sql migration files in the given directory
-// that have not already been applied. It records applied migrations in a
-// dedicated migrations table to ensure idempotency.
+// dataSourceName returns the data source name opening dbPath with foreign
+// key enforcement, which SQLite disables by default on every connection,
+// and with transactions started by BEGIN IMMEDIATE. Transactions read before
+// they write, such as to check that an ISBN is free: started as DEFERRED,
+// two of them would both hold a read lock and fail at once with SQLITE_BUSY
+// when upgrading it, instead of waiting for each other.
+func dataSourceName(dbPath string) string {
+	options := "_foreign_keys=on&_txlock=immediate"
+	if strings.Contains(dbPath, "?") {
+		return dbPath + "&" + options
+	}
+	return dbPath + "?" + options
+}
+
+// ApplyMigrations executes all .sql migration files in the given directory,
+// together with the [goMigrations], that have not already been applied. It
+// records applied migrations in a dedicated migrations table to ensure idempotency.
 func (repo *SQLiteBookRepository) ApplyMigrations(ctx context.Context, migrationsPath string) error {
+	logger := logctx.FromContext(ctx, repo.logger)
 	_, err := repo.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS migrations(
 		name TEXT PRIMARY KEY,
 		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);`)
 	if err != nil {
-		repo.logger.With("error", err).Error("failed to create migration table")
+		logger.With("error", err).Error("failed to create migration table")
 		return err
 	}
 
 	appliedMigrations := make(map[string]struct{}, 0)
 	rows, err := repo.db.QueryContext(ctx, `SELECT name FROM migrations`)
 	if err != nil {
-		repo.logger.With("error", err).Error("failed to read applied migrations")
+		logger.With("error", err).Error("failed to read applied migrations")
 		return err
 	}
 	defer func() {
 		err = rows.Close()
 		if err != nil {
-			repo.logger.With("error", err).Error("failed to close rows")
+			logger.With("error", err).Error("failed to close rows")
 		}
 	}()
 	for rows.Next() {
 		var n string
 		err = rows.Scan(&n)
 		if err != nil {
-			repo.logger.With("error", err).Error("failed to scan migration")
+			logger.With("error", err).Error("failed to scan migration")
 			return err
 		}
 
 		appliedMigrations[n] = struct{}{}
 	}
 	if err = rows.Err(); err != nil {
-		repo.logger.With("error", err).Error("failed to read migrations")
+		logger.With("error", err).Error("failed to read migrations")
 		return err
 	}
 
-	migrationFiles := make([]string, 0)
-	err = filepath.WalkDir(migrationsPath, func(_ string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !d.IsDir() && strings.HasSuffix(d.Name(), ".sql") {
-			migrationFiles = append(migrationFiles, d.Name())
-		}
-		return nil
-	})
+	migrationFiles, err := listMigrations(migrationsPath)
 	if err != nil {
-		repo.logger.With("error", err).Error("failed to walk migration folder")
+		logger.With("error", err).Error("failed to walk migration folder")
 		return err
 	}
 
-	sort.Strings(migrationFiles)
-
 	for _, file := range migrationFiles {
 		if _, applied := appliedMigrations[file]; applied {
 			continue
 		}
 
-		//nolint:gosec // we control the file
-		content, err := os.ReadFile(filepath.Join(migrationsPath, file))
-		if err != nil {
-			repo.logger.With("error", err, "filename", file).Error("failed to read migration file")
-			return err
+		apply, ok := goMigrations[file]
+		if !ok {
+			//nolint:gosec // we control the file
+			content, err := os.ReadFile(filepath.Join(migrationsPath, file))
+			if err != nil {
+				logger.With("error", err, "filename", file).Error("failed to read migration file")
+				return err
+			}
+			apply = func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, string(content))
+				return err
+			}
 		}
 
-		repo.logger.With("filename", file).Info("applying migration")
+		logger.With("filename", file).Info("applying migration")
 
 		tx, err := repo.db.BeginTx(ctx, nil)
 		if err != nil {
-			repo.logger.With("error", err, "filename", file).Error("failed to start transaction")
+			logger.With("error", err, "filename", file).Error("failed to start transaction")
 			return err
 		}
-		_, err = tx.ExecContext(ctx, string(content))
+		err = apply(ctx, tx)
 		if err != nil {
-			repo.logger.With("error", err, "filename", file).Error("failed to apply migration")
+			_ = tx.Rollback()
+			logger.With("error", err, "filename", file).Error("failed to apply migration")
 			return err
 		}
 
 		_, err = tx.ExecContext(ctx, `INSERT INTO migrations(name) VALUES (?)`, file)
 		if err != nil {
-			repo.logger.With("error", err, "filename", file).Error("failed to store migration")
+			_ = tx.Rollback()
+			logger.With("error", err, "filename", file).Error("failed to store migration")
 			return err
 		}
 
 		err = tx.Commit()
 		if err != nil {
-			repo.logger.With("error", err, "filename", file).Error("failed to commit transaction")
+			logger.With("error", err, "filename", file).Error("failed to commit transaction")
 			return err
 		}
 
-		repo.logger.With("filename", file).Info("migration completed")
+		logger.With("filename", file).Info("migration completed")
 	}
 
-	repo.logger.Info("all migrations were applied successfully!")
+	logger.Info("all migrations were applied successfully!")
 
 	return nil
 }
 
+// listMigrations returns the names of the .sql migration files found in
+// migrationsPath and of the [goMigrations], sorted in the order they must be applied.
+func listMigrations(migrationsPath string) ([]string, error) {
+	migrationFiles := make([]string, 0)
+	err := filepath.WalkDir(migrationsPath, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".sql") {
+			migrationFiles = append(migrationFiles, d.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range goMigrations {
+		migrationFiles = append(migrationFiles, name)
+	}
+
+	sort.Strings(migrationFiles)
+
+	return migrationFiles, nil
+}
+
 // Create inserts a new book record into the database. If the book has no ID,
-// a new UUID is generated automatically.
+// a new UUID is generated automatically. The authors parsed from the book's
+// author are linked to it, and created if they do not exist yet, together
+// with the tags of the book, which must already exist. The status of the book
+// starts its status history. It returns a [domain.DuplicateISBNError] if
+// another book has the same ISBN.
 func (repo *SQLiteBookRepository) Create(ctx context.Context, book *domain.Book) (*domain.Book, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
 	if book.ID == "" {
 		book.ID = uuid.NewString()
 	}
-	_, err := repo.db.ExecContext(ctx,
-		`INSERT INTO books (id, title, author, genre, published_year, status)
-		 VALUES (?, ?, ?, ?, ?, ?);`, book.ID, book.Title, book.Author, book.Genre, book.PublishedYear, book.Status,
-	)
+
+	err := repo.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO books (`+bookColumns+`)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+			book.ID, book.Title, book.Author, book.Genre, book.PublishedYear,
+			book.ISBN, book.CoverURL, book.PageCount, book.Description, book.Status, book.SuggestedBy, book.DeletedAt,
+		)
+		if err != nil {
+			return duplicateISBN(ctx, tx, err, book.ISBN)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO book_status_changes (book_id, status, changed_at) VALUES (?, ?, ?);`,
+			book.ID, book.Status, time.Now().UTC(),
+		)
+		if err != nil {
+			return err
+		}
+
+		err = setBookTags(ctx, tx, book.ID, book.Tags)
+		if err != nil {
+			return err
+		}
+
+		return repo.linkAuthors(ctx, tx, book)
+	})
+	var dupErr *domain.DuplicateISBNError
+	if errors.As(err, &dupErr) {
+		return nil, dupErr
+	}
 	if err != nil {
-		repo.logger.With("error", err).Error("failed to insert new record")
+		logger.With("error", err).Error("failed to insert new record")
 		return nil, err
 	}
 
 	return book, nil
 }
 
-// List retrieves books matching the provided filters. This method is not yet implemented.
-func (*SQLiteBookRepository) List(context.Context, *domain.BookFilters) ([]*domain.Book, error) {
-	return nil, errors.New("not yet implemented")
+// linkAuthors replaces the authors linked to book with the ones parsed from
+// its author, and updates the book with the normalized authors.
+func (repo *SQLiteBookRepository) linkAuthors(ctx context.Context, tx *sql.Tx, book *domain.Book) error {
+	authors, err := setBookAuthors(ctx, tx, book.ID, book.Author)
+	if err != nil {
+		return err
+	}
+
+	book.Authors = authors
+	if len(authors) > 0 {
+		book.Author = domain.JoinAuthors(authors)
+	}
+
+	return nil
+}
+
+// inTx runs fn in a transaction, which is committed if fn succeeds and rolled back otherwise.
+func (repo *SQLiteBookRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return inTx(ctx, repo.db, fn)
+}
+
+// WithinTransaction calls fn with a context bound to a new transaction, which
+// is committed if fn succeeds and rolled back otherwise. The repositories
+// sharing the database of repo run their statements in the transaction when
+// called with that context. When ctx is already bound to a transaction, fn
+// runs in it instead, and its outcome is left to the outer caller.
+func (repo *SQLiteBookRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.With("error", err).Error("failed to start transaction")
+		return err
+	}
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.With("error", err).Error("failed to commit transaction")
+		return err
+	}
+
+	return nil
+}
+
+// txKey is the context key of the transaction started by WithinTransaction.
+type txKey struct{}
+
+// querier runs statements on either a database or a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction ctx is bound to by WithinTransaction, if
+// any, or db otherwise.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// inTx runs fn within a transaction on db, which is committed if fn succeeds
+// and rolled back otherwise. When ctx is bound to a transaction by
+// WithinTransaction, fn runs in it instead, and its outcome is left to the
+// caller of WithinTransaction.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	return tx.Commit()
+}
+
+// List retrieves books matching the provided filters. Nil filters, or nil
+// fields within them, match every book that is not in the trash.
+func (repo *SQLiteBookRepository) List(ctx context.Context, filters *domain.BookFilters) ([]*domain.Book, error) {
+	books := make([]*domain.Book, 0)
+	err := repo.Stream(ctx, filters, func(book *domain.Book) error {
+		books = append(books, book)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// Stream calls fn for each book matching the provided filters, ordered by
+// title, reading one row at a time. It stops at the first error returned by fn.
+func (repo *SQLiteBookRepository) Stream(
+	ctx context.Context,
+	filters *domain.BookFilters,
+	fn func(*domain.Book) error,
+) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	query := `SELECT ` + bookColumns + `, ` + bookAuthorsColumn + `, ` + bookTagsColumn + ` FROM books`
+	where, args := bookFiltersClause(filters)
+	query += where + ` ORDER BY title, id;`
+
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.With("error", err).Error("failed to list books")
+		return err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan book")
+			return err
+		}
+
+		err = fn(book)
+		if err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read books")
+		return err
+	}
+
+	return nil
+}
+
+// bookFiltersClause builds the WHERE clause, and its arguments, matching the
+// given filters. The books in the trash are matched only by filters.Trashed.
+func bookFiltersClause(filters *domain.BookFilters) (string, []any) {
+	if filters == nil {
+		return " WHERE deleted_at IS NULL", nil
+	}
+
+	conds := make([]string, 0)
+	args := make([]any, 0)
+	add := func(cond string, arg ...any) {
+		conds = append(conds, cond)
+		args = append(args, arg...)
+	}
+
+	if filters.Trashed {
+		add("deleted_at IS NOT NULL")
+	} else {
+		add("deleted_at IS NULL")
+	}
+
+	if filters.ID != nil {
+		add("id = ?", *filters.ID)
+	}
+	if filters.Title != nil {
+		add("title = ?", *filters.Title)
+	}
+	if filters.Author != nil {
+		add("author = ?", *filters.Author)
+	}
+	if filters.AuthorID != nil {
+		add("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", *filters.AuthorID)
+	}
+	if filters.Genre != nil {
+		add("genre = ?", *filters.Genre)
+	}
+	if filters.PublishedYear != nil {
+		add("published_year = ?", *filters.PublishedYear)
+	}
+	if filters.ISBN != nil {
+		add("isbn = ?", *filters.ISBN)
+	}
+	if filters.Status != nil {
+		add("status = ?", *filters.Status)
+	}
+	for _, group := range filters.Tags {
+		keys := make([]any, len(group))
+		for i, name := range group {
+			keys[i] = domain.TagKey(name)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+		add(fmt.Sprintf(tagGroupCondition, placeholders), keys...)
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// bookColumns are the columns of the books table read by scanBook, in order,
+// before the [bookAuthorsColumn] and the [bookTagsColumn].
+const bookColumns = `id, title, author, genre, published_year, isbn, cover_url, page_count, description, status,
+	suggested_by, deleted_at`
+
+// scanBook reads a book from the current row, which must select the bookColumns
+// followed by the bookAuthorsColumn and the bookTagsColumn.
+func scanBook(rows *sql.Rows) (*domain.Book, error) {
+	var (
+		book          domain.Book
+		authors, tags string
+	)
+	err := rows.Scan(
+		&book.ID, &book.Title, &book.Author, &book.Genre, &book.PublishedYear,
+		&book.ISBN, &book.CoverURL, &book.PageCount, &book.Description, &book.Status,
+		&book.SuggestedBy, &book.DeletedAt, &authors, &tags,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(authors), &book.Authors)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(tags), &book.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &book, nil
 }
 
-// Update modifies an existing book record in the database.
+// Update modifies an existing book record in the database, and relinks its
+// authors to the ones parsed from the book's author. The tags of the book are
+// replaced only when book.Tags is not nil. A change of status is added to the
+// status history of the book. It returns a [domain.DuplicateISBNError] if
+// another book has the same ISBN.
 func (repo *SQLiteBookRepository) Update(ctx context.Context, book *domain.Book) error {
+	logger := logctx.FromContext(ctx, repo.logger)
 	if book.ID == "" {
 		return errors.New("book id cannot be empty")
 	}
-	_, err := repo.db.ExecContext(ctx,
-		`UPDATE books
-		SET title = ?, 
-		author = ?, 
-		genre = ?, 
-		published_year = ?, 
-		status = ?
-		where id = ?;`,
-		book.Title, book.Author, book.Genre, book.PublishedYear, book.Status, book.ID,
-	)
+
+	err := repo.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO book_status_changes (book_id, previous_status, status, changed_at)
+			 SELECT id, status, ?, ? FROM books WHERE id = ? AND status <> ?;`,
+			book.Status, time.Now().UTC(), book.ID, book.Status,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE books
+			SET title = ?, 
+			author = ?, 
+			genre = ?, 
+			published_year = ?, 
+			isbn = ?,
+			cover_url = ?,
+			page_count = ?,
+			description = ?,
+			status = ?,
+			suggested_by = ?
+			where id = ?;`,
+			book.Title, book.Author, book.Genre, book.PublishedYear,
+			book.ISBN, book.CoverURL, book.PageCount, book.Description, book.Status, book.SuggestedBy, book.ID,
+		)
+		if err != nil {
+			return duplicateISBN(ctx, tx, err, book.ISBN)
+		}
+
+		if book.Tags != nil {
+			err = setBookTags(ctx, tx, book.ID, book.Tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		return repo.linkAuthors(ctx, tx, book)
+	})
+	var dupErr *domain.DuplicateISBNError
+	if errors.As(err, &dupErr) {
+		return dupErr
+	}
 	if err != nil {
-		repo.logger.With("error", err, "id", book.ID).Error("failed to update book")
+		logger.With("error", err, "id", book.ID).Error("failed to update book")
 		return err
 	}
 
 	return nil
 }
 
-// Delete removes a book record identified by its ID from the database.
+// Delete moves a book record identified by its ID to the trash. The book
+// keeps its author and tag links, so that it can be restored, until it is purged.
 func (repo *SQLiteBookRepository) Delete(ctx context.Context, bookID string) error {
-	res, err := repo.db.ExecContext(ctx, "DELETE FROM books WHERE id = ?", bookID)
+	logger := logctx.FromContext(ctx, repo.logger)
+	res, err := conn(ctx, repo.db).ExecContext(ctx,
+		`UPDATE books SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL;`,
+		time.Now().UTC(), bookID,
+	)
+	if err != nil {
+		logger.With("error", err, "id", bookID).Error("failed to delete book")
+		return err
+	}
 
+	return checkAffected(logger, res)
+}
+
+// Restore moves a book record identified by its ID out of the trash. It
+// returns a [domain.DuplicateISBNError] if another book has taken its ISBN.
+func (repo *SQLiteBookRepository) Restore(ctx context.Context, bookID string) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	q := conn(ctx, repo.db)
+	res, err := q.ExecContext(ctx,
+		`UPDATE books SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL;`,
+		bookID,
+	)
+	if isUniqueViolation(err, "books.isbn") {
+		var isbn *string
+		qerr := q.QueryRowContext(ctx, `SELECT isbn FROM books WHERE id = ?;`, bookID).Scan(&isbn)
+		if qerr == nil {
+			err = duplicateISBN(ctx, q, err, isbn)
+		}
+	}
+	var dupErr *domain.DuplicateISBNError
+	if errors.As(err, &dupErr) {
+		return dupErr
+	}
 	if err != nil {
-		repo.logger.With("error", err, "id", bookID).Error("failed to delete book")
+		logger.With("error", err, "id", bookID).Error("failed to restore book")
 		return err
 	}
 
+	return checkAffected(logger, res)
+}
+
+// isUniqueViolation reports whether err is the violation of the unique
+// constraint on column, named as table.column.
+func isUniqueViolation(err error, column string) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+		strings.Contains(sqliteErr.Error(), column)
+}
+
+// duplicateISBN returns a [domain.DuplicateISBNError] naming the book that
+// has isbn if err is the violation of the unique index on the ISBN of the
+// books not in the trash, which happens when the book was stored
+// concurrently. It returns err otherwise.
+func duplicateISBN(ctx context.Context, q querier, err error, isbn *string) error {
+	if isbn == nil || !isUniqueViolation(err, "books.isbn") {
+		return err
+	}
+
+	var existingID string
+	qerr := q.QueryRowContext(ctx,
+		`SELECT id FROM books WHERE isbn = ? AND deleted_at IS NULL;`, *isbn,
+	).Scan(&existingID)
+	if qerr != nil {
+		return errors.Join(err, qerr)
+	}
+
+	return &domain.DuplicateISBNError{ISBN: *isbn, ExistingID: existingID}
+}
+
+// checkAffected returns ErrorNotFound if no rows were affected by the statement of res.
+func checkAffected(logger *slog.Logger, res sql.Result) error {
 	count, err := res.RowsAffected()
 	if err != nil {
-		repo.logger.With("error", err).Error("unable to get rows affected by the delete")
+		logger.With("error", err).Error("failed to read affected rows")
 		return err
 	}
 	if count == 0 {
-		repo.logger.With("count", count).Error("no rows affected")
+		logger.With("count", count).Error("no rows affected")
 		return ErrorNotFound
 	}
 
 	return nil
 }
+
+// Purge permanently removes the book records moved to the trash before the
+// given time, together with their author and tag links and status history,
+// which are deleted in cascade, and the authors left without books. It
+// returns the IDs of the removed books.
+func (repo *SQLiteBookRepository) Purge(ctx context.Context, before time.Time) ([]string, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	var ids []string
+	err := repo.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`DELETE FROM books WHERE deleted_at IS NOT NULL AND deleted_at < ? RETURNING id;`, before.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id string
+			err = rows.Scan(&id)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		err = rows.Err()
+		if err != nil {
+			return err
+		}
+
+		return deleteOrphanAuthors(ctx, tx)
+	})
+	if err != nil {
+		logger.With("error", err).Error("failed to purge trash")
+		return nil, err
+	}
+
+	return ids, nil
+}
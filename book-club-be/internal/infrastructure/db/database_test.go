@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// migrationsPath is the folder of the database migrations, relative to this package.
+const migrationsPath = "../../../database/migrations"
+
+// newTestRepo returns a book repository backed by a new, migrated database.
+func newTestRepo(t *testing.T) *SQLiteBookRepository {
+	t.Helper()
+
+	repo, err := NewSQLiteBookRepository(filepath.Join(t.TempDir(), "books.db"), slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("unable to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.db.Close() })
+
+	err = repo.ApplyMigrations(context.Background(), migrationsPath)
+	if err != nil {
+		t.Fatalf("unable to apply migrations: %v", err)
+	}
+
+	return repo
+}
+
+// countRows returns the number of rows of table matching where.
+func countRows(t *testing.T, repo *SQLiteBookRepository, table, where string, args ...any) int {
+	t.Helper()
+
+	var n int
+	err := repo.db.QueryRow(`SELECT count(*) FROM `+table+` WHERE `+where, args...).Scan(&n)
+	if err != nil {
+		t.Fatalf("unable to count %s: %v", table, err)
+	}
+
+	return n
+}
+
+func TestPurgeDeletesLinksInCascade(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	tags := NewSQLiteTagRepository(repo, repo.logger)
+
+	tag, err := tags.Create(ctx, &domain.Tag{Name: "Fantasy"})
+	if err != nil {
+		t.Fatalf("Create(tag) error = %v", err)
+	}
+	book, err := repo.Create(ctx, &domain.Book{
+		Title:  "The Hobbit",
+		Author: "J. R. R. Tolkien",
+		Tags:   []*domain.Tag{tag},
+		Status: domain.BookStatusSaved,
+	})
+	if err != nil {
+		t.Fatalf("Create(book) error = %v", err)
+	}
+
+	err = repo.Delete(ctx, book.ID)
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	ids, err := repo.Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != book.ID {
+		t.Errorf("Purge() = %v, want [%s]", ids, book.ID)
+	}
+
+	for _, table := range []string{"book_authors", "book_tags", "book_status_changes"} {
+		if n := countRows(t, repo, table, "book_id = ?", book.ID); n != 0 {
+			t.Errorf("%s has %d rows of the purged book, want 0", table, n)
+		}
+	}
+	if n := countRows(t, repo, "authors", "1"); n != 0 {
+		t.Errorf("authors has %d rows, want the orphan author deleted", n)
+	}
+}
+
+func TestMergeTagsKeepsSingleLink(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	tags := NewSQLiteTagRepository(repo, repo.logger)
+
+	target, err := tags.Create(ctx, &domain.Tag{Name: "Science Fiction"})
+	if err != nil {
+		t.Fatalf("Create(tag) error = %v", err)
+	}
+	source, err := tags.Create(ctx, &domain.Tag{Name: "Sci-Fi"})
+	if err != nil {
+		t.Fatalf("Create(tag) error = %v", err)
+	}
+	both, err := repo.Create(ctx, &domain.Book{
+		Title: "Dune", Author: "Frank Herbert", Tags: []*domain.Tag{target, source}, Status: domain.BookStatusSaved,
+	})
+	if err != nil {
+		t.Fatalf("Create(book) error = %v", err)
+	}
+	sourceOnly, err := repo.Create(ctx, &domain.Book{
+		Title: "Hyperion", Author: "Dan Simmons", Tags: []*domain.Tag{source}, Status: domain.BookStatusSaved,
+	})
+	if err != nil {
+		t.Fatalf("Create(book) error = %v", err)
+	}
+
+	err = tags.Merge(ctx, target.ID, []string{source.ID})
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	for _, id := range []string{both.ID, sourceOnly.ID} {
+		if n := countRows(t, repo, "book_tags", "book_id = ? AND tag_id = ?", id, target.ID); n != 1 {
+			t.Errorf("book %s has %d links to the target, want 1", id, n)
+		}
+	}
+	if n := countRows(t, repo, "book_tags", "tag_id = ?", source.ID); n != 0 {
+		t.Errorf("book_tags has %d links to the merged tag, want 0", n)
+	}
+}
+
+func TestMemberDeleteCascades(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	members := NewSQLiteMemberRepository(repo, repo.logger)
+	notifications := NewSQLiteNotificationRepository(repo, time.Hour, repo.logger)
+
+	member, err := members.Create(ctx, &domain.Member{Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("Create(member) error = %v", err)
+	}
+	book, err := repo.Create(ctx, &domain.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: domain.BookStatusSuggested, SuggestedBy: &member.ID,
+	})
+	if err != nil {
+		t.Fatalf("Create(book) error = %v", err)
+	}
+	for _, key := range []string{"sent", "pending"} {
+		err = notifications.Enqueue(ctx, &domain.Notification{
+			MemberID: member.ID,
+			Kind:     domain.NotificationSuggestion,
+			DedupKey: key,
+			Email:    &domain.Email{To: member.Email, Subject: key},
+		})
+		if err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	_, err = repo.db.Exec(`UPDATE notifications SET status = ? WHERE dedup_key = 'sent';`, domain.DeliverySucceeded)
+	if err != nil {
+		t.Fatalf("unable to mark notification as sent: %v", err)
+	}
+
+	err = members.Delete(ctx, member.ID)
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if n := countRows(t, repo, "notifications", "member_id = ?", member.ID); n != 0 {
+		t.Errorf("notifications has %d rows of the deleted member, want 0", n)
+	}
+	books, err := repo.List(ctx, &domain.BookFilters{ID: &book.ID})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(books) != 1 || books[0].SuggestedBy != nil {
+		t.Errorf("List() = %v, want the book kept without suggester", books)
+	}
+}
+
+func TestConcurrentTransactionsReadingBeforeWriting(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	// Each transaction reads, waits for the other one to have read unless
+	// it is blocked from starting, then writes, as when checking that an
+	// ISBN is free before storing a book.
+	read := make(chan struct{}, 2)
+	errs := make(chan error, 2)
+	for _, title := range []string{"Emma", "Persuasion"} {
+		go func() {
+			errs <- repo.WithinTransaction(ctx, func(ctx context.Context) error {
+				var n int
+				err := conn(ctx, repo.db).QueryRowContext(ctx, `SELECT count(*) FROM books;`).Scan(&n)
+				if err != nil {
+					return err
+				}
+				read <- struct{}{}
+				deadline := time.After(200 * time.Millisecond)
+				for waiting := true; waiting && len(read) < 2; {
+					select {
+					case <-deadline:
+						waiting = false
+					case <-time.After(10 * time.Millisecond):
+					}
+				}
+
+				_, err = repo.Create(ctx, &domain.Book{Title: title, Author: "Jane Austen", Status: domain.BookStatusSaved})
+				return err
+			})
+		}()
+	}
+
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("WithinTransaction() error = %v, want the transactions to wait for each other", err)
+		}
+	}
+	if n := countRows(t, repo, "books", "1 = 1"); n != 2 {
+		t.Errorf("%d books stored, want 2", n)
+	}
+}
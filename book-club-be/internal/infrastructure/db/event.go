@@ -0,0 +1,336 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// eventBook is the book of an event as stored in the events and in the
+// webhook_deliveries tables. Its JSON field names are part of the schema, as
+// the migrations query them, so they must not follow the renames of [domain.Book].
+type eventBook struct {
+	ID            string           `json:"id"`
+	Title         string           `json:"title"`
+	Author        string           `json:"author"`
+	Authors       []*domain.Author `json:"authors"`
+	Genre         *string          `json:"genre"`
+	Tags          []*domain.Tag    `json:"tags"`
+	PublishedYear *int             `json:"published_year"`
+	ISBN          *string          `json:"isbn"`
+	CoverURL      *string          `json:"cover_url"`
+	PageCount     *int             `json:"page_count"`
+	Description   *string          `json:"description"`
+	Status        string           `json:"status"`
+	SuggestedBy   *string          `json:"suggested_by"`
+	DeletedAt     *time.Time       `json:"deleted_at"`
+}
+
+// encodeBook encodes book in the [eventBook] format, as null if book is nil.
+func encodeBook(book *domain.Book) ([]byte, error) {
+	if book == nil {
+		return json.Marshal(nil)
+	}
+
+	return json.Marshal(&eventBook{
+		ID:            book.ID,
+		Title:         book.Title,
+		Author:        book.Author,
+		Authors:       book.Authors,
+		Genre:         book.Genre,
+		Tags:          book.Tags,
+		PublishedYear: book.PublishedYear,
+		ISBN:          book.ISBN,
+		CoverURL:      book.CoverURL,
+		PageCount:     book.PageCount,
+		Description:   book.Description,
+		Status:        string(book.Status),
+		SuggestedBy:   book.SuggestedBy,
+		DeletedAt:     book.DeletedAt,
+	})
+}
+
+// decodeBook decodes a book encoded by [encodeBook], returning nil for null.
+func decodeBook(data []byte) (*domain.Book, error) {
+	var b *eventBook
+	err := json.Unmarshal(data, &b)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	return &domain.Book{
+		ID:            b.ID,
+		Title:         b.Title,
+		Author:        b.Author,
+		Authors:       b.Authors,
+		Genre:         b.Genre,
+		Tags:          b.Tags,
+		PublishedYear: b.PublishedYear,
+		ISBN:          b.ISBN,
+		CoverURL:      b.CoverURL,
+		PageCount:     b.PageCount,
+		Description:   b.Description,
+		Status:        domain.BookStatus(b.Status),
+		SuggestedBy:   b.SuggestedBy,
+		DeletedAt:     b.DeletedAt,
+	}, nil
+}
+
+// rewriteEventBooks rewrites the books of the stored events and webhook
+// deliveries, which were encoded with the field names of [domain.Book], in
+// the [eventBook] format.
+func rewriteEventBooks(ctx context.Context, tx *sql.Tx) error {
+	for _, table := range []string{"events", "webhook_deliveries"} {
+		err := rewriteBooks(ctx, tx, table)
+		if err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// rewriteBooks rewrites the book column of table in the [eventBook] format.
+func rewriteBooks(ctx context.Context, tx *sql.Tx, table string) error {
+	type storedBook struct {
+		rowID int64
+		book  string
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT rowid, book FROM `+table+` WHERE book IS NOT NULL;`)
+	if err != nil {
+		return err
+	}
+	books := make([]storedBook, 0)
+	for rows.Next() {
+		var b storedBook
+		err = rows.Scan(&b.rowID, &b.book)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		books = append(books, b)
+	}
+	err = errors.Join(rows.Err(), rows.Close())
+	if err != nil {
+		return err
+	}
+
+	for _, b := range books {
+		var book *domain.Book
+		err = json.Unmarshal([]byte(b.book), &book)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", b.rowID, err)
+		}
+		encoded, err := encodeBook(book)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE `+table+` SET book = ? WHERE rowid = ?;`, string(encoded), b.rowID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SQLiteEventLog stores the published events in a SQLite database, where
+// they form the outbox they are dispatched from. Dispatched events are kept
+// for a limited time. It implements [domain.EventLog].
+type SQLiteEventLog struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	retention time.Duration
+}
+
+// NewSQLiteEventLog creates a new SQLiteEventLog sharing the database of repo
+// and keeping the dispatched events for retention.
+func NewSQLiteEventLog(repo *SQLiteBookRepository, retention time.Duration, logger *slog.Logger) *SQLiteEventLog {
+	return &SQLiteEventLog{
+		db:        repo.db,
+		logger:    logger,
+		retention: retention,
+	}
+}
+
+// Append stores event, within the transaction ctx is bound to if any, and
+// sets its ID. The dispatched events older than the retention are deleted.
+func (l *SQLiteEventLog) Append(ctx context.Context, event *domain.Event) error {
+	logger := logctx.FromContext(ctx, l.logger)
+	var book *string
+	if event.Book != nil {
+		b, err := encodeBook(event.Book)
+		if err != nil {
+			logger.With("error", err).Error("failed to encode event book")
+			return err
+		}
+		s := string(b)
+		book = &s
+	}
+
+	err := inTx(ctx, l.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM events WHERE dispatched_at IS NOT NULL AND occurred_at < ?;`,
+			event.OccurredAt.UTC().Add(-l.retention),
+		)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO events (type, book_id, book, previous_status, occurred_at) VALUES (?, ?, ?, ?, ?);`,
+			event.Type, event.BookID, book, sql.NullString{
+				String: string(event.PreviousStatus),
+				Valid:  event.PreviousStatus != "",
+			}, event.OccurredAt.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+
+		event.ID, err = res.LastInsertId()
+		return err
+	})
+	if err != nil {
+		logger.With("error", err, "type", event.Type, "book_id", event.BookID).Error("failed to store event")
+		return err
+	}
+
+	return nil
+}
+
+// ListFrom retrieves up to limit events with an ID greater than or equal to
+// id, ordered by ID.
+func (l *SQLiteEventLog) ListFrom(ctx context.Context, id int64, limit int) ([]*domain.Event, error) {
+	return l.listEvents(ctx,
+		`SELECT id, type, book_id, book, previous_status, occurred_at FROM events
+		 WHERE id >= ? ORDER BY id LIMIT ?;`,
+		id, limit,
+	)
+}
+
+// ListSince retrieves the events that occurred at or after since, ordered by ID.
+func (l *SQLiteEventLog) ListSince(ctx context.Context, since time.Time) ([]*domain.Event, error) {
+	return l.listEvents(ctx,
+		`SELECT id, type, book_id, book, previous_status, occurred_at FROM events
+		 WHERE occurred_at >= ? ORDER BY id;`,
+		since.UTC(),
+	)
+}
+
+// ListUndispatched retrieves up to limit events not yet dispatched, ordered by ID.
+func (l *SQLiteEventLog) ListUndispatched(ctx context.Context, limit int) ([]*domain.Event, error) {
+	return l.listEvents(ctx,
+		`SELECT id, type, book_id, book, previous_status, occurred_at FROM events
+		 WHERE dispatched_at IS NULL ORDER BY id LIMIT ?;`,
+		limit,
+	)
+}
+
+// MarkDispatched records that the events up to the one identified by id were
+// delivered to every sink.
+func (l *SQLiteEventLog) MarkDispatched(ctx context.Context, id int64) error {
+	logger := logctx.FromContext(ctx, l.logger)
+	_, err := conn(ctx, l.db).ExecContext(ctx,
+		`UPDATE events SET dispatched_at = ? WHERE id <= ? AND dispatched_at IS NULL;`, time.Now().UTC(), id,
+	)
+	if err != nil {
+		logger.With("error", err, "id", id).Error("failed to mark events as dispatched")
+		return err
+	}
+
+	return nil
+}
+
+// SinkPosition retrieves the ID of the last event handled by the sink named
+// sink. A sink never seen starts before the first event not yet dispatched,
+// or after the last event when every event was dispatched.
+func (l *SQLiteEventLog) SinkPosition(ctx context.Context, sink string) (int64, error) {
+	logger := logctx.FromContext(ctx, l.logger)
+	var position int64
+	err := conn(ctx, l.db).QueryRowContext(ctx,
+		`SELECT COALESCE(
+			(SELECT position FROM event_sinks WHERE name = ?),
+			(SELECT MIN(id) - 1 FROM events WHERE dispatched_at IS NULL),
+			(SELECT seq FROM sqlite_sequence WHERE name = 'events'),
+			0
+		);`, sink,
+	).Scan(&position)
+	if err != nil {
+		logger.With("error", err, "sink", sink).Error("failed to read sink position")
+		return 0, err
+	}
+
+	return position, nil
+}
+
+// SetSinkPosition records that the sink named sink handled the events up to
+// the one identified by id.
+func (l *SQLiteEventLog) SetSinkPosition(ctx context.Context, sink string, id int64) error {
+	logger := logctx.FromContext(ctx, l.logger)
+	_, err := conn(ctx, l.db).ExecContext(ctx,
+		`INSERT INTO event_sinks (name, position, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT (name) DO UPDATE SET position = excluded.position, updated_at = excluded.updated_at;`,
+		sink, id, time.Now().UTC(),
+	)
+	if err != nil {
+		logger.With("error", err, "sink", sink).Error("failed to store sink position")
+		return err
+	}
+
+	return nil
+}
+
+// listEvents runs query, which selects the columns of an event, and returns
+// the events read.
+func (l *SQLiteEventLog) listEvents(ctx context.Context, query string, args ...any) ([]*domain.Event, error) {
+	logger := logctx.FromContext(ctx, l.logger)
+	rows, err := conn(ctx, l.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.With("error", err).Error("failed to list events")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	events := make([]*domain.Event, 0)
+	for rows.Next() {
+		var (
+			e        domain.Event
+			book     sql.NullString
+			previous sql.NullString
+		)
+		err = rows.Scan(&e.ID, &e.Type, &e.BookID, &book, &previous, &e.OccurredAt)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan event")
+			return nil, err
+		}
+		e.PreviousStatus = domain.BookStatus(previous.String)
+		if book.Valid {
+			e.Book, err = decodeBook([]byte(book.String))
+			if err != nil {
+				logger.With("error", err, "id", e.ID).Error("failed to decode event book")
+				return nil, err
+			}
+		}
+		events = append(events, &e)
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read events")
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+func TestEventBookRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	events := NewSQLiteEventLog(repo, time.Hour, repo.logger)
+
+	book := &domain.Book{
+		ID:            "b-1",
+		Title:         "Dune",
+		Author:        "Frank Herbert",
+		Authors:       []*domain.Author{{ID: "a-1", Name: "Frank Herbert"}},
+		PublishedYear: ptr(1965),
+		PageCount:     ptr(617),
+		Status:        domain.BookStatusReading,
+	}
+	err := events.Append(ctx, &domain.Event{
+		Type: domain.EventBookCreated, BookID: book.ID, Book: book, OccurredAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	var status string
+	var year int
+	err = repo.db.QueryRow(
+		`SELECT json_extract(book, '$.status'), json_extract(book, '$.published_year') FROM events;`,
+	).Scan(&status, &year)
+	if err != nil {
+		t.Fatalf("unable to query the stored book: %v", err)
+	}
+	if status != "READING" || year != 1965 {
+		t.Errorf("stored status, published_year = %q, %d, want READING, 1965", status, year)
+	}
+
+	assertEventBook(t, events, book)
+}
+
+func TestRewriteEventBooks(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	events := NewSQLiteEventLog(repo, time.Hour, repo.logger)
+
+	book := &domain.Book{
+		ID:            "b-1",
+		Title:         "Dune",
+		Author:        "Frank Herbert",
+		Tags:          []*domain.Tag{{ID: "t-1", Name: "Classics"}},
+		PublishedYear: ptr(1965),
+		SuggestedBy:   ptr("m-1"),
+		Status:        domain.BookStatusSuggested,
+	}
+	// the format of the events stored before 00017_event-books
+	legacy, err := json.Marshal(book)
+	if err != nil {
+		t.Fatalf("unable to encode book: %v", err)
+	}
+	_, err = repo.db.Exec(`INSERT INTO events (type, book_id, book, occurred_at) VALUES (?, ?, ?, ?);`,
+		domain.EventBookCreated, book.ID, string(legacy), time.Now().UTC())
+	if err != nil {
+		t.Fatalf("unable to insert event: %v", err)
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("unable to start transaction: %v", err)
+	}
+	err = rewriteEventBooks(ctx, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		t.Fatalf("rewriteEventBooks() error = %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Fatalf("unable to commit: %v", err)
+	}
+
+	if n := countRows(t, repo, "events", "json_extract(book, '$.suggested_by') = 'm-1'"); n != 1 {
+		t.Errorf("events with suggested_by = %d, want 1", n)
+	}
+	assertEventBook(t, events, book)
+}
+
+// assertEventBook checks that the only stored event holds want.
+func assertEventBook(t *testing.T, events *SQLiteEventLog, want *domain.Book) {
+	t.Helper()
+
+	list, err := events.ListFrom(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatalf("ListFrom() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Book == nil {
+		t.Fatalf("ListFrom() = %v, want one event with a book", list)
+	}
+
+	gotJSON, _ := json.Marshal(list[0].Book)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("event book = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
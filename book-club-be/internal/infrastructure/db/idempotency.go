@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// reservationLease is how long a record stays reserved while its request is
+// served. A reservation older than that was left by a request that never
+// completed, such as when the server crashed, and is given to the next retry.
+const reservationLease = time.Minute
+
+// SQLiteIdempotencyRepository stores the outcome of the requests sent with an
+// idempotency key in a SQLite database, for a limited time.
+// It implements [domain.IdempotencyRepository].
+type SQLiteIdempotencyRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+	ttl    time.Duration
+}
+
+// NewSQLiteIdempotencyRepository creates a new SQLiteIdempotencyRepository
+// sharing the database of repo and keeping the records for ttl.
+func NewSQLiteIdempotencyRepository(
+	repo *SQLiteBookRepository,
+	ttl time.Duration,
+	logger *slog.Logger,
+) *SQLiteIdempotencyRepository {
+	return &SQLiteIdempotencyRepository{
+		db:     repo.db,
+		logger: logger,
+		ttl:    ttl,
+	}
+}
+
+// Reserve stores record unless a record with the same key exists and has not
+// expired, in which case it returns the existing record. Expired records are
+// deleted, together with the reservations older than reservationLease.
+func (repo *SQLiteIdempotencyRepository) Reserve(
+	ctx context.Context,
+	record *domain.IdempotencyRecord,
+) (*domain.IdempotencyRecord, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	record.CreatedAt = time.Now().UTC()
+	record.Status = 0
+
+	var existing *domain.IdempotencyRecord
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM idempotency_keys WHERE created_at < ? OR (status = 0 AND created_at < ?);`,
+			record.CreatedAt.Add(-repo.ttl), record.CreatedAt.Add(-reservationLease),
+		)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO idempotency_keys (key, fingerprint, created_at) VALUES (?, ?, ?)
+			 ON CONFLICT (key) DO NOTHING;`,
+			record.Key, record.Fingerprint, record.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		count, err := res.RowsAffected()
+		if err != nil || count == 1 {
+			return err
+		}
+
+		existing = &domain.IdempotencyRecord{Key: record.Key}
+		return tx.QueryRowContext(ctx,
+			`SELECT fingerprint, status, content_type, location, body, created_at
+			 FROM idempotency_keys WHERE key = ?;`, record.Key,
+		).Scan(&existing.Fingerprint, &existing.Status, &existing.ContentType,
+			&existing.Location, &existing.Body, &existing.CreatedAt)
+	})
+	if err != nil {
+		logger.With("error", err).Error("failed to reserve idempotency key")
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// Complete stores the response of the reserved record, unless its
+// reservation expired and was given to a retry.
+func (repo *SQLiteIdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	_, err := repo.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status = ?, content_type = ?, location = ?, body = ?
+		 WHERE key = ? AND status = 0 AND created_at = ?;`,
+		record.Status, record.ContentType, record.Location, record.Body, record.Key, record.CreatedAt,
+	)
+	if err != nil {
+		logger.With("error", err).Error("failed to store idempotent response")
+		return err
+	}
+
+	return nil
+}
+
+// Release deletes the record identified by key while it is in progress.
+func (repo *SQLiteIdempotencyRepository) Release(ctx context.Context, key string) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = ? AND status = 0;`, key)
+	if err != nil {
+		logger.With("error", err).Error("failed to release idempotency key")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+func TestReserveReclaimsExpiredReservations(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	keys := NewSQLiteIdempotencyRepository(repo, 24*time.Hour, repo.logger)
+	reserve := func() (*domain.IdempotencyRecord, *domain.IdempotencyRecord) {
+		t.Helper()
+		record := &domain.IdempotencyRecord{Key: "request:abc POST /v1/books club-night", Fingerprint: "abc"}
+		existing, err := keys.Reserve(ctx, record)
+		if err != nil {
+			t.Fatalf("Reserve() error = %v", err)
+		}
+		return record, existing
+	}
+
+	stale, existing := reserve()
+	if existing != nil {
+		t.Fatalf("Reserve() = %+v, want the key reserved", existing)
+	}
+	if _, existing = reserve(); existing == nil || existing.Status != 0 {
+		t.Fatalf("Reserve() = %+v, want the reservation in progress", existing)
+	}
+
+	// The server crashed while serving the request.
+	_, err := repo.db.Exec(`UPDATE idempotency_keys SET created_at = ?;`,
+		time.Now().UTC().Add(-reservationLease-time.Second))
+	if err != nil {
+		t.Fatalf("unable to age the reservation: %v", err)
+	}
+	retry, existing := reserve()
+	if existing != nil {
+		t.Fatalf("Reserve() = %+v, want the expired reservation given to the retry", existing)
+	}
+
+	// The stale request must not overwrite the outcome of the retry.
+	stale.Status = 500
+	err = keys.Complete(ctx, stale)
+	if err != nil {
+		t.Fatalf("Complete(stale) error = %v", err)
+	}
+	retry.Status, retry.Body = 201, []byte(`{"id":"b-emma"}`)
+	err = keys.Complete(ctx, retry)
+	if err != nil {
+		t.Fatalf("Complete(retry) error = %v", err)
+	}
+	if _, existing = reserve(); existing == nil || existing.Status != 201 || string(existing.Body) != `{"id":"b-emma"}` {
+		t.Errorf("Reserve() = %+v, want the response of the retry", existing)
+	}
+
+	// A completed record lasts for the whole TTL.
+	_, err = repo.db.Exec(`UPDATE idempotency_keys SET created_at = ?;`,
+		time.Now().UTC().Add(-reservationLease-time.Second))
+	if err != nil {
+		t.Fatalf("unable to age the record: %v", err)
+	}
+	if _, existing = reserve(); existing == nil || existing.Status != 201 {
+		t.Errorf("Reserve() = %+v, want the completed record kept", existing)
+	}
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// memberColumns are the columns read into a [domain.Member] by listMembers.
+const memberColumns = `id, name, email, notify_suggestions, notify_decisions, notify_digest, created_at`
+
+// SQLiteMemberRepository stores the members of the club in a SQLite database.
+// It implements [domain.MemberRepository].
+type SQLiteMemberRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewSQLiteMemberRepository creates a new SQLiteMemberRepository sharing the database of repo.
+func NewSQLiteMemberRepository(repo *SQLiteBookRepository, logger *slog.Logger) *SQLiteMemberRepository {
+	return &SQLiteMemberRepository{
+		db:     repo.db,
+		logger: logger,
+	}
+}
+
+// Create stores a new member, setting its ID and creation time. It returns
+// [domain.ErrorDuplicateEmail] if another member has the same email.
+func (repo *SQLiteMemberRepository) Create(ctx context.Context, member *domain.Member) (*domain.Member, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	member.ID = uuid.NewString()
+	member.CreatedAt = time.Now().UTC()
+
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		err := checkMemberEmail(ctx, tx, member)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO members (`+memberColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?);`,
+			member.ID, member.Name, member.Email, member.Notifications.Suggestions,
+			member.Notifications.Decisions, member.Notifications.Digest, member.CreatedAt,
+		)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrorDuplicateEmail) {
+			logger.With("error", err).Error("failed to create member")
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// Get retrieves the member identified by id. It returns [ErrorNotFound] if
+// the member does not exist.
+func (repo *SQLiteMemberRepository) Get(ctx context.Context, id string) (*domain.Member, error) {
+	members, err := repo.listMembers(ctx, `SELECT `+memberColumns+` FROM members WHERE id = ?;`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, ErrorNotFound
+	}
+
+	return members[0], nil
+}
+
+// List retrieves all the members, ordered by name.
+func (repo *SQLiteMemberRepository) List(ctx context.Context) ([]*domain.Member, error) {
+	return repo.listMembers(ctx, `SELECT `+memberColumns+` FROM members ORDER BY name COLLATE NOCASE, id;`)
+}
+
+// Update stores the name, email and notification preferences of an existing
+// member. It returns [ErrorNotFound] if the member does not exist and
+// [domain.ErrorDuplicateEmail] if another member has the same email.
+func (repo *SQLiteMemberRepository) Update(ctx context.Context, member *domain.Member) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	var res sql.Result
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		err := checkMemberEmail(ctx, tx, member)
+		if err != nil {
+			return err
+		}
+
+		res, err = tx.ExecContext(ctx,
+			`UPDATE members SET name = ?, email = ?, notify_suggestions = ?, notify_decisions = ?, notify_digest = ?
+			 WHERE id = ?;`,
+			member.Name, member.Email, member.Notifications.Suggestions,
+			member.Notifications.Decisions, member.Notifications.Digest, member.ID,
+		)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrorDuplicateEmail) {
+			logger.With("error", err, "id", member.ID).Error("failed to update member")
+		}
+		return err
+	}
+
+	return checkAffected(logger, res)
+}
+
+// Delete removes the member identified by id. Their notifications are
+// deleted in cascade, while the books they suggested are kept, without
+// suggester. It returns [ErrorNotFound] if the member does not exist.
+func (repo *SQLiteMemberRepository) Delete(ctx context.Context, id string) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	res, err := conn(ctx, repo.db).ExecContext(ctx, `DELETE FROM members WHERE id = ?;`, id)
+	if err != nil {
+		logger.With("error", err, "id", id).Error("failed to delete member")
+		return err
+	}
+
+	return checkAffected(logger, res)
+}
+
+// listMembers runs query, which selects the [memberColumns], and returns the members read.
+func (repo *SQLiteMemberRepository) listMembers(
+	ctx context.Context,
+	query string,
+	args ...any,
+) ([]*domain.Member, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.With("error", err).Error("failed to list members")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	members := make([]*domain.Member, 0)
+	for rows.Next() {
+		var m domain.Member
+		err = rows.Scan(&m.ID, &m.Name, &m.Email, &m.Notifications.Suggestions,
+			&m.Notifications.Decisions, &m.Notifications.Digest, &m.CreatedAt)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan member")
+			return nil, err
+		}
+		members = append(members, &m)
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read members")
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// checkMemberEmail verifies that the email of member is not used by another member.
+func checkMemberEmail(ctx context.Context, tx *sql.Tx, member *domain.Member) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM members WHERE email = ? COLLATE NOCASE AND id != ?);`,
+		member.Email, member.ID,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrorDuplicateEmail
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// CachedMetadataProvider stores the responses of a [domain.MetadataProvider]
+// in SQLite, so that catalogs are queried at most once per book within the
+// cache TTL. Books unknown to the provider are cached as well.
+// It implements [domain.MetadataProvider].
+type CachedMetadataProvider struct {
+	provider domain.MetadataProvider
+	db       *sql.DB
+	logger   *slog.Logger
+	ttl      time.Duration
+}
+
+// NewCachedMetadataProvider creates a new CachedMetadataProvider wrapping
+// provider and storing its responses for ttl in the database behind repo.
+func NewCachedMetadataProvider(
+	repo *SQLiteBookRepository,
+	provider domain.MetadataProvider,
+	ttl time.Duration,
+	logger *slog.Logger,
+) *CachedMetadataProvider {
+	return &CachedMetadataProvider{
+		provider: provider,
+		db:       repo.db,
+		logger:   logger,
+		ttl:      ttl,
+	}
+}
+
+// Name identifies the wrapped provider.
+func (c *CachedMetadataProvider) Name() string {
+	return c.provider.Name()
+}
+
+// Lookup retrieves the metadata from the cache, or from the wrapped provider
+// when missing or expired. Cache failures are logged and bypass the cache.
+func (c *CachedMetadataProvider) Lookup(ctx context.Context, query domain.MetadataQuery) (*domain.BookMetadata, error) {
+	logger := logctx.FromContext(ctx, c.logger)
+	key := cacheKey(query)
+
+	var (
+		response  sql.NullString
+		fetchedAt time.Time
+	)
+	err := conn(ctx, c.db).QueryRowContext(ctx,
+		`SELECT response, fetched_at FROM metadata_cache WHERE provider = ? AND query = ?;`,
+		c.provider.Name(), key,
+	).Scan(&response, &fetchedAt)
+	switch {
+	case err == nil && time.Since(fetchedAt) < c.ttl:
+		if !response.Valid {
+			return nil, domain.ErrorNotFound
+		}
+		var m domain.BookMetadata
+		err = json.Unmarshal([]byte(response.String), &m)
+		if err == nil {
+			return &m, nil
+		}
+		logger.With("error", err, "query", key).Error("unable to decode cached metadata")
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		logger.With("error", err, "query", key).Error("unable to read metadata cache")
+	}
+
+	m, err := c.provider.Lookup(ctx, query)
+	if err != nil && !errors.Is(err, domain.ErrorNotFound) {
+		return nil, err
+	}
+
+	var stored *string
+	if m != nil {
+		b, mErr := json.Marshal(m)
+		if mErr != nil {
+			return nil, mErr
+		}
+		s := string(b)
+		stored = &s
+	}
+
+	_, cacheErr := conn(ctx, c.db).ExecContext(ctx,
+		`INSERT INTO metadata_cache (provider, query, response, fetched_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (provider, query) DO UPDATE SET response = excluded.response, fetched_at = excluded.fetched_at;`,
+		c.provider.Name(), key, stored, time.Now().UTC(),
+	)
+	if cacheErr != nil {
+		logger.With("error", cacheErr, "query", key).Error("unable to write metadata cache")
+	}
+
+	return m, err
+}
+
+// cacheKey returns the normalized form of query used as cache key.
+func cacheKey(query domain.MetadataQuery) string {
+	if query.ISBN != "" {
+		return "isbn:" + query.ISBN
+	}
+	return "title:" + strings.ToLower(strings.TrimSpace(query.Title)) +
+		"|author:" + strings.ToLower(strings.TrimSpace(query.Author))
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// SQLiteNotificationRepository stores the queue of the emails to send to the
+// members in a SQLite database. Sent and failed notifications are kept for a
+// limited time. It implements [domain.NotificationRepository].
+type SQLiteNotificationRepository struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	retention time.Duration
+}
+
+// NewSQLiteNotificationRepository creates a new SQLiteNotificationRepository
+// sharing the database of repo and keeping the finished notifications for retention.
+func NewSQLiteNotificationRepository(
+	repo *SQLiteBookRepository,
+	retention time.Duration,
+	logger *slog.Logger,
+) *SQLiteNotificationRepository {
+	return &SQLiteNotificationRepository{
+		db:        repo.db,
+		logger:    logger,
+		retention: retention,
+	}
+}
+
+// Enqueue stores a new pending notification, setting its ID and creation
+// time, unless a notification with the same dedup key exists. The finished
+// notifications older than the retention are deleted.
+func (repo *SQLiteNotificationRepository) Enqueue(ctx context.Context, n *domain.Notification) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	n.ID = uuid.NewString()
+	n.CreatedAt = time.Now().UTC()
+	n.Status = domain.DeliveryPending
+	n.Attempts = 0
+	n.NextAttemptAt = &n.CreatedAt
+
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM notifications WHERE status <> ? AND created_at < ?;`,
+			domain.DeliveryPending, n.CreatedAt.Add(-repo.retention),
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO notifications (id, member_id, kind, dedup_key, recipient, subject, text_body, html_body,
+				status, next_attempt_at, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 ON CONFLICT (dedup_key) DO NOTHING;`,
+			n.ID, n.MemberID, n.Kind, n.DedupKey, n.Email.To, n.Email.Subject, n.Email.Text, n.Email.HTML,
+			n.Status, n.NextAttemptAt, n.CreatedAt,
+		)
+		return err
+	})
+	if err != nil {
+		logger.With("error", err, "member_id", n.MemberID, "kind", n.Kind).Error("failed to enqueue notification")
+		return err
+	}
+
+	return nil
+}
+
+// Due retrieves up to limit pending notifications whose next attempt is due
+// at now, oldest first.
+func (repo *SQLiteNotificationRepository) Due(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*domain.Notification, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	rows, err := conn(ctx, repo.db).QueryContext(ctx,
+		`SELECT id, member_id, kind, dedup_key, recipient, subject, text_body, html_body, status, attempts,
+			next_attempt_at, last_error, created_at, sent_at
+		 FROM notifications WHERE status = ? AND next_attempt_at <= ?
+		 ORDER BY next_attempt_at, created_at LIMIT ?;`,
+		domain.DeliveryPending, now.UTC(), limit,
+	)
+	if err != nil {
+		logger.With("error", err).Error("failed to list due notifications")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	notifications := make([]*domain.Notification, 0)
+	for rows.Next() {
+		var (
+			n         = domain.Notification{Email: &domain.Email{}}
+			lastError sql.NullString
+		)
+		err = rows.Scan(&n.ID, &n.MemberID, &n.Kind, &n.DedupKey, &n.Email.To, &n.Email.Subject,
+			&n.Email.Text, &n.Email.HTML, &n.Status, &n.Attempts, &n.NextAttemptAt, &lastError,
+			&n.CreatedAt, &n.SentAt)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan notification")
+			return nil, err
+		}
+		n.LastError = lastError.String
+		notifications = append(notifications, &n)
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read notifications")
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// Update stores the status and the outcome of the last attempt of
+// notification. It returns [ErrorNotFound] if the notification does not exist.
+func (repo *SQLiteNotificationRepository) Update(ctx context.Context, n *domain.Notification) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	utc := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		u := t.UTC()
+		return &u
+	}
+
+	res, err := conn(ctx, repo.db).ExecContext(ctx,
+		`UPDATE notifications SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, sent_at = ?
+		 WHERE id = ?;`,
+		n.Status, n.Attempts, utc(n.NextAttemptAt),
+		sql.NullString{String: n.LastError, Valid: n.LastError != ""}, utc(n.SentAt), n.ID,
+	)
+	if err != nil {
+		logger.With("error", err, "id", n.ID).Error("failed to update notification")
+		return err
+	}
+
+	return checkAffected(logger, res)
+}
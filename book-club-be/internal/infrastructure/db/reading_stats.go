@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// activeBooks selects the IDs of the books not in the trash.
+const activeBooks = `SELECT id FROM books WHERE deleted_at IS NULL`
+
+// readingTimes selects, for each completion of a book, the seconds elapsed
+// since the book last started being read.
+const readingTimes = `SELECT (julianday(c.changed_at) - julianday(r.changed_at)) * 86400 AS seconds
+	FROM book_status_changes c
+	JOIN book_status_changes r ON r.id = (
+		SELECT MAX(id) FROM book_status_changes
+		WHERE book_id = c.book_id AND status = 'READING' AND id < c.id
+	)
+	WHERE c.status = 'COMPLETED' AND c.book_id IN (` + activeBooks + `)`
+
+// SQLiteStatsRepository computes the reading statistics with SQL aggregates
+// over the books and their status history. It implements [domain.StatsRepository].
+type SQLiteStatsRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewSQLiteStatsRepository creates a new SQLiteStatsRepository sharing the database of repo.
+func NewSQLiteStatsRepository(repo *SQLiteBookRepository, logger *slog.Logger) *SQLiteStatsRepository {
+	return &SQLiteStatsRepository{
+		db:     repo.db,
+		logger: logger,
+	}
+}
+
+// ReadingStats computes the statistics of the books not in the trash, within
+// a single transaction so that they are consistent with each other. The
+// completions and the decisions are read from the status history, which only
+// goes back to the events stored when it was introduced.
+func (repo *SQLiteStatsRepository) ReadingStats(ctx context.Context) (*domain.ReadingStats, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	stats := &domain.ReadingStats{Books: make(map[domain.BookStatus]int)}
+
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		err := queryRows(ctx, tx, `SELECT status, COUNT(*) FROM books WHERE deleted_at IS NULL GROUP BY status;`,
+			func(rows *sql.Rows) error {
+				var (
+					status domain.BookStatus
+					count  int
+				)
+				err := rows.Scan(&status, &count)
+				stats.Books[status] = count
+				return err
+			})
+		if err != nil {
+			return err
+		}
+
+		err = queryRows(ctx, tx,
+			`SELECT CAST(strftime('%Y', changed_at) AS INTEGER), COUNT(*)
+			 FROM book_status_changes WHERE status = 'COMPLETED' AND book_id IN (`+activeBooks+`)
+			 GROUP BY 1 ORDER BY 1;`,
+			func(rows *sql.Rows) error {
+				var p domain.PeriodCount
+				err := rows.Scan(&p.Year, &p.Count)
+				stats.CompletedByYear = append(stats.CompletedByYear, p)
+				return err
+			})
+		if err != nil {
+			return err
+		}
+
+		err = queryRows(ctx, tx,
+			`SELECT CAST(strftime('%Y', changed_at) AS INTEGER), CAST(strftime('%m', changed_at) AS INTEGER), COUNT(*)
+			 FROM book_status_changes WHERE status = 'COMPLETED' AND book_id IN (`+activeBooks+`)
+			 GROUP BY 1, 2 ORDER BY 1, 2;`,
+			func(rows *sql.Rows) error {
+				var p domain.PeriodCount
+				err := rows.Scan(&p.Year, &p.Month, &p.Count)
+				stats.CompletedByMonth = append(stats.CompletedByMonth, p)
+				return err
+			})
+		if err != nil {
+			return err
+		}
+
+		var avg, shortest, longest sql.NullFloat64
+		err = tx.QueryRowContext(ctx,
+			`SELECT COUNT(*), AVG(seconds), MIN(seconds), MAX(seconds) FROM (`+readingTimes+`);`,
+		).Scan(&stats.Reading.Completed, &avg, &shortest, &longest)
+		if err != nil {
+			return err
+		}
+		stats.Reading.Average = seconds(avg.Float64)
+		stats.Reading.Shortest = seconds(shortest.Float64)
+		stats.Reading.Longest = seconds(longest.Float64)
+
+		err = queryRows(ctx, tx,
+			`SELECT COALESCE(genre, ''), COUNT(*) FROM books WHERE deleted_at IS NULL
+			 GROUP BY 1 ORDER BY 2 DESC, 1;`,
+			func(rows *sql.Rows) error {
+				var g domain.GenreCount
+				err := rows.Scan(&g.Genre, &g.Count)
+				stats.Genres = append(stats.Genres, g)
+				return err
+			})
+		if err != nil {
+			return err
+		}
+
+		err = queryRows(ctx, tx,
+			`SELECT a.id, a.name, COUNT(*) FROM authors a
+			 JOIN book_authors ba ON ba.author_id = a.id
+			 WHERE ba.book_id IN (`+activeBooks+`)
+			 GROUP BY a.id ORDER BY 3 DESC, a.name COLLATE NOCASE, a.id;`,
+			func(rows *sql.Rows) error {
+				a := domain.AuthorCount{Author: &domain.Author{}}
+				err := rows.Scan(&a.Author.ID, &a.Author.Name, &a.Count)
+				stats.Authors = append(stats.Authors, a)
+				return err
+			})
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`SELECT
+				(SELECT COUNT(*) FROM books WHERE deleted_at IS NULL AND status = 'SUGGESTED'),
+				COALESCE(SUM(status = 'READING'), 0),
+				COALESCE(SUM(status = 'DISCARDED'), 0)
+			 FROM book_status_changes
+			 WHERE previous_status = 'SUGGESTED' AND book_id IN (`+activeBooks+`);`,
+		).Scan(&stats.Suggestions.Pending, &stats.Suggestions.Accepted, &stats.Suggestions.Discarded)
+		if err != nil {
+			return err
+		}
+
+		return queryRows(ctx, tx,
+			`SELECT m.id, m.name, m.email, m.created_at, COUNT(b.id),
+				COALESCE(SUM(b.status IN ('READING', 'COMPLETED')), 0), COALESCE(SUM(b.status = 'COMPLETED'), 0)
+			 FROM members m
+			 LEFT JOIN books b ON b.suggested_by = m.id AND b.deleted_at IS NULL
+			 GROUP BY m.id ORDER BY 5 DESC, m.name COLLATE NOCASE, m.id;`,
+			func(rows *sql.Rows) error {
+				c := domain.MemberContribution{Member: &domain.Member{}}
+				err := rows.Scan(&c.Member.ID, &c.Member.Name, &c.Member.Email, &c.Member.CreatedAt,
+					&c.Suggested, &c.Accepted, &c.Completed)
+				stats.Members = append(stats.Members, c)
+				return err
+			})
+	})
+	if err != nil {
+		logger.With("error", err).Error("failed to compute reading stats")
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// queryRows runs query in tx and calls scan for each row read.
+func queryRows(
+	ctx context.Context,
+	tx *sql.Tx,
+	query string,
+	scan func(rows *sql.Rows) error,
+	args ...any,
+) (err error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	for rows.Next() {
+		err = scan(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// seconds converts a number of seconds computed by SQLite to a duration,
+// rounded to the second.
+func seconds(s float64) time.Duration {
+	return (time.Duration(s * float64(time.Second))).Round(time.Second)
+}
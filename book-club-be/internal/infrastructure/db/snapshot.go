@@ -0,0 +1,307 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+const (
+	snapshotPrefix     = "books-"
+	snapshotSuffix     = ".db"
+	snapshotTimeLayout = "20060102T150405.000Z"
+)
+
+// ErrorSnapshotIncompatible is the sentinel error when a snapshot cannot be
+// restored with the migrations available to the running binary.
+var ErrorSnapshotIncompatible = errors.New("snapshot is not compatible with the available migrations")
+
+// SQLiteSnapshotRepository stores online snapshots of a SQLite database as
+// files in a directory. It implements [domain.SnapshotRepository].
+type SQLiteSnapshotRepository struct {
+	db     *sql.DB
+	dir    string
+	logger *slog.Logger
+}
+
+// NewSQLiteSnapshotRepository creates a new SQLiteSnapshotRepository that takes
+// snapshots of the database behind repo and stores them in dir. The directory
+// is created if it does not exist.
+func NewSQLiteSnapshotRepository(
+	repo *SQLiteBookRepository,
+	dir string,
+	logger *slog.Logger,
+) (*SQLiteSnapshotRepository, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		logger.With("error", err, "dir", dir).Error("unable to create snapshot directory")
+		return nil, err
+	}
+
+	return &SQLiteSnapshotRepository{
+		db:     repo.db,
+		dir:    dir,
+		logger: logger,
+	}, nil
+}
+
+// Create takes a snapshot with VACUUM INTO, which produces a consistent,
+// compacted copy of the database without blocking concurrent readers.
+func (repo *SQLiteSnapshotRepository) Create(ctx context.Context) (*domain.Snapshot, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	createdAt := time.Now().UTC()
+	name := snapshotPrefix + createdAt.Format(snapshotTimeLayout) + snapshotSuffix
+	path := filepath.Join(repo.dir, name)
+
+	_, err := repo.db.ExecContext(ctx, `VACUUM INTO ?`, path)
+	if err != nil {
+		logger.With("error", err, "path", path).Error("failed to take snapshot")
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		logger.With("error", err, "path", path).Error("unable to stat snapshot")
+		return nil, err
+	}
+
+	return &domain.Snapshot{
+		CreatedAt: createdAt,
+		Name:      name,
+		Size:      info.Size(),
+	}, nil
+}
+
+// List retrieves all the snapshots stored in the snapshot directory, newest first.
+func (repo *SQLiteSnapshotRepository) List(context.Context) ([]*domain.Snapshot, error) {
+	entries, err := os.ReadDir(repo.dir)
+	if err != nil {
+		repo.logger.With("error", err, "dir", repo.dir).Error("unable to read snapshot directory")
+		return nil, err
+	}
+
+	snapshots := make([]*domain.Snapshot, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !isSnapshotName(e.Name()) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			repo.logger.With("error", err, "name", e.Name()).Error("unable to stat snapshot")
+			return nil, err
+		}
+
+		ts := strings.TrimSuffix(strings.TrimPrefix(e.Name(), snapshotPrefix), snapshotSuffix)
+		createdAt, err := time.Parse(snapshotTimeLayout, ts)
+		if err != nil {
+			createdAt = info.ModTime().UTC()
+		}
+
+		snapshots = append(snapshots, &domain.Snapshot{
+			CreatedAt: createdAt,
+			Name:      e.Name(),
+			Size:      info.Size(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// Delete removes the snapshot identified by name from the snapshot directory.
+func (repo *SQLiteSnapshotRepository) Delete(_ context.Context, name string) error {
+	if !isSnapshotName(name) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	err := os.Remove(filepath.Join(repo.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrorNotFound
+	}
+	if err != nil {
+		repo.logger.With("error", err, "name", name).Error("failed to delete snapshot")
+		return err
+	}
+
+	return nil
+}
+
+// isSnapshotName reports whether name is a bare file name produced by Create.
+func isSnapshotName(name string) bool {
+	return filepath.Base(name) == name &&
+		strings.HasPrefix(name, snapshotPrefix) &&
+		strings.HasSuffix(name, snapshotSuffix)
+}
+
+// RestoreSnapshot replaces the database at dbPath with the snapshot at
+// snapshotPath. The snapshot must pass an integrity check and its migration
+// level must not be ahead of the migrations found in migrationsPath, so that
+// the running binary can bring it up to date. The replaced database is kept
+// next to dbPath with a ".pre-restore" suffix.
+//
+// RestoreSnapshot must not be called while a server is using dbPath.
+func RestoreSnapshot(ctx context.Context, snapshotPath, dbPath, migrationsPath string, logger *slog.Logger) error {
+	level, err := checkSnapshot(ctx, snapshotPath, migrationsPath)
+	if err != nil {
+		logger.With("error", err, "snapshot", snapshotPath).Error("snapshot validation failed")
+		return err
+	}
+	logger.With("snapshot", snapshotPath, "migration", level).Info("snapshot validated")
+
+	tmpPath := dbPath + ".restore"
+	err = copyFile(snapshotPath, tmpPath)
+	if err != nil {
+		logger.With("error", err, "snapshot", snapshotPath).Error("unable to copy snapshot")
+		return err
+	}
+
+	_, err = os.Stat(dbPath)
+	switch {
+	case err == nil:
+		backupPath := dbPath + ".pre-restore"
+		err = os.Rename(dbPath, backupPath)
+		if err != nil {
+			logger.With("error", err, "path", dbPath).Error("unable to move current database aside")
+			return err
+		}
+		logger.With("path", backupPath).Info("current database moved aside")
+	case !errors.Is(err, os.ErrNotExist):
+		logger.With("error", err, "path", dbPath).Error("unable to stat current database")
+		return err
+	}
+
+	// Stale WAL files belong to the replaced database and must not be replayed on the snapshot.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		err = os.Remove(dbPath + suffix)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.With("error", err, "path", dbPath+suffix).Error("unable to remove stale database file")
+			return err
+		}
+	}
+
+	err = os.Rename(tmpPath, dbPath)
+	if err != nil {
+		logger.With("error", err, "path", dbPath).Error("unable to swap in snapshot")
+		return err
+	}
+
+	logger.With("snapshot", snapshotPath, "path", dbPath).Info("snapshot restored")
+
+	return nil
+}
+
+// checkSnapshot validates the snapshot at path and returns its migration
+// level, i.e. the name of the last migration applied to it.
+func checkSnapshot(ctx context.Context, path, migrationsPath string) (level string, err error) {
+	_, err = os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err = errors.Join(err, snapshot.Close())
+	}()
+
+	var integrity string
+	err = snapshot.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&integrity)
+	if err != nil {
+		return "", err
+	}
+	if integrity != "ok" {
+		return "", fmt.Errorf("snapshot integrity check failed: %s", integrity)
+	}
+
+	applied, err := appliedMigrations(ctx, snapshot)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrorSnapshotIncompatible, err)
+	}
+
+	available, err := listMigrations(migrationsPath)
+	if err != nil {
+		return "", err
+	}
+	known := make(map[string]struct{}, len(available))
+	for _, m := range available {
+		known[m] = struct{}{}
+	}
+
+	for _, m := range applied {
+		if _, ok := known[m]; !ok {
+			return "", fmt.Errorf("%w: unknown migration %s", ErrorSnapshotIncompatible, m)
+		}
+	}
+	if len(applied) == 0 {
+		return "", fmt.Errorf("%w: no migrations applied", ErrorSnapshotIncompatible)
+	}
+
+	return applied[len(applied)-1], nil
+}
+
+// appliedMigrations returns the sorted names of the migrations recorded in the migrations table of conn.
+func appliedMigrations(ctx context.Context, conn *sql.DB) (names []string, err error) {
+	rows, err := conn.QueryContext(ctx, `SELECT name FROM migrations ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	for rows.Next() {
+		var n string
+		err = rows.Scan(&n)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+
+	return names, rows.Err()
+}
+
+// copyFile copies src to dst and flushes dst to stable storage.
+func copyFile(src, dst string) (err error) {
+	//nolint:gosec // the path is provided by the operator
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, in.Close())
+	}()
+
+	//nolint:gosec // the path is provided by the operator
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, out.Close())
+	}()
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		return err
+	}
+
+	return out.Sync()
+}
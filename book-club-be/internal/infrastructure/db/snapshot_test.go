@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIsSnapshotName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "books-20240102T030405.000Z.db", want: true},
+		{name: "books-manual.db", want: true},
+		{name: "books.db", want: false},
+		{name: "books-20240102T030405.000Z.db-wal", want: false},
+		{name: "notes-20240102T030405.000Z.db", want: false},
+		{name: "../books-20240102T030405.000Z.db", want: false},
+		{name: "backups/books-20240102T030405.000Z.db", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isSnapshotName(tt.name); got != tt.want {
+			t.Errorf("isSnapshotName(%q) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListIgnoresForeignFiles(t *testing.T) {
+	repo := newTestRepo(t)
+	dir := t.TempDir()
+	snapshots, err := NewSQLiteSnapshotRepository(repo, dir, repo.logger)
+	if err != nil {
+		t.Fatalf("NewSQLiteSnapshotRepository() error = %v", err)
+	}
+
+	for _, name := range []string{"books-20240101T000000.000Z.db", "books-20240301T000000.000Z.db", "notes.txt", "books.db"} {
+		err = os.WriteFile(filepath.Join(dir, name), nil, 0o600)
+		if err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+	err = os.Mkdir(filepath.Join(dir, "books-20240201T000000.000Z.db"), 0o750)
+	if err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+
+	list, err := snapshots.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	names := make([]string, len(list))
+	for i, s := range list {
+		names[i] = s.Name
+	}
+	want := []string{"books-20240301T000000.000Z.db", "books-20240101T000000.000Z.db"}
+	if !slices.Equal(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+
+	err = snapshots.Delete(context.Background(), "notes.txt")
+	if err == nil {
+		t.Error("Delete(notes.txt) error = nil, want a foreign file left alone")
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare alters the snapshot at path before it is restored.
+		prepare func(t *testing.T, path string)
+		// wantErr is nil when the snapshot must be restored.
+		wantErr error
+	}{
+		{
+			name:    "compatible",
+			prepare: func(*testing.T, string) {},
+		},
+		{
+			name: "newer schema",
+			prepare: func(t *testing.T, path string) {
+				execSnapshot(t, path, `INSERT INTO migrations(name) VALUES ('99999_from-the-future.sql')`)
+			},
+			wantErr: ErrorSnapshotIncompatible,
+		},
+		{
+			name: "no migrations",
+			prepare: func(t *testing.T, path string) {
+				execSnapshot(t, path, `DELETE FROM migrations`)
+			},
+			wantErr: ErrorSnapshotIncompatible,
+		},
+		{
+			name: "no migrations table",
+			prepare: func(t *testing.T, path string) {
+				execSnapshot(t, path, `DROP TABLE migrations`)
+			},
+			wantErr: ErrorSnapshotIncompatible,
+		},
+		{
+			name: "missing",
+			prepare: func(t *testing.T, path string) {
+				err := os.Remove(path)
+				if err != nil {
+					t.Fatalf("unable to remove snapshot: %v", err)
+				}
+			},
+			wantErr: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newTestRepo(t)
+			snapshots, err := NewSQLiteSnapshotRepository(repo, t.TempDir(), repo.logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteSnapshotRepository() error = %v", err)
+			}
+			snapshot, err := snapshots.Create(ctx)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			snapshotPath := filepath.Join(snapshots.dir, snapshot.Name)
+			tt.prepare(t, snapshotPath)
+
+			dbPath := filepath.Join(t.TempDir(), "books.db")
+			current := []byte("current database")
+			err = os.WriteFile(dbPath, current, 0o600)
+			if err != nil {
+				t.Fatalf("unable to write database: %v", err)
+			}
+
+			err = RestoreSnapshot(ctx, snapshotPath, dbPath, migrationsPath, repo.logger)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RestoreSnapshot() error = %v, want %v", err, tt.wantErr)
+				}
+				if got, _ := os.ReadFile(dbPath); string(got) != string(current) {
+					t.Errorf("database = %q, want it left untouched", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("RestoreSnapshot() error = %v", err)
+			}
+			if got, _ := os.ReadFile(dbPath + ".pre-restore"); string(got) != string(current) {
+				t.Errorf("replaced database = %q, want it kept aside", got)
+			}
+			level, err := checkSnapshot(ctx, dbPath, migrationsPath)
+			if err != nil {
+				t.Fatalf("checkSnapshot() of the restored database error = %v", err)
+			}
+			available, _ := listMigrations(migrationsPath)
+			if level != available[len(available)-1] {
+				t.Errorf("restored database is at migration %s, want %s", level, available[len(available)-1])
+			}
+		})
+	}
+}
+
+// execSnapshot runs query on the snapshot at path.
+func execSnapshot(t *testing.T, path, query string) {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatalf("unable to open snapshot: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Exec(query)
+	if err != nil {
+		t.Fatalf("unable to alter snapshot: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// DB returns the database handle of the repository, e.g. to collect the
+// statistics of its connection pool.
+func (repo *SQLiteBookRepository) DB() *sql.DB {
+	return repo.db
+}
+
+// MigrationLevel returns the name of the last migration applied to the
+// database, or an empty string if none was applied.
+func (repo *SQLiteBookRepository) MigrationLevel(ctx context.Context) (string, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	var level sql.NullString
+	err := repo.db.QueryRowContext(ctx, `SELECT MAX(name) FROM migrations;`).Scan(&level)
+	if err != nil {
+		logger.With("error", err).Error("failed to read migration level")
+		return "", err
+	}
+
+	return level.String, nil
+}
+
+// CountByStatus returns the number of books for each status, excluding the
+// books in the trash. Statuses without books are omitted.
+func (repo *SQLiteBookRepository) CountByStatus(ctx context.Context) (counts map[domain.BookStatus]int, err error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	rows, err := repo.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM books WHERE deleted_at IS NULL GROUP BY status;`)
+	if err != nil {
+		logger.With("error", err).Error("failed to count books")
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	counts = make(map[domain.BookStatus]int)
+	for rows.Next() {
+		var (
+			status domain.BookStatus
+			count  int
+		)
+		err = rows.Scan(&status, &count)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan book count")
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,354 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// bookTagsColumn selects the tags of a book, ordered by name, as a JSON array.
+const bookTagsColumn = `(SELECT json_group_array(json_object('id', t.id, 'name', t.name, 'parent_id', t.parent_id)
+	ORDER BY t.name_key)
+	FROM book_tags bt JOIN tags t ON t.id = bt.tag_id
+	WHERE bt.book_id = books.id)`
+
+// tagGroupCondition matches the books having one of the tags whose keys are
+// listed in place of %s, or one of their descendants.
+const tagGroupCondition = `id IN (SELECT bt.book_id FROM book_tags bt WHERE bt.tag_id IN (
+	WITH RECURSIVE matched(id) AS (
+		SELECT id FROM tags WHERE name_key IN (%s)
+		UNION SELECT t.id FROM tags t JOIN matched m ON t.parent_id = m.id
+	)
+	SELECT id FROM matched
+))`
+
+// genreTags adds the genres of the existing books to the tag vocabulary and
+// tags every book with its genre.
+func genreTags(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT genre FROM books WHERE genre IS NOT NULL AND genre != '';`)
+	if err != nil {
+		return err
+	}
+	genres := make([]string, 0)
+	for rows.Next() {
+		var g string
+		err = rows.Scan(&g)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		genres = append(genres, g)
+	}
+	err = errors.Join(rows.Err(), rows.Close())
+	if err != nil {
+		return err
+	}
+
+	for _, g := range genres {
+		key := domain.TagKey(g)
+		if key == "" {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO tags (id, name, name_key) VALUES (?, ?, ?) ON CONFLICT (name_key) DO NOTHING;`,
+			uuid.NewString(), g, key,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO book_tags (book_id, tag_id)
+			 SELECT id, (SELECT id FROM tags WHERE name_key = ?) FROM books WHERE genre = ?;`,
+			key, g,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setBookTags replaces the tags of the book identified by bookID.
+func setBookTags(ctx context.Context, tx *sql.Tx, bookID string, tags []*domain.Tag) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM book_tags WHERE book_id = ?;`, bookID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tags {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO book_tags (book_id, tag_id) VALUES (?, ?);`, bookID, t.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SQLiteTagRepository provides access to the tag vocabulary stored in a
+// SQLite database. It implements [domain.TagRepository].
+type SQLiteTagRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewSQLiteTagRepository creates a new SQLiteTagRepository sharing the database of repo.
+func NewSQLiteTagRepository(repo *SQLiteBookRepository, logger *slog.Logger) *SQLiteTagRepository {
+	return &SQLiteTagRepository{
+		db:     repo.db,
+		logger: logger,
+	}
+}
+
+// List retrieves all tags, ordered by name.
+func (repo *SQLiteTagRepository) List(ctx context.Context) ([]*domain.Tag, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, `SELECT id, name, parent_id FROM tags ORDER BY name_key, id;`)
+	if err != nil {
+		logger.With("error", err).Error("failed to list tags")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	tags := make([]*domain.Tag, 0)
+	for rows.Next() {
+		var t domain.Tag
+		err = rows.Scan(&t.ID, &t.Name, &t.ParentID)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan tag")
+			return nil, err
+		}
+		tags = append(tags, &t)
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read tags")
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// Create inserts a new tag. If the tag has no ID, a new UUID is generated
+// automatically. It returns [domain.ErrorDuplicateTag] if another tag has the
+// same name, and [domain.ErrorUnknownTag] if the parent does not exist.
+func (repo *SQLiteTagRepository) Create(ctx context.Context, tag *domain.Tag) (*domain.Tag, error) {
+	if tag.ID == "" {
+		tag.ID = uuid.NewString()
+	}
+
+	err := repo.inTx(ctx, tag.ID, func(tx *sql.Tx) error {
+		err := checkTag(ctx, tx, tag)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO tags (id, name, name_key, parent_id) VALUES (?, ?, ?, ?);`,
+			tag.ID, tag.Name, domain.TagKey(tag.Name), tag.ParentID,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// Update renames or moves an existing tag. The books whose genre is the old
+// name of the tag get the new name. It returns [ErrorNotFound] if the tag does
+// not exist, [domain.ErrorDuplicateTag] if another tag has the same name,
+// [domain.ErrorUnknownTag] if the parent does not exist and
+// [domain.ErrorTagCycle] if the parent is the tag itself or one of its descendants.
+func (repo *SQLiteTagRepository) Update(ctx context.Context, tag *domain.Tag) error {
+	return repo.inTx(ctx, tag.ID, func(tx *sql.Tx) error {
+		var oldName string
+		err := tx.QueryRowContext(ctx, `SELECT name FROM tags WHERE id = ?;`, tag.ID).Scan(&oldName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		err = checkTag(ctx, tx, tag)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE tags SET name = ?, name_key = ?, parent_id = ? WHERE id = ?;`,
+			tag.Name, domain.TagKey(tag.Name), tag.ParentID, tag.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE books SET genre = ? WHERE genre = ?;`, tag.Name, oldName)
+		return err
+	})
+}
+
+// Merge moves the books and the children of the source tags to the target
+// tag and deletes the source tags. The books whose genre is the name of a
+// source tag get the name of the target. It returns [ErrorNotFound] if any of
+// the tags does not exist.
+func (repo *SQLiteTagRepository) Merge(ctx context.Context, targetID string, sourceIDs []string) error {
+	return repo.inTx(ctx, targetID, func(tx *sql.Tx) error {
+		var targetName string
+		err := tx.QueryRowContext(ctx, `SELECT name FROM tags WHERE id = ?;`, targetID).Scan(&targetName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, sourceID := range sourceIDs {
+			if sourceID == targetID {
+				continue
+			}
+
+			err = mergeTag(ctx, tx, targetID, targetName, sourceID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// mergeTag merges the tag identified by sourceID into the target tag.
+func mergeTag(ctx context.Context, tx *sql.Tx, targetID, targetName, sourceID string) error {
+	var sourceName string
+	var sourceParentID *string
+	err := tx.QueryRowContext(ctx,
+		`SELECT name, parent_id FROM tags WHERE id = ?;`, sourceID,
+	).Scan(&sourceName, &sourceParentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrorNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Books with both tags keep their link to the target: the link to the
+	// source is left in place and deleted with the source.
+	_, err = tx.ExecContext(ctx, `UPDATE OR IGNORE book_tags SET tag_id = ? WHERE tag_id = ?;`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	// The children of the source move under the target, except for the
+	// target and its ancestors, which take the place of the source.
+	_, err = tx.ExecContext(ctx,
+		`WITH RECURSIVE ancestors(id) AS (
+			SELECT ?
+			UNION SELECT t.parent_id FROM tags t JOIN ancestors a ON t.id = a.id WHERE t.parent_id IS NOT NULL
+		 )
+		 UPDATE tags SET parent_id = CASE WHEN id IN (SELECT id FROM ancestors) THEN ? ELSE ? END
+		 WHERE parent_id = ?;`,
+		targetID, sourceParentID, targetID, sourceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE books SET genre = ? WHERE genre = ?;`, targetName, sourceName)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?;`, sourceID)
+
+	return err
+}
+
+// checkTag verifies that the name of tag is not used by another tag and
+// that its parent, if any, exists and is not the tag or one of its descendants.
+func checkTag(ctx context.Context, tx *sql.Tx, tag *domain.Tag) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM tags WHERE name_key = ? AND id != ?);`,
+		domain.TagKey(tag.Name), tag.ID,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrorDuplicateTag
+	}
+
+	if tag.ParentID == nil {
+		return nil
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tags WHERE id = ?);`, *tag.ParentID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrorUnknownTag
+	}
+
+	var cycle bool
+	err = tx.QueryRowContext(ctx,
+		`WITH RECURSIVE ancestors(id) AS (
+			SELECT ?
+			UNION SELECT t.parent_id FROM tags t JOIN ancestors a ON t.id = a.id WHERE t.parent_id IS NOT NULL
+		 )
+		 SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = ?);`,
+		*tag.ParentID, tag.ID,
+	).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return domain.ErrorTagCycle
+	}
+
+	return nil
+}
+
+// inTx runs fn in a transaction, logging the unexpected errors.
+func (repo *SQLiteTagRepository) inTx(ctx context.Context, id string, fn func(tx *sql.Tx) error) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.With("error", err).Error("failed to start transaction")
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		if !errors.Is(err, ErrorNotFound) && !errors.Is(err, domain.ErrorDuplicateTag) &&
+			!errors.Is(err, domain.ErrorUnknownTag) && !errors.Is(err, domain.ErrorTagCycle) {
+			logger.With("error", err, "id", id).Error("failed to store tag")
+		}
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.With("error", err).Error("failed to commit transaction")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedDriverName is the name of the SQLite driver tracing every statement.
+const tracedDriverName = "sqlite3-traced"
+
+var tracer = otel.Tracer("github.com/Michela-DC/book-club/internal/infrastructure/db")
+
+func init() {
+	sql.Register(tracedDriverName, &tracedDriver{parent: &sqlite3.SQLiteDriver{}})
+}
+
+// tracedDriver wraps the SQLite driver so that every statement executed on
+// its connections is recorded as a span of the trace in its context.
+type tracedDriver struct {
+	parent driver.Driver
+}
+
+// Open opens a connection with the wrapped driver.
+func (d *tracedDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.parent.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedConn{Conn: conn}, nil
+}
+
+// tracedConn is a SQLite connection recording a span for each statement
+// executed with ExecContext and QueryContext, which database/sql uses for
+// the statements that are not explicitly prepared.
+type tracedConn struct {
+	driver.Conn
+}
+
+// ExecContext executes query within a span.
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startStatement(ctx, query)
+	res, err := execer.ExecContext(ctx, query, args)
+	endStatement(span, err)
+
+	return res, err
+}
+
+// QueryContext executes query within a span, which ends before the rows are read.
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startStatement(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	endStatement(span, err)
+
+	return rows, err
+}
+
+// PrepareContext prepares query with the wrapped connection.
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Prepare(query)
+}
+
+// BeginTx starts a transaction with the wrapped connection.
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	//nolint:staticcheck // the fallback required by database/sql
+	return c.Begin()
+}
+
+// Ping checks the wrapped connection.
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// startStatement starts the span of query, named after its operation, e.g. SELECT.
+func startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToUpper(operation)
+
+	return tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameSQLite,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// endStatement ends the span of a statement, recording err if it failed.
+func endStatement(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,331 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// maxListedDeliveries is the number of deliveries returned by ListDeliveries.
+const maxListedDeliveries = 100
+
+// deliveryColumns are the columns read into a [domain.WebhookDelivery] by listDeliveries.
+const deliveryColumns = `id, webhook_id, event_id, redelivery_of, event, book_id, book, occurred_at, status,
+	attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at`
+
+// SQLiteWebhookRepository stores the webhooks and their deliveries in a
+// SQLite database. Finished deliveries are kept for a limited time.
+// It implements [domain.WebhookRepository].
+type SQLiteWebhookRepository struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	retention time.Duration
+}
+
+// NewSQLiteWebhookRepository creates a new SQLiteWebhookRepository sharing
+// the database of repo and keeping the finished deliveries for retention.
+func NewSQLiteWebhookRepository(
+	repo *SQLiteBookRepository,
+	retention time.Duration,
+	logger *slog.Logger,
+) *SQLiteWebhookRepository {
+	return &SQLiteWebhookRepository{
+		db:        repo.db,
+		logger:    logger,
+		retention: retention,
+	}
+}
+
+// Create stores a new webhook, setting its ID and creation time.
+func (repo *SQLiteWebhookRepository) Create(ctx context.Context, hook *domain.Webhook) (*domain.Webhook, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	hook.ID = uuid.NewString()
+	hook.CreatedAt = time.Now().UTC()
+
+	events, err := json.Marshal(hook.Events)
+	if err != nil {
+		logger.With("error", err).Error("failed to encode webhook events")
+		return nil, err
+	}
+
+	_, err = conn(ctx, repo.db).ExecContext(ctx,
+		`INSERT INTO webhooks (id, url, secret, events, created_at) VALUES (?, ?, ?, ?, ?);`,
+		hook.ID, hook.URL, hook.Secret, string(events), hook.CreatedAt,
+	)
+	if err != nil {
+		logger.With("error", err).Error("failed to create webhook")
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// Get retrieves the webhook identified by id. It returns [ErrorNotFound] if
+// the webhook does not exist.
+func (repo *SQLiteWebhookRepository) Get(ctx context.Context, id string) (*domain.Webhook, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	hook, err := scanWebhook(conn(ctx, repo.db).QueryRowContext(ctx,
+		`SELECT id, url, secret, events, created_at FROM webhooks WHERE id = ?;`, id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrorNotFound
+	}
+	if err != nil {
+		logger.With("error", err, "id", id).Error("failed to get webhook")
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// List retrieves all the webhooks, oldest first.
+func (repo *SQLiteWebhookRepository) List(ctx context.Context) ([]*domain.Webhook, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	rows, err := conn(ctx, repo.db).QueryContext(ctx,
+		`SELECT id, url, secret, events, created_at FROM webhooks ORDER BY created_at, id;`,
+	)
+	if err != nil {
+		logger.With("error", err).Error("failed to list webhooks")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	hooks := make([]*domain.Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan webhook")
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read webhooks")
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// Delete removes the webhook identified by id. Its deliveries are deleted in
+// cascade. It returns [ErrorNotFound] if the webhook does not exist.
+func (repo *SQLiteWebhookRepository) Delete(ctx context.Context, id string) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	res, err := conn(ctx, repo.db).ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?;`, id)
+	if err != nil {
+		logger.With("error", err, "id", id).Error("failed to delete webhook")
+		return err
+	}
+
+	return checkAffected(logger, res)
+}
+
+// Enqueue stores a new pending delivery, setting its ID and creation time.
+// A delivery of an event already queued for the webhook is ignored, unless
+// it is a redelivery. The finished deliveries older than the retention are deleted.
+func (repo *SQLiteWebhookRepository) Enqueue(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	delivery.ID = uuid.NewString()
+	delivery.CreatedAt = time.Now().UTC()
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	if delivery.NextAttemptAt == nil {
+		delivery.NextAttemptAt = &delivery.CreatedAt
+	}
+
+	book, err := encodeBook(delivery.Book)
+	if err != nil {
+		logger.With("error", err).Error("failed to encode delivery book")
+		return err
+	}
+
+	err = inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM webhook_deliveries WHERE status <> ? AND created_at < ?;`,
+			domain.DeliveryPending, delivery.CreatedAt.Add(-repo.retention),
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO webhook_deliveries (id, webhook_id, event_id, redelivery_of, event, book_id, book,
+				occurred_at, status, next_attempt_at, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING;`,
+			delivery.ID, delivery.WebhookID,
+			sql.NullInt64{Int64: delivery.EventID, Valid: delivery.EventID != 0},
+			sql.NullString{String: delivery.RedeliveryOf, Valid: delivery.RedeliveryOf != ""},
+			delivery.Event, delivery.BookID, string(book),
+			delivery.OccurredAt.UTC(), delivery.Status, delivery.NextAttemptAt.UTC(), delivery.CreatedAt,
+		)
+		return err
+	})
+	if err != nil {
+		logger.With("error", err, "webhook_id", delivery.WebhookID).Error("failed to enqueue delivery")
+		return err
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of the webhook identified by
+// webhookID, newest first.
+func (repo *SQLiteWebhookRepository) ListDeliveries(
+	ctx context.Context,
+	webhookID string,
+) ([]*domain.WebhookDelivery, error) {
+	return repo.listDeliveries(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		 WHERE webhook_id = ? ORDER BY created_at DESC, id LIMIT ?;`,
+		webhookID, maxListedDeliveries,
+	)
+}
+
+// GetDelivery retrieves the delivery identified by id. It returns
+// [ErrorNotFound] if the delivery does not exist.
+func (repo *SQLiteWebhookRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	deliveries, err := repo.listDeliveries(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ?;`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, ErrorNotFound
+	}
+
+	return deliveries[0], nil
+}
+
+// DueDeliveries retrieves up to limit pending deliveries whose next attempt
+// is due at now, oldest first.
+func (repo *SQLiteWebhookRepository) DueDeliveries(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*domain.WebhookDelivery, error) {
+	return repo.listDeliveries(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		 WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, created_at LIMIT ?;`,
+		domain.DeliveryPending, now.UTC(), limit,
+	)
+}
+
+// UpdateDelivery stores the status and the outcome of the last attempt of
+// delivery. It returns [ErrorNotFound] if the delivery does not exist.
+func (repo *SQLiteWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	logger := logctx.FromContext(ctx, repo.logger)
+	utc := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		u := t.UTC()
+		return &u
+	}
+
+	res, err := conn(ctx, repo.db).ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_attempt_at = ?,
+			response_status = ?, last_error = ?
+		 WHERE id = ?;`,
+		delivery.Status, delivery.Attempts, utc(delivery.NextAttemptAt), utc(delivery.LastAttemptAt),
+		sql.NullInt64{Int64: int64(delivery.ResponseStatus), Valid: delivery.ResponseStatus != 0},
+		sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""},
+		delivery.ID,
+	)
+	if err != nil {
+		logger.With("error", err, "id", delivery.ID).Error("failed to update delivery")
+		return err
+	}
+
+	return checkAffected(logger, res)
+}
+
+// listDeliveries runs query, which selects the [deliveryColumns], and returns
+// the deliveries read.
+func (repo *SQLiteWebhookRepository) listDeliveries(
+	ctx context.Context,
+	query string,
+	args ...any,
+) ([]*domain.WebhookDelivery, error) {
+	logger := logctx.FromContext(ctx, repo.logger)
+	rows, err := conn(ctx, repo.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.With("error", err).Error("failed to list deliveries")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.With("error", err).Error("failed to close rows")
+		}
+	}()
+
+	deliveries := make([]*domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var (
+			d              domain.WebhookDelivery
+			eventID        sql.NullInt64
+			redeliveryOf   sql.NullString
+			book           string
+			responseStatus sql.NullInt64
+			lastError      sql.NullString
+		)
+		err = rows.Scan(&d.ID, &d.WebhookID, &eventID, &redeliveryOf, &d.Event, &d.BookID, &book,
+			&d.OccurredAt, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastAttemptAt, &responseStatus,
+			&lastError, &d.CreatedAt)
+		if err != nil {
+			logger.With("error", err).Error("failed to scan delivery")
+			return nil, err
+		}
+		d.Book, err = decodeBook([]byte(book))
+		if err != nil {
+			logger.With("error", err, "id", d.ID).Error("failed to decode delivery book")
+			return nil, err
+		}
+		d.EventID = eventID.Int64
+		d.RedeliveryOf = redeliveryOf.String
+		d.ResponseStatus = int(responseStatus.Int64)
+		d.LastError = lastError.String
+		deliveries = append(deliveries, &d)
+	}
+	if err = rows.Err(); err != nil {
+		logger.With("error", err).Error("failed to read deliveries")
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// scanWebhook reads a webhook from row, which selects id, url, secret,
+// events and created_at.
+func scanWebhook(row interface{ Scan(dest ...any) error }) (*domain.Webhook, error) {
+	var (
+		hook   domain.Webhook
+		events string
+	)
+	err := row.Scan(&hook.ID, &hook.URL, &hook.Secret, &events, &hook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(events), &hook.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hook, nil
+}
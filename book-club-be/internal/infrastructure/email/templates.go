@@ -0,0 +1,114 @@
+// Package email renders the emails sent to the members of the club and
+// delivers them over SMTP.
+package email
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// section is a list of books shown under a title in a digest.
+type section struct {
+	Title string
+	Books []*domain.Book
+}
+
+// funcs are the functions available to the templates.
+var funcs = map[string]any{
+	"section": func(title string, books []*domain.Book) section {
+		return section{Title: title, Books: books}
+	},
+}
+
+// data is what the templates render.
+type data struct {
+	Member *domain.Member
+	Book   *domain.Book
+	// Accepted is set when a suggested book was accepted for reading.
+	Accepted bool
+	Digest   *digest
+}
+
+// digest adds to a [domain.Digest] the last day it covers.
+type digest struct {
+	*domain.Digest
+	Last time.Time
+}
+
+// Templates renders the emails from the templates embedded in the binary:
+// the subject and the plain text body with text/template, the HTML body with
+// html/template. It implements [domain.EmailTemplates].
+type Templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// NewTemplates creates a new Templates, parsing the embedded templates.
+func NewTemplates() (*Templates, error) {
+	text, err := texttemplate.New("text").Funcs(funcs).ParseFS(templateFS, "templates/text.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New("html").Funcs(funcs).ParseFS(templateFS, "templates/html.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Templates{text: text, html: html}, nil
+}
+
+// Suggestion renders the email telling member that book was suggested.
+func (t *Templates) Suggestion(member *domain.Member, book *domain.Book) (*domain.Email, error) {
+	return t.render("suggestion", member, &data{Member: member, Book: book})
+}
+
+// Decision renders the email telling member that the suggested book was
+// accepted for reading or discarded.
+func (t *Templates) Decision(member *domain.Member, book *domain.Book) (*domain.Email, error) {
+	return t.render("decision", member, &data{
+		Member:   member,
+		Book:     book,
+		Accepted: book.Status != domain.BookStatusDiscarded,
+	})
+}
+
+// Digest renders the weekly digest sent to member.
+func (t *Templates) Digest(member *domain.Member, d *domain.Digest) (*domain.Email, error) {
+	return t.render("digest", member, &data{
+		Member: member,
+		Digest: &digest{Digest: d, Last: d.To.Add(-time.Nanosecond)},
+	})
+}
+
+// render executes the subject, text and html templates of kind.
+func (t *Templates) render(kind string, member *domain.Member, d *data) (*domain.Email, error) {
+	var subject, text, html bytes.Buffer
+	err := t.text.ExecuteTemplate(&subject, kind+".subject", d)
+	if err != nil {
+		return nil, err
+	}
+	err = t.text.ExecuteTemplate(&text, kind+".text", d)
+	if err != nil {
+		return nil, err
+	}
+	err = t.html.ExecuteTemplate(&html, kind+".html", d)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Email{
+		To:      member.Email,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
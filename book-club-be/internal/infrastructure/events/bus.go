@@ -0,0 +1,175 @@
+// Package events dispatches the changes to books from the outbox they are
+// stored in to the sinks interested in them, such as the browsers following
+// the event stream.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// EventReset is delivered instead of the events a subscriber missed when
+// they are no longer in the log: the subscriber must reload the books.
+const EventReset domain.EventType = "reset"
+
+const (
+	// subscriberBuffer is the number of live events a subscriber can fall
+	// behind before being dropped. A dropped subscriber resumes from the log
+	// when it subscribes again.
+	subscriberBuffer = 64
+	// replayPage is the number of events read from the log at once while replaying.
+	replayPage = 100
+)
+
+// Bus is the hub of the event stream: it delivers the dispatched events to
+// its subscribers, replaying from the log the ones they missed.
+// It implements [domain.EventHandler].
+type Bus struct {
+	log    domain.EventLog
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	subs map[chan *domain.Event]struct{}
+}
+
+// NewBus creates a new Bus replaying the missed events from log.
+func NewBus(log domain.EventLog, logger *slog.Logger) *Bus {
+	return &Bus{
+		log:    log,
+		logger: logger,
+		subs:   make(map[chan *domain.Event]struct{}),
+	}
+}
+
+// HandleEvent delivers event to the subscribers. Subscribers too slow to
+// receive it are dropped. Subscribers skip the events they already received,
+// so that an event dispatched again is delivered once.
+func (b *Bus) HandleEvent(ctx context.Context, event *domain.Event) error {
+	logger := logctx.FromContext(ctx, b.logger)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		select {
+		case sub <- event:
+		default:
+			logger.With("event_id", event.ID).Warn("dropping slow event subscriber")
+			delete(b.subs, sub)
+			close(sub)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel receiving the dispatched events until ctx is
+// canceled or the subscriber falls too far behind, when the channel is
+// closed. When lastID is not nil, the events published after the one with
+// that ID are read from the log and delivered first; if the log no longer
+// holds them, an [EventReset] event is delivered instead.
+func (b *Bus) Subscribe(ctx context.Context, lastID *int64) <-chan *domain.Event {
+	live := make(chan *domain.Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[live] = struct{}{}
+	b.mu.Unlock()
+
+	out := make(chan *domain.Event)
+	go func() {
+		defer close(out)
+		defer b.unsubscribe(live)
+
+		send := func(e *domain.Event) bool {
+			select {
+			case out <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var last int64
+		if lastID != nil {
+			var ok bool
+			last, ok = b.replay(ctx, *lastID, send)
+			if !ok {
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-live:
+				if !ok {
+					return
+				}
+				// Events already replayed from the log are skipped.
+				if e.ID <= last {
+					continue
+				}
+				if !send(e) {
+					return
+				}
+				last = e.ID
+			}
+		}
+	}()
+
+	return out
+}
+
+// replay sends the events of the log following the one with ID lastID, or
+// an [EventReset] event if that event is no longer in the log. It returns
+// the ID of the last event sent and whether the subscriber is still listening.
+func (b *Bus) replay(ctx context.Context, lastID int64, send func(*domain.Event) bool) (int64, bool) {
+	logger := logctx.FromContext(ctx, b.logger)
+	last := lastID
+	// The event with ID lastID is read too, to check that no event was
+	// deleted from the log since the subscriber received it.
+	from := lastID
+	for {
+		events, err := b.log.ListFrom(ctx, from, replayPage)
+		if err != nil {
+			logger.With("error", err).Error("unable to replay events")
+			return last, send(&domain.Event{Type: EventReset})
+		}
+
+		more := len(events) == replayPage
+		if from == lastID {
+			if lastID > 0 && (len(events) == 0 || events[0].ID != lastID) {
+				return last, send(&domain.Event{Type: EventReset})
+			}
+			if len(events) > 0 && events[0].ID == lastID {
+				events = events[1:]
+			}
+		}
+
+		for _, e := range events {
+			if !send(e) {
+				return last, false
+			}
+			last = e.ID
+		}
+		if !more {
+			return last, true
+		}
+		from = last + 1
+	}
+}
+
+// unsubscribe stops delivering events to sub.
+func (b *Bus) unsubscribe(sub chan *domain.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub)
+	}
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// dispatchPage is the number of events read from the outbox at once while dispatching.
+const dispatchPage = 100
+
+// Dispatcher publishes the events to the outbox and dispatches them to the
+// sinks once they are committed. Each sink goes through the outbox at its
+// own pace: the position of the last event it handled is stored, and an
+// event it fails to handle is dispatched to it again, without holding back
+// the other sinks. Sinks thus receive each event at least once, in order.
+// An event is marked as dispatched once every sink handled it. It implements
+// [domain.EventPublisher].
+type Dispatcher struct {
+	outbox domain.EventLog
+	sinks  []Sink
+	logger *slog.Logger
+	// wake signals Run that events were committed.
+	wake chan struct{}
+	// mu keeps a single dispatch running at a time.
+	mu sync.Mutex
+}
+
+// Sink is an event handler the events are dispatched to.
+type Sink struct {
+	// Name identifies the position of the sink in the outbox, and must not
+	// change across restarts.
+	Name    string
+	Handler domain.EventHandler
+}
+
+// NewDispatcher creates a new Dispatcher storing the events in outbox and
+// dispatching them to sinks. Without sinks, events are only stored, to be
+// dispatched by another process sharing the outbox.
+func NewDispatcher(outbox domain.EventLog, logger *slog.Logger, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{
+		outbox: outbox,
+		sinks:  sinks,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Publish stores event in the outbox, within the transaction ctx is bound to
+// if any, and sets its ID.
+func (d *Dispatcher) Publish(ctx context.Context, event *domain.Event) error {
+	return d.outbox.Append(ctx, event)
+}
+
+// Notify wakes Run up, unless it is already due to wake up.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatch delivers to each sink, in order, the events of the outbox it has
+// not handled yet. A sink stops at the first event it fails to handle, which
+// is dispatched to it again by the next call, while the other sinks go on.
+// The events every sink handled are then marked as dispatched.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []error
+	// dispatched is the last event handled by every sink.
+	dispatched := int64(math.MaxInt64)
+	for _, sink := range d.sinks {
+		position, err := d.dispatchTo(ctx, sink)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", sink.Name, err))
+		}
+		dispatched = min(dispatched, position)
+	}
+
+	if dispatched > 0 {
+		err := d.outbox.MarkDispatched(ctx, dispatched)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// dispatchTo delivers to sink the events following its position, until it
+// fails to handle one. It returns the new position of sink.
+func (d *Dispatcher) dispatchTo(ctx context.Context, sink Sink) (int64, error) {
+	position, err := d.outbox.SinkPosition(ctx, sink.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		events, err := d.outbox.ListFrom(ctx, position+1, dispatchPage)
+		if err != nil {
+			return position, err
+		}
+
+		handled := position
+		for _, e := range events {
+			err = sink.Handler.HandleEvent(ctx, e)
+			if err != nil {
+				err = fmt.Errorf("event %d: %w", e.ID, err)
+				break
+			}
+			handled = e.ID
+		}
+
+		if handled != position {
+			serr := d.outbox.SetSinkPosition(ctx, sink.Name, handled)
+			if serr != nil {
+				return position, errors.Join(err, serr)
+			}
+			position = handled
+		}
+
+		if err != nil || len(events) < dispatchPage {
+			return position, err
+		}
+	}
+}
+
+// Run dispatches the events of the outbox on start, every interval and as
+// soon as it is notified, until ctx is canceled. Events stored before a
+// crash are dispatched on start.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	logger := logctx.FromContext(ctx, d.logger)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := d.Dispatch(ctx)
+		if err != nil {
+			logger.With("error", err).Error("unable to dispatch events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// LogSink writes the dispatched events to a logger. It implements [domain.EventHandler].
+type LogSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSink creates a new LogSink writing to logger.
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+// HandleEvent logs event.
+func (s *LogSink) HandleEvent(ctx context.Context, event *domain.Event) error {
+	logctx.FromContext(ctx, s.logger).
+		With("event_id", event.ID, "type", event.Type, "book_id", event.BookID).
+		Info("book event dispatched")
+	return nil
+}
@@ -0,0 +1,142 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+	"github.com/Michela-DC/book-club/internal/infrastructure/events"
+)
+
+// recordingSink records the IDs of the events it handles, and fails to
+// handle the event identified by failOn.
+type recordingSink struct {
+	failOn  int64
+	handled []int64
+}
+
+func (s *recordingSink) HandleEvent(_ context.Context, event *domain.Event) error {
+	if event.ID == s.failOn {
+		return errors.New("sink unavailable")
+	}
+	s.handled = append(s.handled, event.ID)
+	return nil
+}
+
+// newTestOutbox returns an event log on a new database holding n events.
+func newTestOutbox(t *testing.T, n int) *db.SQLiteEventLog {
+	t.Helper()
+
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+	repo, err := db.NewSQLiteBookRepository(filepath.Join(t.TempDir(), "books.db"), logger)
+	if err != nil {
+		t.Fatalf("unable to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.DB().Close() })
+	err = repo.ApplyMigrations(ctx, "../../../database/migrations")
+	if err != nil {
+		t.Fatalf("unable to apply migrations: %v", err)
+	}
+
+	outbox := db.NewSQLiteEventLog(repo, time.Hour, logger)
+	for range n {
+		err = outbox.Append(ctx, &domain.Event{
+			Type: domain.EventBookDeleted, BookID: "b-emma", OccurredAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	return outbox
+}
+
+// undispatched returns the IDs of the events of outbox not yet dispatched.
+func undispatched(t *testing.T, outbox *db.SQLiteEventLog) []int64 {
+	t.Helper()
+
+	list, err := outbox.ListUndispatched(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("ListUndispatched() error = %v", err)
+	}
+	ids := make([]int64, len(list))
+	for i, e := range list {
+		ids[i] = e.ID
+	}
+
+	return ids
+}
+
+func TestDispatchIsolatesFailingSinks(t *testing.T) {
+	ctx := context.Background()
+	outbox := newTestOutbox(t, 3)
+	healthy := &recordingSink{}
+	failing := &recordingSink{failOn: 2}
+	d := events.NewDispatcher(outbox, slog.New(slog.DiscardHandler),
+		events.Sink{Name: "failing", Handler: failing}, events.Sink{Name: "healthy", Handler: healthy},
+	)
+
+	err := d.Dispatch(ctx)
+	if err == nil || !strings.Contains(err.Error(), "sink failing: event 2") {
+		t.Errorf("Dispatch() error = %v, want the failure of the failing sink on event 2", err)
+	}
+	if !slices.Equal(healthy.handled, []int64{1, 2, 3}) {
+		t.Errorf("healthy sink handled %v, want every event despite the failing sink", healthy.handled)
+	}
+	if !slices.Equal(failing.handled, []int64{1}) {
+		t.Errorf("failing sink handled %v, want the events before its failure", failing.handled)
+	}
+	if got := undispatched(t, outbox); !slices.Equal(got, []int64{2, 3}) {
+		t.Errorf("undispatched events = %v, want the ones the failing sink did not handle", got)
+	}
+
+	failing.failOn = 0
+	err = d.Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if !slices.Equal(failing.handled, []int64{1, 2, 3}) {
+		t.Errorf("failing sink handled %v, want the events resumed from its failure, in order", failing.handled)
+	}
+	if !slices.Equal(healthy.handled, []int64{1, 2, 3}) {
+		t.Errorf("healthy sink handled %v, want no event twice", healthy.handled)
+	}
+	if got := undispatched(t, outbox); len(got) != 0 {
+		t.Errorf("undispatched events = %v, want none", got)
+	}
+}
+
+func TestDispatchToNewSink(t *testing.T) {
+	ctx := context.Background()
+	outbox := newTestOutbox(t, 2)
+	logger := slog.New(slog.DiscardHandler)
+
+	err := events.NewDispatcher(outbox, logger, events.Sink{Name: "sse", Handler: &recordingSink{}}).Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	err = outbox.Append(ctx, &domain.Event{Type: domain.EventBookDeleted, BookID: "b-emma", OccurredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// A sink added to the configuration starts from the events not yet dispatched.
+	added := &recordingSink{}
+	err = events.NewDispatcher(outbox, logger,
+		events.Sink{Name: "sse", Handler: &recordingSink{}}, events.Sink{Name: "log", Handler: added},
+	).Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if !slices.Equal(added.handled, []int64{3}) {
+		t.Errorf("added sink handled %v, want only the event not yet dispatched", added.handled)
+	}
+}
@@ -0,0 +1,110 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// FakeProvider is an in-memory catalog for tests and offline development.
+// It implements [domain.MetadataProvider].
+type FakeProvider struct {
+	byISBN  map[string]*domain.BookMetadata
+	byTitle map[string]*domain.BookMetadata
+	delay   time.Duration
+	mu      sync.RWMutex
+}
+
+// NewFakeProvider creates a new FakeProvider knowing the given books.
+func NewFakeProvider(books ...*domain.BookMetadata) *FakeProvider {
+	p := &FakeProvider{
+		byISBN:  make(map[string]*domain.BookMetadata),
+		byTitle: make(map[string]*domain.BookMetadata),
+	}
+	for _, b := range books {
+		p.Add(b)
+	}
+
+	return p
+}
+
+// LoadFakeProvider creates a new FakeProvider knowing the books listed in the
+// JSON file at path, an array of [domain.BookMetadata].
+func LoadFakeProvider(path string) (*FakeProvider, error) {
+	//nolint:gosec // the path is provided by the operator
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var books []*domain.BookMetadata
+	err = json.Unmarshal(content, &books)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFakeProvider(books...), nil
+}
+
+// Name identifies the provider.
+func (*FakeProvider) Name() string {
+	return "fake"
+}
+
+// Add makes the provider know about a book.
+func (p *FakeProvider) Add(book *domain.BookMetadata) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if book.ISBN != "" {
+		p.byISBN[book.ISBN] = book
+	}
+	if book.Title != "" {
+		p.byTitle[strings.ToLower(book.Title)] = book
+	}
+}
+
+// SetDelay makes every lookup wait for d before answering, as a slow catalog would.
+func (p *FakeProvider) SetDelay(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.delay = d
+}
+
+// Lookup retrieves the book by ISBN, or by case-insensitive title, after the
+// delay set with SetDelay. It returns the error of ctx if it is done first.
+func (p *FakeProvider) Lookup(ctx context.Context, query domain.MetadataQuery) (*domain.BookMetadata, error) {
+	p.mu.RLock()
+	delay := p.delay
+	p.mu.RUnlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	book, ok := p.byISBN[query.ISBN]
+	if !ok && query.ISBN == "" {
+		book, ok = p.byTitle[strings.ToLower(query.Title)]
+	}
+	if !ok {
+		return nil, domain.ErrorNotFound
+	}
+
+	clone := *book
+	return &clone, nil
+}
@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// GoogleBooksURL is the base URL of the public Google Books API.
+const GoogleBooksURL = "https://www.googleapis.com"
+
+// GoogleBooksProvider looks up books in the Google Books catalog.
+// It implements [domain.MetadataProvider].
+type GoogleBooksProvider struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewGoogleBooksProvider creates a new GoogleBooksProvider querying the API at
+// baseURL, which is usually [GoogleBooksURL] but can point to a local stand-in
+// server. The API key is optional.
+func NewGoogleBooksProvider(baseURL, apiKey string) *GoogleBooksProvider {
+	return &GoogleBooksProvider{
+		client:  &http.Client{Timeout: defaultTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// Name identifies the provider.
+func (*GoogleBooksProvider) Name() string {
+	return "googlebooks"
+}
+
+type googleBooksVolumes struct {
+	Items []struct {
+		VolumeInfo struct {
+			Title               string   `json:"title"`
+			Authors             []string `json:"authors"`
+			PublishedDate       string   `json:"publishedDate"`
+			Description         string   `json:"description"`
+			Categories          []string `json:"categories"`
+			IndustryIdentifiers []struct {
+				Type       string `json:"type"`
+				Identifier string `json:"identifier"`
+			} `json:"industryIdentifiers"`
+			ImageLinks struct {
+				Thumbnail string `json:"thumbnail"`
+			} `json:"imageLinks"`
+			PageCount int `json:"pageCount"`
+		} `json:"volumeInfo"`
+	} `json:"items"`
+}
+
+// Lookup retrieves the metadata of the book matching the query using the volumes API.
+func (p *GoogleBooksProvider) Lookup(ctx context.Context, query domain.MetadataQuery) (*domain.BookMetadata, error) {
+	q := "isbn:" + query.ISBN
+	if query.ISBN == "" {
+		q = "intitle:" + query.Title
+		if query.Author != "" {
+			q += "+inauthor:" + query.Author
+		}
+	}
+
+	params := url.Values{}
+	params.Set("q", q)
+	params.Set("maxResults", "1")
+	if p.apiKey != "" {
+		params.Set("key", p.apiKey)
+	}
+
+	var res googleBooksVolumes
+	err := getJSON(ctx, p.client, p.baseURL+"/books/v1/volumes?"+params.Encode(), &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Items) == 0 {
+		return nil, domain.ErrorNotFound
+	}
+
+	info := res.Items[0].VolumeInfo
+	m := &domain.BookMetadata{
+		Title:         info.Title,
+		Author:        strings.Join(info.Authors, " & "),
+		PublishedYear: parseYear(info.PublishedDate),
+		PageCount:     positive(info.PageCount),
+		Description:   info.Description,
+		// Google serves thumbnails over plain HTTP by default.
+		CoverURL: strings.Replace(info.ImageLinks.Thumbnail, "http://", "https://", 1),
+		ISBN:     query.ISBN,
+	}
+	if len(info.Categories) > 0 {
+		m.Genre = info.Categories[0]
+	}
+	if m.ISBN == "" {
+		for _, id := range info.IndustryIdentifiers {
+			if normalized, err := domain.NormalizeISBN(id.Identifier); err == nil {
+				m.ISBN = normalized
+				break
+			}
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,62 @@
+// Package metadata implements [domain.MetadataProvider] on top of public book catalogs.
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// defaultTimeout bounds the time spent waiting for a catalog.
+const defaultTimeout = 5 * time.Second
+
+// yearPattern finds the year in the free-form publication dates of the catalogs, e.g. "October 26, 2015".
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+// getJSON sends a GET request to url and decodes the JSON response into v.
+// A 404 response is reported as [domain.ErrorNotFound].
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return domain.ErrorNotFound
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected response status %s", res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// parseYear returns the first year found in date, if any.
+func parseYear(date string) *int {
+	y, err := strconv.Atoi(yearPattern.FindString(date))
+	if err != nil {
+		return nil
+	}
+	return &y
+}
+
+// positive returns a pointer to n if it is greater than zero.
+func positive(n int) *int {
+	if n <= 0 {
+		return nil
+	}
+	return &n
+}
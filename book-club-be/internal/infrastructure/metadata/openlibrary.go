@@ -0,0 +1,96 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// OpenLibraryURL is the base URL of the public Open Library API.
+const OpenLibraryURL = "https://openlibrary.org"
+
+// OpenLibraryProvider looks up books in the Open Library catalog.
+// It implements [domain.MetadataProvider].
+type OpenLibraryProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewOpenLibraryProvider creates a new OpenLibraryProvider querying the API at
+// baseURL, which is usually [OpenLibraryURL] but can point to a local stand-in server.
+func NewOpenLibraryProvider(baseURL string) *OpenLibraryProvider {
+	return &OpenLibraryProvider{
+		client:  &http.Client{Timeout: defaultTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Name identifies the provider.
+func (*OpenLibraryProvider) Name() string {
+	return "openlibrary"
+}
+
+type openLibrarySearch struct {
+	Docs []struct {
+		Title            string   `json:"title"`
+		AuthorName       []string `json:"author_name"`
+		ISBN             []string `json:"isbn"`
+		Subject          []string `json:"subject"`
+		FirstPublishYear int      `json:"first_publish_year"`
+		PageCount        int      `json:"number_of_pages_median"`
+		CoverID          int      `json:"cover_i"`
+	} `json:"docs"`
+}
+
+// Lookup retrieves the metadata of the book matching the query using the search API.
+func (p *OpenLibraryProvider) Lookup(ctx context.Context, query domain.MetadataQuery) (*domain.BookMetadata, error) {
+	params := url.Values{}
+	params.Set("limit", "1")
+	params.Set("fields", "title,author_name,isbn,subject,first_publish_year,number_of_pages_median,cover_i")
+	if query.ISBN != "" {
+		params.Set("isbn", query.ISBN)
+	} else {
+		params.Set("title", query.Title)
+		if query.Author != "" {
+			params.Set("author", query.Author)
+		}
+	}
+
+	var res openLibrarySearch
+	err := getJSON(ctx, p.client, p.baseURL+"/search.json?"+params.Encode(), &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Docs) == 0 {
+		return nil, domain.ErrorNotFound
+	}
+
+	doc := res.Docs[0]
+	m := &domain.BookMetadata{
+		Title:         doc.Title,
+		Author:        strings.Join(doc.AuthorName, " & "),
+		PublishedYear: positive(doc.FirstPublishYear),
+		PageCount:     positive(doc.PageCount),
+		ISBN:          query.ISBN,
+	}
+	if len(doc.Subject) > 0 {
+		m.Genre = doc.Subject[0]
+	}
+	if doc.CoverID > 0 {
+		m.CoverURL = "https://covers.openlibrary.org/b/id/" + strconv.Itoa(doc.CoverID) + "-L.jpg"
+	}
+	if m.ISBN == "" {
+		for _, isbn := range doc.ISBN {
+			if normalized, err := domain.NormalizeISBN(isbn); err == nil {
+				m.ISBN = normalized
+				break
+			}
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,159 @@
+// Package metrics exposes the metrics of the book club server in the
+// Prometheus text format.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// namespace prefixes the names of the metrics of the book club server.
+const namespace = "book_club"
+
+// collectTimeout bounds the queries run to collect the state gauges.
+const collectTimeout = 5 * time.Second
+
+// StateSource provides the state of the library exposed as gauges.
+type StateSource interface {
+	// MigrationLevel returns the name of the last migration applied to the database.
+	MigrationLevel(ctx context.Context) (string, error)
+	// CountByStatus returns the number of books for each status.
+	CountByStatus(ctx context.Context) (map[domain.BookStatus]int, error)
+}
+
+// Metrics records the requests served by the HTTP server and exposes them,
+// together with the database pool statistics and the state of the library.
+type Metrics struct {
+	handler  http.Handler
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// New creates a new Metrics collecting the pool statistics of db and the
+// state provided by source, whose failures are logged with logger.
+func New(db *sql.DB, source StateSource, logger *slog.Logger) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests served, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the HTTP requests served, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "books"),
+		newStateCollector(source, logger),
+		m.requests,
+		m.duration,
+	)
+	m.handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	})
+
+	return m
+}
+
+// ObserveRequest records a request served in elapsed with the given status.
+// The route is the pattern that matched the request, or empty if none did.
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	// The pattern may start with the method, which has its own label.
+	if _, path, ok := strings.Cut(route, " "); ok {
+		route = path
+	}
+	if route == "" {
+		route = "unmatched"
+	}
+
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.duration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
+
+// stateCollector reads the state of the library on every scrape.
+// It implements [prometheus.Collector].
+type stateCollector struct {
+	source    StateSource
+	logger    *slog.Logger
+	migration *prometheus.Desc
+	books     *prometheus.Desc
+}
+
+func newStateCollector(source StateSource, logger *slog.Logger) *stateCollector {
+	return &stateCollector{
+		source: source,
+		logger: logger,
+		migration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "migration_version"),
+			"Version of the last migration applied to the database.",
+			[]string{"name"}, nil,
+		),
+		books: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "books"),
+			"Number of books, by status.",
+			[]string{"status"}, nil,
+		),
+	}
+}
+
+// Describe sends the descriptors of the state gauges to ch.
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.migration
+	ch <- c.books
+}
+
+// Collect sends the state gauges to ch. Gauges that cannot be read are
+// omitted, and the failure is logged.
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	level, err := c.source.MigrationLevel(ctx)
+	if err != nil {
+		c.logger.With("error", err).Error("unable to collect migration version")
+	} else if level != "" {
+		// Migrations are named after their zero padded version, e.g. 00008_genre-tags.
+		prefix, _, _ := strings.Cut(level, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			c.logger.With("error", err, "migration", level).Error("unable to parse migration version")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.migration, prometheus.GaugeValue, float64(version), level)
+		}
+	}
+
+	counts, err := c.source.CountByStatus(ctx)
+	if err != nil {
+		c.logger.With("error", err).Error("unable to collect book counts")
+		return
+	}
+	for _, status := range domain.StringToBookStatusMap {
+		ch <- prometheus.MustNewConstMetric(c.books, prometheus.GaugeValue, float64(counts[status]), string(status))
+	}
+}
@@ -0,0 +1,89 @@
+// Package webhook implements [domain.WebhookSender] by posting signed JSON
+// payloads over HTTP.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+)
+
+// defaultTimeout bounds the time spent waiting for a receiver.
+const defaultTimeout = 10 * time.Second
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-BookClub-Event"
+	HeaderDelivery  = "X-BookClub-Delivery"
+	HeaderTimestamp = "X-BookClub-Timestamp"
+	HeaderSignature = "X-BookClub-Signature"
+)
+
+// HTTPSender posts the deliveries to their webhooks. It implements [domain.WebhookSender].
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender creates a new HTTPSender.
+func NewHTTPSender() *HTTPSender {
+	return &HTTPSender{client: &http.Client{Timeout: defaultTimeout}}
+}
+
+// Send posts delivery to hook as a [v1.WebhookPayload]. The request carries
+// the Unix timestamp of the attempt in [HeaderTimestamp] and, in
+// [HeaderSignature], the HMAC-SHA256 of the timestamp, a dot and the body,
+// keyed with the secret of the webhook. Any 2xx response accepts the delivery.
+func (s *HTTPSender) Send(ctx context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(v1.NewWebhookPayload(delivery))
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "book-club-webhooks/1")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(hook.Secret, timestamp, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Draining the body lets the connection be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of timestamp, a dot and body,
+// keyed with secret, as sent in [HeaderSignature].
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// emmaSuggested returns the delivery of the suggestion of a book.
+func emmaSuggested() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:    "d-1",
+		Event: domain.WebhookBookSuggested,
+		Book:  &domain.Book{ID: "b-emma", Title: "Emma", Author: "Jane Austen", Status: domain.BookStatusSuggested},
+	}
+}
+
+func TestSend(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusOK},
+		{name: "accepted without content", status: http.StatusNoContent},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true},
+		{name: "rejected", status: http.StatusGone, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				timestamp := r.Header.Get(HeaderTimestamp)
+				sent, err := strconv.ParseInt(timestamp, 10, 64)
+				if err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+					t.Errorf("%s = %q, want the current Unix time", HeaderTimestamp, timestamp)
+				}
+				if got, want := r.Header.Get(HeaderSignature), "sha256="+Sign(secret, timestamp, body); got != want {
+					t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+				}
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			status, err := NewHTTPSender().Send(context.Background(),
+				&domain.Webhook{URL: server.URL, Secret: secret},
+				emmaSuggested(),
+			)
+			if status != tt.status || (err != nil) != tt.wantErr {
+				t.Errorf("Send() = %d, %v, want %d and error %t", status, err, tt.status, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSendUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	status, err := NewHTTPSender().Send(context.Background(),
+		&domain.Webhook{URL: url, Secret: "secret"},
+		emmaSuggested(),
+	)
+	if status != 0 || err == nil {
+		t.Errorf("Send() = %d, %v, want 0 and an error", status, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{"id":"d-1"}" keyed with "secret".
+	const want = "4b7109ecf9caed355a3e33bd348d3a4d982e43e2b147fd95a7b8886444b19743"
+
+	if got := Sign("secret", "1700000000", []byte(`{"id":"d-1"}`)); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
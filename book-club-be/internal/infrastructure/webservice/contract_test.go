@@ -0,0 +1,517 @@
+package webservice_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+)
+
+// specURL is the URL the API description is compiled from.
+const specURL = "file:///openapi.json"
+
+// apiSpec is the part of the API description needed to check the responses.
+type apiSpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Responses map[string]apiResponse `json:"responses"`
+	} `json:"components"`
+}
+
+// apiOperation is an operation of the API description.
+type apiOperation struct {
+	Responses map[string]apiResponse `json:"responses"`
+}
+
+// apiResponse is a response of the API description, or a reference to one.
+type apiResponse struct {
+	Ref     string                     `json:"$ref"`
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+// contract checks the responses of the API against its description.
+type contract struct {
+	t        *testing.T
+	app      *testApp
+	spec     apiSpec
+	compiler *jsonschema.Compiler
+	// called holds the operations called, as ServeMux patterns.
+	called map[string]bool
+}
+
+func newContract(t *testing.T, app *testApp) *contract {
+	t.Helper()
+
+	c := &contract{t: t, app: app, called: make(map[string]bool)}
+	err := json.Unmarshal(v1.OpenAPI, &c.spec)
+	if err != nil {
+		t.Fatalf("unable to decode the API description: %v", err)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(v1.OpenAPI))
+	if err != nil {
+		t.Fatalf("unable to decode the API description: %v", err)
+	}
+	c.compiler = jsonschema.NewCompiler()
+	c.compiler.DefaultDraft(jsonschema.Draft2020)
+	err = c.compiler.AddResource(specURL, doc)
+	if err != nil {
+		t.Fatalf("unable to load the API description: %v", err)
+	}
+
+	return c
+}
+
+// request describes a request to an operation of the API.
+type request struct {
+	// op is the ServeMux pattern of the operation, e.g. GET /v1/books/{id}.
+	op string
+	// path is the path and query of the request.
+	path        string
+	body        io.Reader
+	contentType string
+	admin       bool
+	// timeout, if set, cancels the request after it, for streams.
+	timeout time.Duration
+}
+
+// do sends req and checks that its response is described by the API,
+// failing the test otherwise, and returns it.
+func (c *contract) do(req request) *httptest.ResponseRecorder {
+	c.t.Helper()
+
+	method, pattern, _ := strings.Cut(req.op, " ")
+	r := httptest.NewRequest(method, req.path, req.body)
+	if req.contentType != "" {
+		r.Header.Set("Content-Type", req.contentType)
+	}
+	if req.admin {
+		r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	}
+	if req.timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), req.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	rec := httptest.NewRecorder()
+	c.app.handler.ServeHTTP(rec, r)
+	c.called[req.op] = true
+	c.check(req.op, pattern, strings.ToLower(method), rec)
+
+	return rec
+}
+
+// call sends req, checks its response and that it has the want status, and
+// decodes its JSON body into out, if not nil.
+func (c *contract) call(req request, want int, out any) {
+	c.t.Helper()
+
+	rec := c.do(req)
+	if rec.Code != want {
+		c.t.Fatalf("%s status = %d, want %d: %s", req.op, rec.Code, want, rec.Body)
+	}
+	if out != nil {
+		err := json.Unmarshal(rec.Body.Bytes(), out)
+		if err != nil {
+			c.t.Fatalf("%s: unable to decode body: %v", req.op, err)
+		}
+	}
+}
+
+// check checks rec, the response of op, against the API description.
+func (c *contract) check(op, pattern, method string, rec *httptest.ResponseRecorder) {
+	c.t.Helper()
+
+	raw, ok := c.spec.Paths[pattern][method]
+	if !ok {
+		c.t.Errorf("%s is not described", op)
+		return
+	}
+	var operation apiOperation
+	err := json.Unmarshal(raw, &operation)
+	if err != nil {
+		c.t.Fatalf("unable to decode %s: %v", op, err)
+	}
+
+	status := strconv.Itoa(rec.Code)
+	pointer := "/paths/" + escapePointer(pattern) + "/" + method + "/responses/"
+	resp, ok := operation.Responses[status]
+	if !ok {
+		status = status[:1] + "XX"
+		resp, ok = operation.Responses[status]
+	}
+	if !ok {
+		status = "default"
+		resp, ok = operation.Responses[status]
+	}
+	if !ok {
+		c.t.Errorf("%s answered with undescribed status %d: %s", op, rec.Code, rec.Body)
+		return
+	}
+	pointer += status
+	if name, ok := strings.CutPrefix(resp.Ref, "#/components/responses/"); ok {
+		resp = c.spec.Components.Responses[name]
+		pointer = "/components/responses/" + escapePointer(name)
+	}
+
+	if len(resp.Content) == 0 {
+		if rec.Body.Len() > 0 {
+			c.t.Errorf("%s %d has a body, while none is described: %s", op, rec.Code, rec.Body)
+		}
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil {
+		c.t.Errorf("%s %d Content-Type %q: %v", op, rec.Code, rec.Header().Get("Content-Type"), err)
+		return
+	}
+	described := ""
+	for m := range resp.Content {
+		if ok, _ := path.Match(m, mediaType); ok {
+			described = m
+			break
+		}
+	}
+	if described == "" {
+		c.t.Errorf("%s %d Content-Type = %s, want one of %v", op, rec.Code, mediaType, mediaTypes(resp.Content))
+		return
+	}
+	if mediaType != "application/json" && mediaType != "application/problem+json" {
+		return
+	}
+
+	var media struct {
+		Schema json.RawMessage `json:"schema"`
+	}
+	err = json.Unmarshal(resp.Content[described], &media)
+	if err != nil || media.Schema == nil {
+		c.t.Errorf("%s %d %s has no schema", op, rec.Code, described)
+		return
+	}
+	pointer += "/content/" + escapePointer(described) + "/schema"
+	schema, err := c.compiler.Compile(specURL + "#" + (&url.URL{Fragment: pointer}).EscapedFragment())
+	if err != nil {
+		c.t.Fatalf("unable to compile the schema of %s %d: %v", op, rec.Code, err)
+	}
+
+	body, err := jsonschema.UnmarshalJSON(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		c.t.Errorf("%s %d body is not JSON: %v: %s", op, rec.Code, err, rec.Body)
+		return
+	}
+	err = schema.Validate(body)
+	if err != nil {
+		c.t.Errorf("%s %d body does not match its schema: %v\n%s", op, rec.Code, err, rec.Body)
+	}
+}
+
+// escapePointer escapes s as a JSON pointer token.
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func mediaTypes(content map[string]json.RawMessage) []string {
+	types := make([]string, 0, len(content))
+	for m := range content {
+		types = append(types, m)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// jsonBody returns the JSON encoding of v as a request body.
+func jsonBody(t *testing.T, v any) io.Reader {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unable to encode body: %v", err)
+	}
+
+	return bytes.NewReader(b)
+}
+
+// multipartBody returns a multipart form with content as the file field,
+// and its content type.
+func multipartBody(t *testing.T, field, filename string, content []byte) (io.Reader, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile(field, filename)
+	if err == nil {
+		_, err = fw.Write(content)
+	}
+	if err == nil {
+		err = mw.Close()
+	}
+	if err != nil {
+		t.Fatalf("unable to write multipart body: %v", err)
+	}
+
+	return &buf, mw.FormDataContentType()
+}
+
+// pngImage returns a small PNG image.
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 60, 90))
+	for x := range 60 {
+		for y := range 90 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 2), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatalf("unable to encode image: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+type idResponse struct {
+	ID string `json:"id"`
+}
+
+func TestContract(t *testing.T) {
+	app := newTestApp(t)
+	c := newContract(t, app)
+	jsonType := "application/json"
+
+	// tags
+	var fiction, classics, classic idResponse
+	c.call(request{op: "POST /v1/tags", path: "/v1/tags", contentType: jsonType,
+		body: jsonBody(t, map[string]any{"name": "Fiction"})}, http.StatusCreated, &fiction)
+	c.call(request{op: "POST /v1/tags", path: "/v1/tags", contentType: jsonType,
+		body: jsonBody(t, map[string]any{"name": "Classics", "parent_id": fiction.ID})}, http.StatusCreated, &classics)
+	c.call(request{op: "POST /v1/tags", path: "/v1/tags", contentType: jsonType,
+		body: jsonBody(t, map[string]any{"name": "Classic"})}, http.StatusCreated, &classic)
+	c.call(request{op: "POST /v1/tags", path: "/v1/tags", contentType: jsonType,
+		body: jsonBody(t, map[string]any{"name": "Classics"})}, http.StatusConflict, nil)
+	c.call(request{op: "PATCH /v1/tags/{id}", path: "/v1/tags/" + classics.ID, contentType: jsonType,
+		body: jsonBody(t, map[string]any{"name": "Classics", "parent_id": nil})}, http.StatusOK, nil)
+	c.call(request{op: "POST /v1/tags/{id}/merge", path: "/v1/tags/" + classics.ID + "/merge", contentType: jsonType,
+		body: jsonBody(t, map[string]any{"tag_ids": []string{classic.ID}})}, http.StatusNoContent, nil)
+	c.call(request{op: "GET /v1/tags", path: "/v1/tags"}, http.StatusOK, nil)
+
+	// members
+	var ada idResponse
+	c.call(request{op: "POST /v1/admin/members", path: "/v1/admin/members", admin: true, contentType: jsonType,
+		body: jsonBody(t, map[string]any{"name": "Ada", "email": "ada@example.com"})}, http.StatusCreated, &ada)
+	c.call(request{op: "POST /v1/admin/members", path: "/v1/admin/members", contentType: jsonType,
+		body: jsonBody(t, map[string]any{"name": "Eve", "email": "eve@example.com"})}, http.StatusUnauthorized, nil)
+	c.call(request{op: "PATCH /v1/admin/members/{id}", path: "/v1/admin/members/" + ada.ID, admin: true,
+		contentType: jsonType, body: jsonBody(t, map[string]any{"notifications": map[string]any{"digest": false}})},
+		http.StatusOK, nil)
+	c.call(request{op: "GET /v1/admin/members", path: "/v1/admin/members", admin: true}, http.StatusOK, nil)
+
+	// webhooks
+	var hook idResponse
+	c.call(request{op: "POST /v1/admin/webhooks", path: "/v1/admin/webhooks", admin: true, contentType: jsonType,
+		body: jsonBody(t, map[string]any{"url": "http://127.0.0.1:9/hook", "events": []string{"book.suggested", "book.reading"}})},
+		http.StatusCreated, &hook)
+	c.call(request{op: "GET /v1/admin/webhooks", path: "/v1/admin/webhooks", admin: true}, http.StatusOK, nil)
+
+	// books
+	var dune, emma idResponse
+	c.call(request{op: "POST /v1/books", path: "/v1/books", contentType: jsonType, body: jsonBody(t, map[string]any{
+		"title": "Dune", "author": "Frank Herbert", "isbn": "9780441172719", "status": "SUGGESTED",
+		"tags": []string{"Classics"}, "suggested_by": ada.ID,
+	})}, http.StatusCreated, &dune)
+	rec := c.do(request{op: "POST /v1/books", path: "/v1/books", contentType: jsonType, body: jsonBody(t, map[string]any{
+		"title": "Dune Messiah", "author": "Frank Herbert", "isbn": "9780441172719", "status": "SAVED",
+	})})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("POST /v1/books with a taken ISBN status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if got, want := rec.Header().Get("Link"), `</v1/books/`+dune.ID+`>; rel="duplicate"`; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+	c.call(request{op: "POST /v1/books", path: "/v1/books", contentType: jsonType, body: jsonBody(t, map[string]any{
+		"title": "", "author": "Frank Herbert", "status": "SAVED",
+	})}, http.StatusBadRequest, nil)
+	c.call(request{op: "PUT /v1/books/{id}", path: "/v1/books/4b0c8f0e-6d0f-4ad4-9c38-0d5a4f8f6c11",
+		contentType: jsonType, body: jsonBody(t, map[string]any{
+			"title": "Emma", "author": "Jane Austen", "published_year": 1815, "status": "SAVED",
+		})}, http.StatusCreated, &emma)
+	c.call(request{op: "PUT /v1/books/{id}", path: "/v1/books/" + emma.ID, contentType: jsonType,
+		body: jsonBody(t, map[string]any{
+			"title": "Emma", "author": "Jane Austen", "genre": "Novel", "published_year": 1815, "status": "SAVED",
+		})}, http.StatusOK, nil)
+	c.call(request{op: "PATCH /v1/books/{id}", path: "/v1/books/" + dune.ID, contentType: jsonType,
+		body: jsonBody(t, map[string]any{"status": "READING"})}, http.StatusOK, nil)
+	c.call(request{op: "GET /v1/books/{id}", path: "/v1/books/" + dune.ID}, http.StatusOK, nil)
+	c.call(request{op: "GET /v1/books/{id}", path: "/v1/books/unknown"}, http.StatusNotFound, nil)
+	c.call(request{op: "GET /v1/books", path: "/v1/books?tag=Fiction&status=READING"}, http.StatusOK, nil)
+	c.call(request{op: "GET /v1/books", path: "/v1/books?year=last"}, http.StatusBadRequest, nil)
+	c.call(request{op: "GET /v1/books/isbn/{isbn}", path: "/v1/books/isbn/978-0-441-17271-9"}, http.StatusOK, nil)
+	c.call(request{op: "POST /v1/books/{id}/enrich", path: "/v1/books/" + dune.ID + "/enrich"}, http.StatusOK, nil)
+	c.call(request{op: "POST /v1/books:batch", path: "/v1/books:batch", contentType: jsonType,
+		body: jsonBody(t, map[string]any{"operations": []map[string]any{
+			{"op": "create", "book": map[string]any{"title": "Hyperion", "author": "Dan Simmons", "status": "SAVED"}},
+			{"op": "update", "id": emma.ID, "book": map[string]any{"status": "SUGGESTED"}},
+			{"op": "delete", "id": "unknown"},
+		}})}, http.StatusOK, nil)
+
+	// covers
+	cover, coverType := multipartBody(t, "cover", "dune.png", pngImage(t))
+	c.call(request{op: "PUT /v1/books/{id}/cover", path: "/v1/books/" + dune.ID + "/cover", body: cover,
+		contentType: coverType}, http.StatusOK, nil)
+	c.call(request{op: "GET /v1/books/{id}/cover", path: "/v1/books/" + dune.ID + "/cover?size=small"},
+		http.StatusOK, nil)
+
+	// import and export
+	export := "Title,Author,Exclusive Shelf,ISBN13\nPersuasion,Jane Austen,read,\n,Nobody,read,\n"
+	file, fileType := multipartBody(t, "file", "goodreads.csv", []byte(export))
+	c.call(request{op: "POST /v1/books/import", path: "/v1/books/import", body: file, contentType: fileType},
+		http.StatusOK, nil)
+	file, fileType = multipartBody(t, "file", "unknown.csv", []byte("a,b\n1,2\n"))
+	c.call(request{op: "POST /v1/books/import", path: "/v1/books/import", body: file, contentType: fileType},
+		http.StatusBadRequest, nil)
+	for _, format := range []string{"csv", "jsonl", "md"} {
+		c.call(request{op: "GET /v1/books/export", path: "/v1/books/export?format=" + format}, http.StatusOK, nil)
+	}
+
+	// authors
+	var authors []idResponse
+	c.call(request{op: "GET /v1/authors", path: "/v1/authors"}, http.StatusOK, &authors)
+	if len(authors) < 2 {
+		t.Fatalf("GET /v1/authors = %v, want the authors of the books", authors)
+	}
+	c.call(request{op: "GET /v1/authors/{id}/books", path: "/v1/authors/" + authors[0].ID + "/books"},
+		http.StatusOK, nil)
+	c.call(request{op: "POST /v1/authors/{id}/merge", path: "/v1/authors/" + authors[0].ID + "/merge",
+		contentType: jsonType, body: jsonBody(t, map[string]any{"author_ids": []string{authors[1].ID}})},
+		http.StatusNoContent, nil)
+
+	// trash
+	c.call(request{op: "DELETE /v1/books/{id}", path: "/v1/books/" + emma.ID}, http.StatusOK, nil)
+	c.call(request{op: "GET /v1/books/trash", path: "/v1/books/trash"}, http.StatusOK, nil)
+	c.call(request{op: "POST /v1/books/{id}/restore", path: "/v1/books/" + emma.ID + "/restore"}, http.StatusOK, nil)
+
+	// statistics and events
+	c.call(request{op: "GET /v1/stats", path: "/v1/stats"}, http.StatusOK, nil)
+	c.call(request{op: "GET /v1/events", path: "/v1/events?last_event_id=0", timeout: 50 * time.Millisecond},
+		http.StatusOK, nil)
+
+	// webhook deliveries
+	err := app.dispatcher.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	var deliveries []idResponse
+	c.call(request{op: "GET /v1/admin/webhooks/{id}/deliveries", path: "/v1/admin/webhooks/" + hook.ID + "/deliveries",
+		admin: true}, http.StatusOK, &deliveries)
+	if len(deliveries) == 0 {
+		t.Fatalf("GET /v1/admin/webhooks/{id}/deliveries = [], want the deliveries of the created books")
+	}
+	c.call(request{op: "POST /v1/admin/webhooks/{id}/deliveries/{delivery_id}/redeliver",
+		path: "/v1/admin/webhooks/" + hook.ID + "/deliveries/" + deliveries[0].ID + "/redeliver", admin: true},
+		http.StatusAccepted, nil)
+	c.call(request{op: "DELETE /v1/admin/webhooks/{id}", path: "/v1/admin/webhooks/" + hook.ID, admin: true},
+		http.StatusNoContent, nil)
+
+	// backups
+	c.call(request{op: "POST /v1/admin/backups", path: "/v1/admin/backups", admin: true}, http.StatusCreated, nil)
+	c.call(request{op: "GET /v1/admin/backups", path: "/v1/admin/backups", admin: true}, http.StatusOK, nil)
+
+	c.call(request{op: "DELETE /v1/admin/members/{id}", path: "/v1/admin/members/" + ada.ID, admin: true},
+		http.StatusNoContent, nil)
+
+	ops, err := v1.Operations()
+	if err != nil {
+		t.Fatalf("Operations() error = %v", err)
+	}
+	for _, op := range ops {
+		if !c.called[op] {
+			t.Errorf("%s is described but not covered by the contract test", op)
+		}
+	}
+}
+
+func TestServedRoutesAreDescribed(t *testing.T) {
+	app := newTestApp(t)
+
+	if strings.Contains(app.logs.String(), "routes missing from the OpenAPI description") {
+		t.Errorf("the server serves undescribed routes:\n%s", app.logs)
+	}
+}
+
+func TestDescribedOperationsAreServed(t *testing.T) {
+	app := newTestApp(t)
+	ops, err := v1.Operations()
+	if err != nil {
+		t.Fatalf("Operations() error = %v", err)
+	}
+
+	for _, op := range ops {
+		method, pattern, _ := strings.Cut(op, " ")
+		path := strings.NewReplacer("{id}", "unknown", "{isbn}", "9780441172719",
+			"{delivery_id}", "unknown").Replace(pattern)
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Authorization", "Bearer "+testAdminToken)
+		rec := httptest.NewRecorder()
+		ctx, cancel := context.WithTimeout(r.Context(), 20*time.Millisecond)
+		app.handler.ServeHTTP(rec, r.WithContext(ctx))
+		cancel()
+
+		if rec.Code == http.StatusMethodNotAllowed ||
+			(rec.Code == http.StatusNotFound && !strings.Contains(rec.Body.String(), `"code":"not_found"`)) {
+			t.Errorf("%s is described but not served: %d %s", op, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	app := newTestApp(t)
+
+	rec := httptest.NewRecorder()
+	app.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /docs status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	vendored := !strings.Contains(app.logs.String(), "Swagger UI assets not vendored")
+	csp := rec.Header().Get("Content-Security-Policy")
+	if vendored {
+		if !strings.Contains(rec.Body.String(), `src="/docs/assets/swagger-ui-bundle.js"`) ||
+			!strings.Contains(csp, "script-src 'self'") {
+			t.Errorf("GET /docs does not load the vendored assets: %s\n%s", csp, rec.Body)
+		}
+
+		rec = httptest.NewRecorder()
+		app.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/assets/swagger-ui-bundle.js", nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET /docs/assets/swagger-ui-bundle.js status = %d, want the vendored bundle", rec.Code)
+		}
+		return
+	}
+
+	if !strings.Contains(rec.Body.String(), `src="https://unpkg.com/swagger-ui-dist@`) ||
+		!strings.Contains(csp, "script-src https://unpkg.com/swagger-ui-dist@") {
+		t.Errorf("GET /docs does not load the assets from the CDN: %s\n%s", csp, rec.Body)
+	}
+}
@@ -0,0 +1,106 @@
+package webservice
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig holds the Cross-Origin Resource Sharing settings of the handler.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to call the API from a browser,
+	// e.g. http://localhost:3000, or "*" for any origin. CORS is disabled
+	// when empty.
+	AllowedOrigins []string
+	// MaxAge is how long browsers may cache the result of a preflight request.
+	MaxAge time.Duration
+}
+
+// corsMethods are the methods allowed in cross-origin requests.
+var corsMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// corsRequestHeaders are the request headers allowed in cross-origin requests.
+var corsRequestHeaders = []string{
+	"Authorization", "Content-Type", "Idempotency-Key", "Last-Event-ID", "X-Request-ID", "traceparent", "tracestate",
+}
+
+// corsResponseHeaders are the response headers exposed to cross-origin callers.
+var corsResponseHeaders = []string{
+	"Idempotent-Replayed", "Link", "Location", "Retry-After", "X-Request-ID",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+}
+
+// allowOrigin reports whether the CORS settings allow requests from origin.
+func (c CORSConfig) allowOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// handleCORS wraps next so that the allowed origins can call it from a
+// browser. Preflight requests are answered with 204 No Content, without
+// reaching next.
+func handleCORS(cfg CORSConfig, next http.Handler) http.Handler {
+	methods := strings.Join(corsMethods, ", ")
+	requestHeaders := strings.Join(corsRequestHeaders, ", ")
+	responseHeaders := strings.Join(corsResponseHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !cfg.allowOrigin(origin) {
+			if preflight {
+				// Without the CORS headers, the browser blocks the actual request.
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.Set("Access-Control-Allow-Origin", origin)
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", responseHeaders)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.Set("Access-Control-Allow-Methods", methods)
+		h.Set("Access-Control-Allow-Headers", requestHeaders)
+		h.Set("Access-Control-Max-Age", maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// setSecurityHeaders wraps next so that every response carries the standard
+// security headers of an API, which serves no active content.
+func setSecurityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		h.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if r.TLS != nil {
+			h.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,159 @@
+package webservice
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+)
+
+// swaggerUIFiles holds swaggerui/VERSION, the version of Swagger UI, and its
+// assets once vendored by scripts/vendor-swagger-ui.sh (make swagger-ui).
+// They are not committed, so that a build depends on the CDN without it.
+//
+//go:embed swaggerui
+var swaggerUIFiles embed.FS
+
+// swaggerUIAssets is the path the vendored Swagger UI assets are served at.
+const swaggerUIAssets = "/docs/assets"
+
+// swaggerUICDN is the base URL of the Swagger UI assets loaded by the
+// documentation page when they are not vendored, as in a plain build.
+const swaggerUICDN = "https://unpkg.com/swagger-ui-dist@"
+
+// docsScript starts Swagger UI on the documentation page.
+const docsScript = `window.onload = () => {
+  window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+};`
+
+// docsPage is the documentation page, rendering the OpenAPI description with
+// the Swagger UI assets found at the %[1]s URL.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Book Club API</title>
+  <link rel="stylesheet" href="%[1]s/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="%[1]s/swagger-ui-bundle.js"></script>
+  <script>` + docsScript + `</script>
+</body>
+</html>
+`
+
+// swaggerUI returns the vendored Swagger UI assets, and false if they were
+// not vendored, in which case the documentation page loads them from the CDN.
+func swaggerUI() (fs.FS, bool) {
+	assets, err := fs.Sub(swaggerUIFiles, "swaggerui")
+	if err != nil {
+		return nil, false
+	}
+	_, err = fs.Stat(assets, "swagger-ui-bundle.js")
+
+	return assets, err == nil
+}
+
+// routes registers routes on a ServeMux and keeps their patterns, so that
+// they can be checked against the API description.
+type routes struct {
+	mux      *http.ServeMux
+	patterns []string
+}
+
+// handle registers h for pattern.
+func (rs *routes) handle(pattern string, h http.Handler) {
+	rs.mux.Handle(pattern, h)
+	rs.patterns = append(rs.patterns, pattern)
+}
+
+// handleFunc registers h for pattern.
+func (rs *routes) handleFunc(pattern string, h http.HandlerFunc) {
+	rs.handle(pattern, h)
+}
+
+// served records pattern as served by a handler registered with another pattern.
+func (rs *routes) served(pattern string) {
+	rs.patterns = append(rs.patterns, pattern)
+}
+
+// undocumented returns the registered patterns that are not described in [v1.OpenAPI].
+func (rs *routes) undocumented() ([]string, error) {
+	ops, err := v1.Operations()
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]string, 0)
+	for _, p := range rs.patterns {
+		if !slices.Contains(ops, p) {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing, nil
+}
+
+// checkDocumented logs a warning for each registered route that is missing
+// from the API description, so that they cannot drift apart unnoticed.
+func checkDocumented(rs *routes, logger *slog.Logger) {
+	missing, err := rs.undocumented()
+	if err != nil {
+		logger.With("error", err).Error("unable to read the OpenAPI description")
+		return
+	}
+	if len(missing) > 0 {
+		logger.With("routes", strings.Join(missing, ", ")).Warn("routes missing from the OpenAPI description")
+	}
+}
+
+// serveOpenAPI writes the OpenAPI description of the API.
+func serveOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(v1.OpenAPI)
+}
+
+// serveDocs returns a handler writing the documentation page, which loads
+// the Swagger UI assets from baseURL. Its Content Security Policy allows the
+// Swagger UI assets and the page script only.
+func serveDocs(baseURL string) http.HandlerFunc {
+	source := "'self'"
+	if !strings.HasPrefix(baseURL, "/") {
+		source = baseURL + "/"
+	}
+	sum := sha256.Sum256([]byte(docsScript))
+	csp := "default-src 'none'; " +
+		"script-src " + source + " 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'; " +
+		"style-src " + source + " 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+	page := fmt.Sprintf(docsPage, baseURL)
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Security-Policy", csp)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}
+}
+
+// handleDocs registers the documentation page on mux, together with the
+// vendored Swagger UI assets. When they were not vendored, the page loads
+// them from the CDN and a warning is logged.
+func handleDocs(mux *http.ServeMux, logger *slog.Logger) {
+	assets, vendored := swaggerUI()
+	if !vendored {
+		version, _ := fs.ReadFile(swaggerUIFiles, "swaggerui/VERSION")
+		logger.Warn("Swagger UI assets not vendored, /docs loads them from the CDN: run make swagger-ui before building for it to work offline")
+		mux.HandleFunc("GET /docs", serveDocs(swaggerUICDN+strings.TrimSpace(string(version))))
+		return
+	}
+
+	mux.Handle("GET "+swaggerUIAssets+"/", http.StripPrefix(swaggerUIAssets, http.FileServerFS(assets)))
+	mux.HandleFunc("GET /docs", serveDocs(swaggerUIAssets))
+}
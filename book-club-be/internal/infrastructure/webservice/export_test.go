@@ -0,0 +1,33 @@
+package webservice_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExportFailingBeforeTheFirstBook(t *testing.T) {
+	app := newTestApp(t)
+	// The books can no longer be fetched.
+	_, err := app.repo.DB().Exec(`ALTER TABLE books RENAME TO shelved_books`)
+	if err != nil {
+		t.Fatalf("unable to rename the books table: %v", err)
+	}
+
+	for _, format := range []string{"csv", "jsonl", "md"} {
+		t.Run(format, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			app.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/books/export?format="+format, nil))
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("GET /v1/books/export status = %d, want %d: %s", rec.Code, http.StatusInternalServerError, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want a problem", got)
+			}
+			if got := rec.Header().Get("Content-Disposition"); got != "" {
+				t.Errorf("Content-Disposition = %q, want no attachment", got)
+			}
+		})
+	}
+}
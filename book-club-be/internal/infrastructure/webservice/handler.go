@@ -1,7 +1,11 @@
 package webservice
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
 )
 
 // CRUDController defines the basic Create, Read, Update, and Delete
@@ -17,14 +21,314 @@ type CRUDController interface {
 	Delete(w http.ResponseWriter, r *http.Request)
 }
 
-// NewHandler registers the CRUDController routes for book resources and
-// returns an http.Handler. It maps each HTTP method and endpoint to the
-// corresponding CRUD operation.
-func NewHandler(bookController CRUDController) http.Handler {
+// ItemController defines the operations on a single resource identified by
+// an ID in the request path.
+type ItemController interface {
+	// ReadOne handles the HTTP request to retrieve a resource.
+	ReadOne(w http.ResponseWriter, r *http.Request)
+	// Replace handles the HTTP request to create or replace a resource with a client-chosen ID.
+	Replace(w http.ResponseWriter, r *http.Request)
+}
+
+// BackupController defines the administrative operations on database backups.
+type BackupController interface {
+	// Create handles the HTTP request to take a new backup.
+	Create(w http.ResponseWriter, r *http.Request)
+	// List handles the HTTP request to retrieve the stored backups.
+	List(w http.ResponseWriter, r *http.Request)
+}
+
+// WebhookController defines the administrative operations on webhooks and
+// their deliveries.
+type WebhookController interface {
+	// Create handles the HTTP request to register a webhook.
+	Create(w http.ResponseWriter, r *http.Request)
+	// List handles the HTTP request to retrieve the webhooks.
+	List(w http.ResponseWriter, r *http.Request)
+	// Delete handles the HTTP request to remove a webhook.
+	Delete(w http.ResponseWriter, r *http.Request)
+	// Deliveries handles the HTTP request to retrieve the delivery log of a webhook.
+	Deliveries(w http.ResponseWriter, r *http.Request)
+	// Redeliver handles the HTTP request to send a past delivery again.
+	Redeliver(w http.ResponseWriter, r *http.Request)
+}
+
+// StatsController defines the operation of reporting the reading statistics.
+type StatsController interface {
+	// Get handles the HTTP request to retrieve the reading statistics of the club.
+	Get(w http.ResponseWriter, r *http.Request)
+}
+
+// MemberController defines the administrative operations on the members of
+// the club and their notification preferences.
+type MemberController interface {
+	// Create handles the HTTP request to add a member.
+	Create(w http.ResponseWriter, r *http.Request)
+	// List handles the HTTP request to retrieve the members.
+	List(w http.ResponseWriter, r *http.Request)
+	// Update handles the HTTP request to change a member or their notification preferences.
+	Update(w http.ResponseWriter, r *http.Request)
+	// Delete handles the HTTP request to remove a member.
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+// ImportController defines the operation of importing resources in bulk.
+type ImportController interface {
+	// Import handles the HTTP request to import resources from an uploaded file.
+	Import(w http.ResponseWriter, r *http.Request)
+}
+
+// TrashController defines the operations on the resources deleted recently,
+// which are kept in a trash until they are purged.
+type TrashController interface {
+	// Trash handles the HTTP request to list the resources in the trash.
+	Trash(w http.ResponseWriter, r *http.Request)
+	// Restore handles the HTTP request to move a resource out of the trash.
+	Restore(w http.ResponseWriter, r *http.Request)
+}
+
+// EventController defines the streaming of the changes to resources.
+type EventController interface {
+	// Stream handles the HTTP request to follow the changes as they happen.
+	Stream(w http.ResponseWriter, r *http.Request)
+}
+
+// BatchController defines the operation of applying many changes to resources at once.
+type BatchController interface {
+	// Batch handles the HTTP request to apply a batch of create, update and delete operations.
+	Batch(w http.ResponseWriter, r *http.Request)
+}
+
+// ExportController defines the operation of exporting resources in bulk.
+type ExportController interface {
+	// Export handles the HTTP request to export resources in a requested format.
+	Export(w http.ResponseWriter, r *http.Request)
+}
+
+// ISBNController defines the lookup of books by ISBN.
+type ISBNController interface {
+	// ReadByISBN handles the HTTP request to retrieve the book with a given ISBN.
+	ReadByISBN(w http.ResponseWriter, r *http.Request)
+}
+
+// EnrichController defines the completion of a resource from external sources.
+type EnrichController interface {
+	// Enrich handles the HTTP request to fill the missing fields of a resource.
+	Enrich(w http.ResponseWriter, r *http.Request)
+}
+
+// CoverController defines the operations on the cover images of books.
+type CoverController interface {
+	// Upload handles the HTTP request to set the cover of a book.
+	Upload(w http.ResponseWriter, r *http.Request)
+	// Read handles the HTTP request to retrieve the cover of a book.
+	Read(w http.ResponseWriter, r *http.Request)
+}
+
+// AuthorController defines the operations on the authors of books.
+type AuthorController interface {
+	// List handles the HTTP request to retrieve all authors.
+	List(w http.ResponseWriter, r *http.Request)
+	// Books handles the HTTP request to retrieve the books of an author.
+	Books(w http.ResponseWriter, r *http.Request)
+	// Merge handles the HTTP request to merge duplicate authors into one.
+	Merge(w http.ResponseWriter, r *http.Request)
+}
+
+// TagController defines the operations on the tag vocabulary.
+type TagController interface {
+	// List handles the HTTP request to retrieve all tags.
+	List(w http.ResponseWriter, r *http.Request)
+	// Create handles the HTTP request to add a tag.
+	Create(w http.ResponseWriter, r *http.Request)
+	// Update handles the HTTP request to rename or move a tag.
+	Update(w http.ResponseWriter, r *http.Request)
+	// Merge handles the HTTP request to merge duplicate tags into one.
+	Merge(w http.ResponseWriter, r *http.Request)
+}
+
+// MetricsRecorder records the requests served by the handler and exposes
+// the collected metrics.
+type MetricsRecorder interface {
+	http.Handler
+	// ObserveRequest records a request matching route, served in elapsed with the given status.
+	ObserveRequest(method, route string, status int, elapsed time.Duration)
+}
+
+// Controllers groups the controllers whose routes are registered by NewHandler.
+// Optional controllers left nil have their routes omitted.
+type Controllers struct {
+	Books       CRUDController
+	BookItems   ItemController
+	BookImports ImportController
+	BookBatches BatchController
+	BookTrash   TrashController
+	Events      EventController
+	BookExports ExportController
+	BookISBNs   ISBNController
+	BookEnrich  EnrichController
+	BookCovers  CoverController
+	Authors     AuthorController
+	Tags        TagController
+	Stats       StatsController
+	Backups     BackupController
+	Webhooks    WebhookController
+	Members     MemberController
+}
+
+// Config holds the settings of the HTTP handler.
+type Config struct {
+	// AdminToken is the bearer token required by the /v1/admin routes.
+	// The admin routes are disabled when it is empty.
+	AdminToken string
+	// Logger is the logger every request is logged with, and that is
+	// carried, with the request ID, in the request context.
+	// It defaults to [slog.Default].
+	Logger *slog.Logger
+	// Metrics records the requests served and is exposed at /metrics.
+	// Requests are not measured when it is nil.
+	Metrics MetricsRecorder
+	// CORS sets which origins can call the API from a browser.
+	CORS CORSConfig
+	// Idempotency stores the responses to the creation requests sent with an
+	// Idempotency-Key header. The header is ignored when it is nil.
+	Idempotency domain.IdempotencyRepository
+	// ReadLimit and WriteLimit limit the read (GET and HEAD) and write
+	// requests of each client.
+	ReadLimit  RateLimit
+	WriteLimit RateLimit
+}
+
+// NewHandler registers the controllers routes and returns an http.Handler.
+// It maps each HTTP method and endpoint to the corresponding operation.
+// Every request is given an ID, traced, logged, measured and rate limited,
+// and panics are recovered. Responses carry the CORS and security headers.
+// The API description is served at /openapi.json and rendered at /docs, with
+// Swagger UI loaded from the CDN unless its assets were vendored, and the
+// routes it does not describe are logged as a warning.
+func NewHandler(c Controllers, cfg Config) http.Handler {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("PUT /v1/books", bookController.Create)
-	mux.HandleFunc("GET /v1/books", bookController.Read)
-	mux.HandleFunc("PATCH /v1/books/{id}", bookController.Update)
-	mux.HandleFunc("DELETE /v1/books/{id}", bookController.Delete)
-	return mux
+	rs := &routes{mux: mux}
+	rs.handleFunc("POST /v1/books", idempotent(cfg.Idempotency, cfg.AdminToken, logger, c.Books.Create))
+	rs.handleFunc("GET /v1/books", c.Books.Read)
+	rs.handleFunc("PATCH /v1/books/{id}", c.Books.Update)
+	rs.handleFunc("DELETE /v1/books/{id}", c.Books.Delete)
+
+	if c.BookItems != nil {
+		rs.handleFunc("GET /v1/books/{id}", c.BookItems.ReadOne)
+		rs.handleFunc("PUT /v1/books/{id}", c.BookItems.Replace)
+	}
+
+	if c.BookImports != nil {
+		rs.handleFunc("POST /v1/books/import", c.BookImports.Import)
+	}
+	if c.BookTrash != nil {
+		rs.handleFunc("GET /v1/books/trash", c.BookTrash.Trash)
+		rs.handleFunc("POST /v1/books/{id}/restore", c.BookTrash.Restore)
+	}
+	if c.Events != nil {
+		rs.handleFunc("GET /v1/events", c.Events.Stream)
+	}
+	if c.BookBatches != nil {
+		rs.handleFunc("POST /v1/books:batch", idempotent(cfg.Idempotency, cfg.AdminToken, logger, c.BookBatches.Batch))
+	}
+	if c.BookExports != nil {
+		rs.handleFunc("GET /v1/books/export", c.BookExports.Export)
+	}
+	if c.BookEnrich != nil {
+		rs.handleFunc("POST /v1/books/{id}/enrich", c.BookEnrich.Enrich)
+	}
+
+	// ServeMux cannot tell GET /v1/books/isbn/{isbn} apart from the book
+	// subresources, such as GET /v1/books/{id}/cover, so they share a pattern.
+	var readByISBN http.HandlerFunc
+	if c.BookISBNs != nil {
+		readByISBN = c.BookISBNs.ReadByISBN
+		rs.served("GET /v1/books/isbn/{isbn}")
+	}
+	subresources := make(map[string]http.HandlerFunc)
+	if c.BookCovers != nil {
+		rs.handleFunc("PUT /v1/books/{id}/cover", c.BookCovers.Upload)
+		subresources["cover"] = c.BookCovers.Read
+		rs.served("GET /v1/books/{id}/cover")
+	}
+	mux.HandleFunc("GET /v1/books/{id}/{subresource}", bookSubresources(readByISBN, subresources))
+
+	if c.Authors != nil {
+		rs.handleFunc("GET /v1/authors", c.Authors.List)
+		rs.handleFunc("GET /v1/authors/{id}/books", c.Authors.Books)
+		rs.handleFunc("POST /v1/authors/{id}/merge", c.Authors.Merge)
+	}
+
+	if c.Tags != nil {
+		rs.handleFunc("GET /v1/tags", c.Tags.List)
+		rs.handleFunc("POST /v1/tags", c.Tags.Create)
+		rs.handleFunc("PATCH /v1/tags/{id}", c.Tags.Update)
+		rs.handleFunc("POST /v1/tags/{id}/merge", c.Tags.Merge)
+	}
+
+	if c.Stats != nil {
+		rs.handleFunc("GET /v1/stats", c.Stats.Get)
+	}
+
+	if cfg.AdminToken != "" && c.Backups != nil {
+		rs.handle("POST /v1/admin/backups", requireToken(cfg.AdminToken, c.Backups.Create))
+		rs.handle("GET /v1/admin/backups", requireToken(cfg.AdminToken, c.Backups.List))
+	}
+
+	if cfg.AdminToken != "" && c.Webhooks != nil {
+		rs.handle("POST /v1/admin/webhooks", requireToken(cfg.AdminToken, c.Webhooks.Create))
+		rs.handle("GET /v1/admin/webhooks", requireToken(cfg.AdminToken, c.Webhooks.List))
+		rs.handle("DELETE /v1/admin/webhooks/{id}", requireToken(cfg.AdminToken, c.Webhooks.Delete))
+		rs.handle("GET /v1/admin/webhooks/{id}/deliveries", requireToken(cfg.AdminToken, c.Webhooks.Deliveries))
+		rs.handle("POST /v1/admin/webhooks/{id}/deliveries/{delivery_id}/redeliver",
+			requireToken(cfg.AdminToken, c.Webhooks.Redeliver))
+	}
+
+	if cfg.AdminToken != "" && c.Members != nil {
+		rs.handle("POST /v1/admin/members", requireToken(cfg.AdminToken, c.Members.Create))
+		rs.handle("GET /v1/admin/members", requireToken(cfg.AdminToken, c.Members.List))
+		rs.handle("PATCH /v1/admin/members/{id}", requireToken(cfg.AdminToken, c.Members.Update))
+		rs.handle("DELETE /v1/admin/members/{id}", requireToken(cfg.AdminToken, c.Members.Delete))
+	}
+
+	mux.HandleFunc("GET /openapi.json", serveOpenAPI)
+	handleDocs(mux, logger)
+	checkDocumented(rs, logger)
+
+	h := limitRequests(cfg.ReadLimit, cfg.WriteLimit, cfg.AdminToken, mux)
+	h = handleCORS(cfg.CORS, h)
+	h = setSecurityHeaders(h)
+	h = recoverPanics(logger, h)
+	if cfg.Metrics != nil {
+		mux.Handle("GET /metrics", cfg.Metrics)
+		h = measureRequests(cfg.Metrics, h)
+	}
+
+	return withRequestID(logger, traceRequests(logger, logRequests(logger, h)))
+}
+
+// bookSubresources returns a handler serving GET /v1/books/{id}/{subresource}
+// with the handler of the subresource, or GET /v1/books/isbn/{isbn} with
+// readByISBN, setting the isbn path value.
+func bookSubresources(readByISBN http.HandlerFunc, subresources map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "isbn" && readByISBN != nil {
+			r.SetPathValue("isbn", r.PathValue("subresource"))
+			readByISBN(w, r)
+			return
+		}
+
+		h, ok := subresources[r.PathValue("subresource")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	}
 }
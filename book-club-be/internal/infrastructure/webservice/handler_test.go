@@ -0,0 +1,145 @@
+package webservice_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/blob"
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+	"github.com/Michela-DC/book-club/internal/infrastructure/events"
+	"github.com/Michela-DC/book-club/internal/infrastructure/metadata"
+	"github.com/Michela-DC/book-club/internal/infrastructure/webhook"
+	"github.com/Michela-DC/book-club/internal/infrastructure/webservice"
+	"github.com/Michela-DC/book-club/internal/interfaces/controller"
+	"github.com/Michela-DC/book-club/internal/usecase/interactor"
+)
+
+// migrationsPath is the folder of the database migrations, relative to this package.
+const migrationsPath = "../../../database/migrations"
+
+// duneYear is the published year of the book known to the metadata provider of newTestApp.
+var duneYear = 1965
+
+// testAdminToken is the admin token of the handler returned by newTestApp.
+const testAdminToken = "test-admin-token"
+
+// testApp is the book club server wired as by the serve command, on a new
+// database, without its background jobs.
+type testApp struct {
+	handler http.Handler
+	// logs holds the warnings and errors logged by the server.
+	logs       *syncBuffer
+	repo       *db.SQLiteBookRepository
+	books      *interactor.BookInteractor
+	dispatcher *events.Dispatcher
+}
+
+// newTestApp returns the book club server on a new database, with every
+// controller registered.
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+
+	ctx := context.Background()
+	logs := &syncBuffer{}
+	logger := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	dir := t.TempDir()
+
+	repo, err := db.NewSQLiteBookRepository(filepath.Join(dir, "books.db"), logger)
+	if err != nil {
+		t.Fatalf("unable to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.DB().Close() })
+	err = repo.ApplyMigrations(ctx, migrationsPath)
+	if err != nil {
+		t.Fatalf("unable to apply migrations: %v", err)
+	}
+
+	snapshots, err := db.NewSQLiteSnapshotRepository(repo, filepath.Join(dir, "backups"), logger)
+	if err != nil {
+		t.Fatalf("unable to create snapshot repository: %v", err)
+	}
+	blobs, err := blob.NewLocalStore(filepath.Join(dir, "blobs"), logger)
+	if err != nil {
+		t.Fatalf("unable to create blob store: %v", err)
+	}
+
+	tags := db.NewSQLiteTagRepository(repo, logger)
+	members := db.NewSQLiteMemberRepository(repo, logger)
+	eventLog := db.NewSQLiteEventLog(repo, time.Hour, logger)
+	bus := events.NewBus(eventLog, logger)
+	wi := interactor.NewWebhookInteractor(
+		db.NewSQLiteWebhookRepository(repo, time.Hour, logger),
+		webhook.NewHTTPSender(),
+		logger,
+	)
+	dispatcher := events.NewDispatcher(eventLog, logger,
+		events.Sink{Name: "sse", Handler: bus}, events.Sink{Name: "webhook", Handler: wi},
+	)
+	provider := metadata.NewFakeProvider(&domain.BookMetadata{
+		ISBN:          "9780441172719",
+		Title:         "Dune",
+		Author:        "Frank Herbert",
+		PublishedYear: &duneYear,
+		Description:   "A desert planet.",
+	})
+
+	i := interactor.NewBookInteractor(repo, tags, members, provider, blobs, dispatcher, logger)
+	bc := controller.NewBookController(i, logger)
+	controllers := webservice.Controllers{
+		Books:       bc,
+		BookItems:   bc,
+		BookImports: bc,
+		BookBatches: bc,
+		BookTrash:   bc,
+		BookExports: bc,
+		BookISBNs:   bc,
+		BookEnrich:  bc,
+		BookCovers:  controller.NewCoverController(interactor.NewCoverInteractor(repo, blobs, dispatcher, logger), logger),
+		Authors: controller.NewAuthorController(
+			interactor.NewAuthorInteractor(db.NewSQLiteAuthorRepository(repo, logger), repo, logger), logger,
+		),
+		Tags:  controller.NewTagController(interactor.NewTagInteractor(tags, logger), logger),
+		Stats: controller.NewStatsController(interactor.NewStatsInteractor(db.NewSQLiteStatsRepository(repo, logger), logger), logger),
+		Backups: controller.NewBackupController(
+			interactor.NewBackupInteractor(snapshots, interactor.RetentionPolicy{Keep: 10}, logger), logger,
+		),
+		Events:   controller.NewEventController(bus, logger),
+		Webhooks: controller.NewWebhookController(wi, logger),
+		Members:  controller.NewMemberController(interactor.NewMemberInteractor(members, logger), logger),
+	}
+
+	h := webservice.NewHandler(controllers, webservice.Config{
+		AdminToken:  testAdminToken,
+		Logger:      logger,
+		Idempotency: db.NewSQLiteIdempotencyRepository(repo, time.Hour, logger),
+		ReadLimit:   webservice.RateLimit{PerMinute: 6000, Burst: 1000},
+		WriteLimit:  webservice.RateLimit{PerMinute: 6000, Burst: 1000},
+	})
+
+	return &testApp{handler: h, logs: logs, repo: repo, books: i, dispatcher: dispatcher}
+}
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
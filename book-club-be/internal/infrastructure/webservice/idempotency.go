@@ -0,0 +1,161 @@
+package webservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/interfaces/problem"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// idempotencyKeyHeader is the header carrying the idempotency key of a request.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength is the maximum length of an idempotency key.
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodySize is the maximum size of the body of a request sent
+// with an idempotency key, which is read in memory to fingerprint it.
+const maxIdempotentBodySize = 1 << 20
+
+// responseCapture is an http.ResponseWriter keeping a copy of the response
+// written through it.
+type responseCapture struct {
+	*statusRecorder
+	body bytes.Buffer
+}
+
+// Write keeps a copy of b and writes it to the response.
+func (w *responseCapture) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.statusRecorder.Write(b)
+}
+
+// idempotent wraps next so that a request sent with an Idempotency-Key header
+// is served at most once: its retries, with the same key and body, are
+// answered with the stored response and the Idempotent-Replayed header.
+// Reusing a key for a different request of the same admin client fails with
+// 422 Unprocessable Entity, and retrying while the request is served with
+// 409 Conflict.
+// Requests failing with a server error can be retried. Keys are scoped by
+// idempotencyScope, so that a client cannot replay the responses of another
+// one.
+func idempotent(
+	repo domain.IdempotencyRepository, adminToken string, fallback *slog.Logger, next http.HandlerFunc,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || repo == nil {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeBadRequest,
+				"the idempotency key must not exceed "+strconv.Itoa(maxIdempotencyKeyLength)+" characters"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				problem.Write(w, r, problem.New(http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge,
+					"the request body must not exceed "+strconv.Itoa(maxIdempotentBodySize)+" bytes"))
+				return
+			}
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeMalformedBody, err.Error()))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.New()
+		_, _ = io.WriteString(sum, r.Method+" "+r.URL.Path+"\n"+r.Header.Get("Content-Type")+"\n")
+		_, _ = sum.Write(body)
+		fingerprint := hex.EncodeToString(sum.Sum(nil))
+		record := &domain.IdempotencyRecord{
+			Key:         idempotencyScope(r, adminToken, fingerprint) + " " + r.Pattern + " " + key,
+			Fingerprint: fingerprint,
+		}
+
+		ctx := r.Context()
+		logger := logctx.FromContext(ctx, fallback)
+		existing, err := repo.Reserve(ctx, record)
+		if err != nil {
+			problem.Write(w, r, problem.New(http.StatusInternalServerError, problem.CodeInternal, ""))
+			return
+		}
+		if existing != nil {
+			replay(w, r, record, existing)
+			return
+		}
+
+		rec := &responseCapture{statusRecorder: recordStatus(w)}
+		// The outcome is stored even when the client is gone, which is when
+		// it is most likely to retry.
+		ctx = context.WithoutCancel(ctx)
+		defer func() {
+			record.Status = rec.statusOrOK()
+			if v := recover(); v != nil || record.Status >= http.StatusInternalServerError {
+				_ = repo.Release(ctx, record.Key)
+				if v != nil {
+					panic(v)
+				}
+				return
+			}
+
+			record.ContentType = rec.Header().Get("Content-Type")
+			record.Location = rec.Header().Get("Location")
+			record.Body = rec.body.Bytes()
+			err := repo.Complete(ctx, record)
+			if err != nil {
+				logger.With("error", err).Warn("unable to store idempotent response, retries will fail")
+			}
+		}()
+
+		next(rec, r)
+	}
+}
+
+// idempotencyScope returns the scope of the idempotency key of r, whose
+// fingerprint identifies its method, path and body. The requests carrying
+// the admin token are scoped to it. The others have no identity that
+// survives a retry, since a client may retry from another IP address, such
+// as a phone switching networks: they are scoped to their fingerprint, so
+// that only the same request with the same key is replayed.
+func idempotencyScope(r *http.Request, adminToken, fingerprint string) string {
+	if hasToken(r, adminToken) {
+		return clientKey(r, adminToken)
+	}
+
+	return "request:" + fingerprint
+}
+
+// replay answers r, whose idempotency key is already known, with the response stored in existing.
+func replay(w http.ResponseWriter, r *http.Request, record, existing *domain.IdempotencyRecord) {
+	switch {
+	case existing.Fingerprint != record.Fingerprint:
+		problem.Write(w, r, problem.New(http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused,
+			"the idempotency key was used for a different request"))
+	case existing.Status == 0:
+		problem.Write(w, r, problem.New(http.StatusConflict, problem.CodeConflict,
+			"a request with the same idempotency key is being served"))
+	default:
+		if existing.ContentType != "" {
+			w.Header().Set("Content-Type", existing.ContentType)
+		}
+		if existing.Location != "" {
+			w.Header().Set("Location", existing.Location)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(existing.Status)
+		_, _ = w.Write(existing.Body)
+	}
+}
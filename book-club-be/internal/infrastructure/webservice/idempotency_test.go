@@ -0,0 +1,63 @@
+package webservice_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIdempotencyKeyScopes(t *testing.T) {
+	app := newTestApp(t)
+	create := func(remoteAddr, auth, title string) (id string, status int, replayed bool) {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/books",
+			strings.NewReader(`{"title": "`+title+`", "author": "Jane Austen", "status": "SAVED"}`))
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Idempotency-Key", "club-night")
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		app.handler.ServeHTTP(rec, r)
+
+		var book struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &book)
+
+		return book.ID, rec.Code, rec.Header().Get("Idempotent-Replayed") == "true"
+	}
+
+	first, status, _ := create("192.0.2.1:1234", "", "Emma")
+	if status != http.StatusCreated {
+		t.Fatalf("POST /v1/books status = %d, want %d", status, http.StatusCreated)
+	}
+
+	// A phone retrying after switching networks comes from another address.
+	again, _, replayed := create("198.51.100.7:1234", "Bearer made-up", "Emma")
+	if !replayed || again != first {
+		t.Errorf("retry from another address = %s (replayed %t), want the replayed %s", again, replayed, first)
+	}
+
+	other, status, replayed := create("192.0.2.1:1234", "", "Persuasion")
+	if status != http.StatusCreated || replayed || other == first {
+		t.Errorf("another request with the key = %s (status %d, replayed %t), want a new book", other, status, replayed)
+	}
+
+	admin, status, replayed := create("192.0.2.1:1234", "Bearer "+testAdminToken, "Emma")
+	if status != http.StatusCreated || replayed || admin == first {
+		t.Errorf("request with the admin token = %s (status %d, replayed %t), want a new book", admin, status, replayed)
+	}
+	adminAgain, _, replayed := create("203.0.113.9:1234", "Bearer "+testAdminToken, "Emma")
+	if !replayed || adminAgain != admin {
+		t.Errorf("admin retry = %s (replayed %t), want the replayed %s", adminAgain, replayed, admin)
+	}
+	_, status, _ = create("192.0.2.1:1234", "Bearer "+testAdminToken, "Sanditon")
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("admin key reused for another request status = %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+}
@@ -0,0 +1,236 @@
+package webservice
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Michela-DC/book-club/internal/interfaces/problem"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// requestIDHeader is the header carrying the ID of a request, taken from the
+// client when valid and otherwise generated.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of a request ID sent by a client.
+const maxRequestIDLength = 128
+
+var tracer = otel.Tracer("github.com/Michela-DC/book-club/internal/infrastructure/webservice")
+
+// requireToken wraps next so that it is only served to requests carrying
+// token as a bearer token in the Authorization header.
+func requireToken(token string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasToken(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized,
+				"a valid bearer token is required"))
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// hasToken reports whether r carries token as a bearer token in the
+// Authorization header.
+func hasToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// withRequestID wraps next so that every request has an ID, echoed in the
+// X-Request-ID response header, and a logger carrying it in its context.
+func withRequestID(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		ctx := logctx.NewContext(r.Context(), logger.With("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether a request ID sent by a client can be used:
+// it must be non-empty, not too long and made of printable ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// statusRecorder is an http.ResponseWriter that records the status code and
+// the size of the response written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status code and writes it to the response.
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the size of b and writes it to the response.
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// statusOrOK returns the recorded status code, which is 200 OK when the
+// handler wrote nothing.
+func (w *statusRecorder) statusOrOK() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// recordStatus returns w if it is a statusRecorder, or a new statusRecorder wrapping it.
+func recordStatus(w http.ResponseWriter) *statusRecorder {
+	if rec, ok := w.(*statusRecorder); ok {
+		return rec
+	}
+	return &statusRecorder{ResponseWriter: w}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for [http.ResponseController].
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logRequests wraps next so that every request is logged, once served, with
+// its method, path, status and latency. Server errors are logged as errors.
+func logRequests(fallback *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := recordStatus(w)
+		next.ServeHTTP(rec, r)
+
+		status := rec.statusOrOK()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logctx.FromContext(r.Context(), fallback).With(
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", r.Pattern,
+			"status", status,
+			"bytes", rec.bytes,
+			"latency_ms", time.Since(start).Milliseconds(),
+		).Log(r.Context(), level, "request served")
+	})
+}
+
+// recoverPanics wraps next so that a panic while serving a request is logged
+// with its stack trace and answered with 500 Internal Server Error, instead
+// of dropping the connection.
+func recoverPanics(fallback *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := recordStatus(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+
+			logctx.FromContext(r.Context(), fallback).
+				With("panic", fmt.Sprint(v), "stack", string(debug.Stack())).
+				Error("panic while serving request")
+
+			if rec.status != 0 {
+				// The response has started: the client sees it truncated.
+				return
+			}
+			problem.Write(rec, r, problem.New(http.StatusInternalServerError, problem.CodeInternal,
+				"an unexpected error occurred"))
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// measureRequests wraps next so that every request is recorded by metrics,
+// with the route it matched, its status and its latency.
+func measureRequests(metrics MetricsRecorder, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := recordStatus(w)
+		next.ServeHTTP(rec, r)
+
+		metrics.ObserveRequest(r.Method, r.Pattern, rec.statusOrOK(), time.Since(start))
+	})
+}
+
+// traceRequests wraps next so that every request is served within a server
+// span, continuing the trace of the W3C traceparent header when present.
+// The trace ID is added to the logger carried in the request context.
+func traceRequests(fallback *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			logger := logctx.FromContext(ctx, fallback).With("trace_id", sc.TraceID().String())
+			ctx = logctx.NewContext(ctx, logger)
+		}
+
+		rec := recordStatus(w)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+
+		status := rec.statusOrOK()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if r.Pattern != "" {
+			_, route, _ := strings.Cut(r.Pattern, " ")
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
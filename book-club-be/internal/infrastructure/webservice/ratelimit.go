@@ -0,0 +1,154 @@
+package webservice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/interfaces/problem"
+)
+
+// RateLimit is a token bucket limit on the requests of a client: the bucket
+// holds up to Burst requests and is refilled with PerMinute requests a minute.
+// The limit is disabled when PerMinute is zero.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+// bucketIdleTime is how long the bucket of a client is kept once full.
+const bucketIdleTime = 10 * time.Minute
+
+// bucket holds the tokens left to a client when it was last updated.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket for each client.
+type rateLimiter struct {
+	limit   RateLimit
+	rate    float64 // tokens per second
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// newRateLimiter creates a rateLimiter enforcing limit, or returns nil if the limit is disabled.
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	return &rateLimiter{
+		limit:   limit,
+		rate:    float64(limit.PerMinute) / 60,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// take takes a token from the bucket of client, if any is left. It returns
+// the tokens left and how long it takes to refill the bucket or, when the
+// request is rejected, to get the next token.
+func (l *rateLimiter) take(client string, now time.Time) (ok bool, remaining int, wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	burst := float64(l.limit.Burst)
+	b, found := l.buckets[client]
+	if !found {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, 0, l.refillTime(1 - b.tokens)
+	}
+
+	b.tokens--
+	return true, int(b.tokens), l.refillTime(burst - b.tokens)
+}
+
+// refillTime returns how long it takes to refill tokens.
+func (l *rateLimiter) refillTime(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep removes the buckets that have been full for a while, at most once
+// every bucketIdleTime.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < bucketIdleTime {
+		return
+	}
+	l.swept = now
+
+	for client, b := range l.buckets {
+		if now.Sub(b.last) > l.refillTime(float64(l.limit.Burst)-b.tokens)+bucketIdleTime {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// limitRequests wraps next so that each client, identified as by clientKey,
+// is limited to read requests, i.e. GET and HEAD, and to write requests. The limit and the state of the bucket are
+// returned in the RateLimit-* headers, and rejected requests are answered
+// with 429 Too Many Requests and a Retry-After header.
+func limitRequests(read, write RateLimit, adminToken string, next http.Handler) http.Handler {
+	readLimiter, writeLimiter := newRateLimiter(read), newRateLimiter(write)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := readLimiter
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			l = writeLimiter
+		}
+		if l == nil || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, remaining, wait := l.take(clientKey(r, adminToken), time.Now())
+		seconds := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(l.limit.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		h.Set("RateLimit-Reset", seconds)
+
+		if !allowed {
+			h.Set("Retry-After", seconds)
+			problem.Write(w, r, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited,
+				"too many requests, retry after "+seconds+" seconds"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the client of r by its bearer token when it is the
+// admin token, hashed so that it is not kept in memory, or else by its IP
+// address. Unknown tokens are not trusted as an identity, otherwise a client
+// could get a new bucket for each made-up token.
+func clientKey(r *http.Request, adminToken string) string {
+	if hasToken(r, adminToken) {
+		sum := sha256.Sum256([]byte(adminToken))
+		return "token:" + hex.EncodeToString(sum[:])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,82 @@
+package webservice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientKey(t *testing.T) {
+	const admin = "admin-token"
+	sum := sha256.Sum256([]byte(admin))
+
+	tests := []struct {
+		name       string
+		auth       string
+		remoteAddr string
+		want       string
+	}{
+		{name: "anonymous", remoteAddr: "192.0.2.1:1234", want: "ip:192.0.2.1"},
+		{name: "unknown token", auth: "Bearer made-up", remoteAddr: "192.0.2.1:1234", want: "ip:192.0.2.1"},
+		{name: "other scheme", auth: "Basic " + admin, remoteAddr: "192.0.2.1:1234", want: "ip:192.0.2.1"},
+		{
+			name:       "admin token",
+			auth:       "Bearer " + admin,
+			remoteAddr: "192.0.2.1:1234",
+			want:       "token:" + hex.EncodeToString(sum[:]),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/books", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+
+			if got := clientKey(r, admin); got != tt.want {
+				t.Errorf("clientKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientKeyWithoutAdminToken(t *testing.T) {
+	r := bearerRequest("", "192.0.2.1:1234")
+
+	if got := clientKey(r, ""); got != "ip:192.0.2.1" {
+		t.Errorf("clientKey() = %q, want the IP address", got)
+	}
+}
+
+func TestLimitRequestsIgnoresUnknownTokens(t *testing.T) {
+	h := limitRequests(RateLimit{PerMinute: 1, Burst: 1}, RateLimit{}, "admin-token",
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+
+	for i, want := range []int{http.StatusNoContent, http.StatusTooManyRequests} {
+		r := bearerRequest("made-up-"+string(rune('a'+i)), "192.0.2.1:1234")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Errorf("request %d status = %d, want %d", i, rec.Code, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, bearerRequest("admin-token", "192.0.2.1:1234"))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("admin request status = %d, want its own bucket", rec.Code)
+	}
+}
+
+// bearerRequest returns a GET request from remoteAddr carrying token as a bearer token.
+func bearerRequest(token, remoteAddr string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/v1/books", nil)
+	r.RemoteAddr = remoteAddr
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	return r
+}
@@ -0,0 +1,141 @@
+package webservice_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceChain(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	app := newTestApp(t)
+	book, err := app.books.CreateBook(context.Background(), &domain.Book{
+		Title: "Dune", Author: "Frank Herbert", Status: domain.BookStatusSaved,
+	})
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	exporter.Reset()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/v1/books/"+book.ID, nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	app.handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /v1/books/{id} status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	spans := exporter.GetSpans()
+	server := findSpan(t, spans, "GET /v1/books/{id}")
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v, want %v", server.SpanKind, trace.SpanKindServer)
+	}
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("server span trace ID = %s, want the one of traceparent %s", got, traceID)
+	}
+	assertAttributes(t, server, map[attribute.Key]attribute.Value{
+		"http.request.method":       attribute.StringValue(http.MethodGet),
+		"url.path":                  attribute.StringValue("/v1/books/" + book.ID),
+		"http.route":                attribute.StringValue("/v1/books/{id}"),
+		"http.response.status_code": attribute.IntValue(http.StatusOK),
+	})
+
+	ctrl := findSpan(t, spans, "BookController.ReadOne")
+	assertParent(t, ctrl, server)
+	interactor := findSpan(t, spans, "BookInteractor.GetBook")
+	assertParent(t, interactor, ctrl)
+
+	var query *tracetest.SpanStub
+	for i, s := range spans {
+		if s.Parent.SpanID() == interactor.SpanContext.SpanID() && s.Name == "SELECT" {
+			query = &spans[i]
+			break
+		}
+	}
+	if query == nil {
+		t.Fatalf("no SELECT span is a child of %s in %v", interactor.Name, spanNames(spans))
+	}
+	if query.SpanKind != trace.SpanKindClient {
+		t.Errorf("query span kind = %v, want %v", query.SpanKind, trace.SpanKindClient)
+	}
+	assertAttributes(t, *query, map[attribute.Key]attribute.Value{
+		"db.system.name":    attribute.StringValue("sqlite"),
+		"db.operation.name": attribute.StringValue("SELECT"),
+	})
+	if text := attributeOf(*query, "db.query.text").AsString(); !strings.Contains(text, "FROM books") {
+		t.Errorf("db.query.text = %q, want a query of the books table", text)
+	}
+}
+
+// findSpan returns the span of spans named name, failing the test when there is none.
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span %q in %v", name, spanNames(spans))
+
+	return tracetest.SpanStub{}
+}
+
+// assertParent checks that child is a direct child of parent in the same trace.
+func assertParent(t *testing.T, child, parent tracetest.SpanStub) {
+	t.Helper()
+
+	if child.SpanContext.TraceID() != parent.SpanContext.TraceID() {
+		t.Errorf("%s trace ID = %s, want %s", child.Name, child.SpanContext.TraceID(), parent.SpanContext.TraceID())
+	}
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+		t.Errorf("%s parent = %s, want %s (%s)", child.Name, child.Parent.SpanID(), parent.Name, parent.SpanContext.SpanID())
+	}
+}
+
+// assertAttributes checks that span holds every attribute of want.
+func assertAttributes(t *testing.T, span tracetest.SpanStub, want map[attribute.Key]attribute.Value) {
+	t.Helper()
+
+	for k, v := range want {
+		if got := attributeOf(span, k); got != v {
+			t.Errorf("%s attribute %s = %v, want %v", span.Name, k, got.Emit(), v.Emit())
+		}
+	}
+}
+
+// attributeOf returns the value of the attribute key of span, or an empty value.
+func attributeOf(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+
+	return names
+}
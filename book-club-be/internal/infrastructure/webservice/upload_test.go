@@ -0,0 +1,111 @@
+package webservice_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+)
+
+// serverTimeout is the read and write timeout of the server of slowUpload,
+// shorter than the time taken by the uploads.
+const serverTimeout = 300 * time.Millisecond
+
+// slowReader returns the content of r in chunks, waiting delay before each.
+type slowReader struct {
+	r     io.Reader
+	chunk int
+	delay time.Duration
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.r.Read(p[:min(len(p), s.chunk)])
+}
+
+// slowUpload sends body to path of app, served with the timeouts of the
+// serve command shortened to serverTimeout, taking about three times
+// serverTimeout to upload it. It returns the response, whose body is read.
+func slowUpload(t *testing.T, app *testApp, method, path string, body []byte, contentType string) (*http.Response, []byte) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(app.handler)
+	server.Config.ReadTimeout = serverTimeout
+	server.Config.WriteTimeout = serverTimeout
+	server.Config.ReadHeaderTimeout = serverTimeout
+	server.Start()
+	t.Cleanup(server.Close)
+
+	chunks := 6
+	req, err := http.NewRequest(method, server.URL+path, &slowReader{
+		r:     bytes.NewReader(body),
+		chunk: len(body)/chunks + 1,
+		delay: serverTimeout / 2,
+	})
+	if err != nil {
+		t.Fatalf("unable to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	start := time.Now()
+	res, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v, want the response after a slow upload", method, path, err)
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("unable to read the response after a slow upload: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*serverTimeout {
+		t.Fatalf("upload took %v, want longer than the write timeout %v", elapsed, serverTimeout)
+	}
+
+	return res, data
+}
+
+func TestSlowImport(t *testing.T) {
+	app := newTestApp(t)
+	export := "Title,Author,Exclusive Shelf,ISBN13\nPersuasion,Jane Austen,read,\nEmma,Jane Austen,to-read,\n"
+	body, contentType := multipartBody(t, "file", "goodreads.csv", []byte(export))
+	content, _ := io.ReadAll(body)
+
+	res, data := slowUpload(t, app, http.MethodPost, "/v1/books/import", content, contentType)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("POST /v1/books/import status = %d, want %d: %s", res.StatusCode, http.StatusOK, data)
+	}
+	var report v1.ImportReport
+	err := json.Unmarshal(data, &report)
+	if err != nil || len(report.Created) != 2 {
+		t.Errorf("import report = %s, want the 2 books imported", data)
+	}
+}
+
+func TestSlowCoverUpload(t *testing.T) {
+	app := newTestApp(t)
+	book, err := app.books.CreateBook(context.Background(), &domain.Book{
+		Title: "Emma", Author: "Jane Austen", Status: domain.BookStatusSaved,
+	})
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	body, contentType := multipartBody(t, "cover", "emma.png", pngImage(t))
+	content, _ := io.ReadAll(body)
+
+	res, data := slowUpload(t, app, http.MethodPut, "/v1/books/"+book.ID+"/cover", content, contentType)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("PUT /v1/books/{id}/cover status = %d, want %d: %s", res.StatusCode, http.StatusOK, data)
+	}
+	var updated v1.Book
+	err = json.Unmarshal(data, &updated)
+	if err != nil || updated.ID != book.ID || updated.CoverURL == nil {
+		t.Errorf("updated book = %s, want %s with its cover", data, book.ID)
+	}
+}
@@ -0,0 +1,35 @@
+package v1
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// OpenAPI is the OpenAPI 3 description of the /v1 API, in JSON.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// Operations returns the operations described by [OpenAPI] as ServeMux
+// patterns, e.g. "GET /v1/books/{id}", sorted.
+func Operations() ([]string, error) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	err := json.Unmarshal(OpenAPI, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]string, 0)
+	for path, item := range doc.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+
+	return ops, nil
+}
@@ -0,0 +1,490 @@
+// Package v1 defines the wire format of the responses of the /v1 API and
+// maps the domain entities to it. The domain can change without affecting
+// the API as long as the mapping functions keep the same output, and a
+// future API version can define its own format next to this one.
+package v1
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/interfaces/problem"
+)
+
+// Book is a book as returned by the API.
+type Book struct {
+	ID            string     `json:"id"`
+	Title         string     `json:"title"`
+	Author        string     `json:"author"`
+	Authors       []Author   `json:"authors"`
+	Genre         *string    `json:"genre"`
+	Tags          []Tag      `json:"tags"`
+	PublishedYear *int       `json:"published_year"`
+	ISBN          *string    `json:"isbn"`
+	CoverURL      *string    `json:"cover_url"`
+	PageCount     *int       `json:"page_count"`
+	Description   *string    `json:"description"`
+	Status        string     `json:"status"`
+	SuggestedBy   *string    `json:"suggested_by"`
+	DeletedAt     *time.Time `json:"deleted_at"`
+}
+
+// Author is an author as returned by the API.
+type Author struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Tag is a tag of the vocabulary as returned by the API.
+type Tag struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+// Snapshot is a database backup as returned by the API.
+type Snapshot struct {
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+}
+
+// ImportReport is the outcome of an import as returned by the API.
+type ImportReport struct {
+	Created []ImportResult `json:"created"`
+	Skipped []ImportResult `json:"skipped"`
+	Failed  []ImportResult `json:"failed"`
+}
+
+// ImportResult is the outcome of an imported row as returned by the API.
+type ImportResult struct {
+	BookID string `json:"book_id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Line   int    `json:"line"`
+}
+
+// Event is a change to a book as returned by the API. Book is null for
+// deletions, while a reset event, telling clients to reload the books,
+// has only a type.
+type Event struct {
+	ID     int64  `json:"id,omitempty"`
+	Type   string `json:"type"`
+	BookID string `json:"book_id,omitempty"`
+	Book   *Book  `json:"book"`
+	// PreviousStatus is the status of the book before an update.
+	PreviousStatus string     `json:"previous_status,omitempty"`
+	OccurredAt     *time.Time `json:"occurred_at,omitempty"`
+}
+
+// BatchReport is the outcome of a batch of book operations as returned by the API.
+type BatchReport struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// BatchResult is the outcome of a batch operation as returned by the API,
+// with the problem details of the failure, if any, in Error.
+type BatchResult struct {
+	Index  int              `json:"index"`
+	Op     string           `json:"op"`
+	Status int              `json:"status"`
+	Book   *Book            `json:"book,omitempty"`
+	Error  *problem.Problem `json:"error,omitempty"`
+}
+
+// Webhook is a webhook as returned by the API. The secret is only returned
+// when the webhook is created.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is an attempted or pending delivery of a webhook as
+// returned by the API.
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	WebhookID      string     `json:"webhook_id"`
+	EventID        *int64     `json:"event_id"`
+	RedeliveryOf   *string    `json:"redelivery_of"`
+	Event          string     `json:"event"`
+	BookID         string     `json:"book_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus *int       `json:"response_status"`
+	LastError      *string    `json:"last_error"`
+	OccurredAt     time.Time  `json:"occurred_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Member is a member of the club as returned by the API.
+type Member struct {
+	ID            string                  `json:"id"`
+	Name          string                  `json:"name"`
+	Email         string                  `json:"email"`
+	Notifications NotificationPreferences `json:"notifications"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+// NotificationPreferences lists the emails a member receives.
+type NotificationPreferences struct {
+	Suggestions bool `json:"suggestions"`
+	Decisions   bool `json:"decisions"`
+	Digest      bool `json:"digest"`
+}
+
+// ReadingStats is the reading statistics of the club as returned by the API.
+type ReadingStats struct {
+	BooksByStatus map[string]int       `json:"books_by_status"`
+	Completed     CompletedStats       `json:"completed"`
+	ReadingTime   ReadingTime          `json:"reading_time"`
+	Genres        []GenreCount         `json:"genres"`
+	Authors       []AuthorCount        `json:"authors"`
+	Suggestions   SuggestionStats      `json:"suggestions"`
+	Members       []MemberContribution `json:"members"`
+}
+
+// CompletedStats counts the books completed each year and each month.
+type CompletedStats struct {
+	ByYear  []PeriodCount `json:"by_year"`
+	ByMonth []PeriodCount `json:"by_month"`
+}
+
+// PeriodCount is a number of books over a year, or over a month when Month is set.
+type PeriodCount struct {
+	Year  int `json:"year"`
+	Month int `json:"month,omitempty"`
+	Count int `json:"count"`
+}
+
+// ReadingTime is the time, in days, taken to read the completed books. The
+// durations are null when no completion was measured.
+type ReadingTime struct {
+	Completed    int      `json:"completed"`
+	AverageDays  *float64 `json:"average_days"`
+	ShortestDays *float64 `json:"shortest_days"`
+	LongestDays  *float64 `json:"longest_days"`
+}
+
+// GenreCount is the number of books of a genre, null for the books without genre.
+type GenreCount struct {
+	Genre *string `json:"genre"`
+	Count int     `json:"count"`
+}
+
+// AuthorCount is the number of books of an author.
+type AuthorCount struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// SuggestionStats counts the decisions on the suggested books.
+type SuggestionStats struct {
+	Pending        int      `json:"pending"`
+	Accepted       int      `json:"accepted"`
+	Discarded      int      `json:"discarded"`
+	AcceptanceRate *float64 `json:"acceptance_rate"`
+}
+
+// MemberContribution is the number of books suggested by a member. The
+// email of the member is left out, as the statistics are public.
+type MemberContribution struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Suggested int    `json:"suggested"`
+	Accepted  int    `json:"accepted"`
+	Completed int    `json:"completed"`
+}
+
+// WebhookPayload is the body posted to a webhook for a delivery.
+type WebhookPayload struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Book       Book      `json:"book"`
+}
+
+// NewBook maps book to its API format.
+func NewBook(book *domain.Book) Book {
+	return Book{
+		ID:            book.ID,
+		Title:         book.Title,
+		Author:        book.Author,
+		Authors:       NewAuthors(book.Authors),
+		Genre:         book.Genre,
+		Tags:          NewTags(book.Tags),
+		PublishedYear: book.PublishedYear,
+		ISBN:          book.ISBN,
+		CoverURL:      book.CoverURL,
+		PageCount:     book.PageCount,
+		Description:   book.Description,
+		Status:        string(book.Status),
+		SuggestedBy:   book.SuggestedBy,
+		DeletedAt:     book.DeletedAt,
+	}
+}
+
+// NewBooks maps books to their API format. It never returns nil, so that
+// an empty list is encoded as [].
+func NewBooks(books []*domain.Book) []Book {
+	return mapAll(books, NewBook)
+}
+
+// NewAuthor maps author to its API format.
+func NewAuthor(author *domain.Author) Author {
+	return Author{ID: author.ID, Name: author.Name}
+}
+
+// NewAuthors maps authors to their API format. It never returns nil.
+func NewAuthors(authors []*domain.Author) []Author {
+	return mapAll(authors, NewAuthor)
+}
+
+// NewTag maps tag to its API format.
+func NewTag(tag *domain.Tag) Tag {
+	return Tag{ID: tag.ID, Name: tag.Name, ParentID: tag.ParentID}
+}
+
+// NewTags maps tags to their API format. It never returns nil.
+func NewTags(tags []*domain.Tag) []Tag {
+	return mapAll(tags, NewTag)
+}
+
+// NewSnapshot maps snapshot to its API format.
+func NewSnapshot(snapshot *domain.Snapshot) Snapshot {
+	return Snapshot{CreatedAt: snapshot.CreatedAt, Name: snapshot.Name, Size: snapshot.Size}
+}
+
+// NewSnapshots maps snapshots to their API format. It never returns nil.
+func NewSnapshots(snapshots []*domain.Snapshot) []Snapshot {
+	return mapAll(snapshots, NewSnapshot)
+}
+
+// NewImportReport maps report to its API format.
+func NewImportReport(report *domain.ImportReport) ImportReport {
+	results := func(rs []domain.ImportResult) []ImportResult {
+		out := make([]ImportResult, len(rs))
+		for i, r := range rs {
+			out[i] = ImportResult{BookID: r.BookID, Title: r.Title, Author: r.Author, Reason: r.Reason, Line: r.Line}
+		}
+		return out
+	}
+
+	return ImportReport{
+		Created: results(report.Created),
+		Skipped: results(report.Skipped),
+		Failed:  results(report.Failed),
+	}
+}
+
+// NewEvent maps event to its API format.
+func NewEvent(event *domain.Event) Event {
+	e := Event{
+		ID:             event.ID,
+		Type:           string(event.Type),
+		BookID:         event.BookID,
+		PreviousStatus: string(event.PreviousStatus),
+	}
+	if event.Book != nil {
+		book := NewBook(event.Book)
+		e.Book = &book
+	}
+	if !event.OccurredAt.IsZero() {
+		e.OccurredAt = &event.OccurredAt
+	}
+
+	return e
+}
+
+// batchStatus is the status reported for each kind of successful batch operation.
+var batchStatus = map[domain.BatchOp]int{
+	domain.BatchOpCreate: http.StatusCreated,
+	domain.BatchOpUpdate: http.StatusOK,
+	domain.BatchOpDelete: http.StatusNoContent,
+}
+
+// NewBatchReport maps report to its API format. The problem details of the
+// failed operations are given by problemFor.
+func NewBatchReport(report *domain.BatchReport, problemFor func(error) *problem.Problem) BatchReport {
+	results := make([]BatchResult, len(report.Results))
+	for i, r := range report.Results {
+		results[i] = BatchResult{Index: i, Op: string(r.Op), Status: batchStatus[r.Op]}
+		if r.Err != nil {
+			results[i].Error = problemFor(r.Err)
+			results[i].Status = results[i].Error.Status
+			continue
+		}
+		if r.Book != nil {
+			book := NewBook(r.Book)
+			results[i].Book = &book
+		}
+	}
+
+	return BatchReport{Committed: report.Committed, Results: results}
+}
+
+// NewWebhook maps hook to its API format, leaving out its secret.
+func NewWebhook(hook *domain.Webhook) Webhook {
+	events := make([]string, len(hook.Events))
+	for i, e := range hook.Events {
+		events[i] = string(e)
+	}
+
+	return Webhook{ID: hook.ID, URL: hook.URL, Events: events, CreatedAt: hook.CreatedAt}
+}
+
+// NewWebhooks maps hooks to their API format. It never returns nil.
+func NewWebhooks(hooks []*domain.Webhook) []Webhook {
+	return mapAll(hooks, NewWebhook)
+}
+
+// NewWebhookDelivery maps delivery to its API format.
+func NewWebhookDelivery(delivery *domain.WebhookDelivery) WebhookDelivery {
+	d := WebhookDelivery{
+		ID:            delivery.ID,
+		WebhookID:     delivery.WebhookID,
+		Event:         string(delivery.Event),
+		BookID:        delivery.BookID,
+		Status:        string(delivery.Status),
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		LastAttemptAt: delivery.LastAttemptAt,
+		OccurredAt:    delivery.OccurredAt,
+		CreatedAt:     delivery.CreatedAt,
+	}
+	if delivery.Status != domain.DeliveryPending {
+		d.NextAttemptAt = nil
+	}
+	if delivery.EventID != 0 {
+		d.EventID = &delivery.EventID
+	}
+	if delivery.RedeliveryOf != "" {
+		d.RedeliveryOf = &delivery.RedeliveryOf
+	}
+	if delivery.ResponseStatus != 0 {
+		d.ResponseStatus = &delivery.ResponseStatus
+	}
+	if delivery.LastError != "" {
+		d.LastError = &delivery.LastError
+	}
+
+	return d
+}
+
+// NewWebhookDeliveries maps deliveries to their API format. It never returns nil.
+func NewWebhookDeliveries(deliveries []*domain.WebhookDelivery) []WebhookDelivery {
+	return mapAll(deliveries, NewWebhookDelivery)
+}
+
+// NewMember maps member to its API format.
+func NewMember(member *domain.Member) Member {
+	return Member{
+		ID:    member.ID,
+		Name:  member.Name,
+		Email: member.Email,
+		Notifications: NotificationPreferences{
+			Suggestions: member.Notifications.Suggestions,
+			Decisions:   member.Notifications.Decisions,
+			Digest:      member.Notifications.Digest,
+		},
+		CreatedAt: member.CreatedAt,
+	}
+}
+
+// NewMembers maps members to their API format. It never returns nil.
+func NewMembers(members []*domain.Member) []Member {
+	return mapAll(members, NewMember)
+}
+
+// NewReadingStats maps stats to its API format. It never returns nil lists.
+func NewReadingStats(stats *domain.ReadingStats) ReadingStats {
+	res := ReadingStats{
+		BooksByStatus: make(map[string]int, len(stats.Books)),
+		Completed: CompletedStats{
+			ByYear:  make([]PeriodCount, len(stats.CompletedByYear)),
+			ByMonth: make([]PeriodCount, len(stats.CompletedByMonth)),
+		},
+		ReadingTime: ReadingTime{Completed: stats.Reading.Completed},
+		Genres:      make([]GenreCount, len(stats.Genres)),
+		Authors:     make([]AuthorCount, len(stats.Authors)),
+		Suggestions: SuggestionStats{
+			Pending:   stats.Suggestions.Pending,
+			Accepted:  stats.Suggestions.Accepted,
+			Discarded: stats.Suggestions.Discarded,
+		},
+		Members: make([]MemberContribution, len(stats.Members)),
+	}
+
+	for status, count := range stats.Books {
+		res.BooksByStatus[string(status)] = count
+	}
+	for i, p := range stats.CompletedByYear {
+		res.Completed.ByYear[i] = PeriodCount{Year: p.Year, Count: p.Count}
+	}
+	for i, p := range stats.CompletedByMonth {
+		res.Completed.ByMonth[i] = PeriodCount{Year: p.Year, Month: int(p.Month), Count: p.Count}
+	}
+	if stats.Reading.Completed > 0 {
+		res.ReadingTime.AverageDays = days(stats.Reading.Average)
+		res.ReadingTime.ShortestDays = days(stats.Reading.Shortest)
+		res.ReadingTime.LongestDays = days(stats.Reading.Longest)
+	}
+	for i, g := range stats.Genres {
+		res.Genres[i] = GenreCount{Count: g.Count}
+		if g.Genre != "" {
+			res.Genres[i].Genre = &g.Genre
+		}
+	}
+	for i, a := range stats.Authors {
+		res.Authors[i] = AuthorCount{ID: a.Author.ID, Name: a.Author.Name, Count: a.Count}
+	}
+	if rate, ok := stats.Suggestions.AcceptanceRate(); ok {
+		rate = math.Round(rate*1000) / 1000
+		res.Suggestions.AcceptanceRate = &rate
+	}
+	for i, m := range stats.Members {
+		res.Members[i] = MemberContribution{
+			ID:        m.Member.ID,
+			Name:      m.Member.Name,
+			Suggested: m.Suggested,
+			Accepted:  m.Accepted,
+			Completed: m.Completed,
+		}
+	}
+
+	return res
+}
+
+// days converts d to a number of days rounded to the hundredth.
+func days(d time.Duration) *float64 {
+	n := math.Round(d.Hours()/24*100) / 100
+	return &n
+}
+
+// NewWebhookPayload maps delivery to the body posted to its webhook.
+func NewWebhookPayload(delivery *domain.WebhookDelivery) WebhookPayload {
+	return WebhookPayload{
+		ID:         delivery.ID,
+		Event:      string(delivery.Event),
+		OccurredAt: delivery.OccurredAt,
+		Book:       NewBook(delivery.Book),
+	}
+}
+
+// mapAll maps each of items with fn.
+func mapAll[T, U any](items []*T, fn func(*T) U) []U {
+	out := make([]U, len(items))
+	for i, item := range items {
+		out[i] = fn(item)
+	}
+	return out
+}
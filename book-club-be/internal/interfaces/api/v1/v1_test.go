@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/interfaces/problem"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// assertGolden compares the JSON encoding of v with testdata/name.golden,
+// which is rewritten instead when the tests run with -update.
+func assertGolden(t *testing.T, name string, v any) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("unable to encode %s: %v", name, err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		err = os.WriteFile(path, got, 0o644)
+		if err != nil {
+			t.Fatalf("unable to update %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read %s, run the tests with -update to create it: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s changed, run the tests with -update if it is intended\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+var (
+	createdAt = time.Date(2025, time.March, 14, 9, 30, 0, 0, time.UTC)
+	deletedAt = time.Date(2025, time.April, 2, 18, 0, 0, 0, time.UTC)
+)
+
+// dune returns a book with every field set.
+func dune() *domain.Book {
+	return &domain.Book{
+		ID:            "b6f1f0c2-7d1e-4c8a-9a57-3f1c4b1b2a10",
+		Title:         "Dune",
+		Author:        "Frank Herbert",
+		Authors:       []*domain.Author{{ID: "a-herbert", Name: "Frank Herbert"}},
+		Genre:         ptr("Science Fiction"),
+		Tags:          []*domain.Tag{{ID: "t-classics", Name: "Classics", ParentID: ptr("t-fiction")}},
+		PublishedYear: ptr(1965),
+		ISBN:          ptr("9780441172719"),
+		CoverURL:      ptr("/v1/books/b6f1f0c2-7d1e-4c8a-9a57-3f1c4b1b2a10/cover"),
+		PageCount:     ptr(617),
+		Description:   ptr("A desert planet."),
+		Status:        domain.BookStatusReading,
+		SuggestedBy:   ptr("m-ada"),
+		DeletedAt:     &deletedAt,
+	}
+}
+
+func TestNewBookGolden(t *testing.T) {
+	assertGolden(t, "book", NewBook(dune()))
+	assertGolden(t, "book_minimal", NewBook(&domain.Book{
+		ID: "c0ffee00-0000-4000-8000-000000000001", Title: "Emma", Author: "Jane Austen", Status: domain.BookStatusSuggested,
+	}))
+}
+
+func TestProblemGolden(t *testing.T) {
+	p := problem.New(http.StatusUnprocessableEntity, problem.CodeValidation, "the book is invalid")
+	p.Instance = "/v1/books"
+	p.Errors = []problem.FieldError{
+		{Field: "title", Code: string(domain.ValidationRequired), Message: "title cannot be empty"},
+		{
+			Field:   "published_year",
+			Code:    string(domain.ValidationFuture),
+			Message: "if specified, published_year cannot be in the future",
+		},
+	}
+
+	assertGolden(t, "problem", p)
+}
+
+func TestNewBatchReportGolden(t *testing.T) {
+	report := &domain.BatchReport{
+		Committed: false,
+		Results: []domain.BatchResult{
+			{Op: domain.BatchOpCreate, Book: dune()},
+			{Op: domain.BatchOpUpdate, Err: domain.ErrorNotFound},
+			{Op: domain.BatchOpDelete},
+		},
+	}
+	problemFor := func(err error) *problem.Problem {
+		if !errors.Is(err, domain.ErrorNotFound) {
+			t.Fatalf("problemFor(%v), want a not found error", err)
+		}
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, "book not found")
+	}
+
+	assertGolden(t, "batch_report", NewBatchReport(report, problemFor))
+}
+
+func TestNewReadingStatsGolden(t *testing.T) {
+	stats := &domain.ReadingStats{
+		Books: map[domain.BookStatus]int{
+			domain.BookStatusSuggested: 2,
+			domain.BookStatusReading:   1,
+			domain.BookStatusCompleted: 3,
+		},
+		CompletedByYear: []domain.PeriodCount{{Year: 2024, Count: 1}, {Year: 2025, Count: 2}},
+		CompletedByMonth: []domain.PeriodCount{
+			{Year: 2024, Month: time.November, Count: 1},
+			{Year: 2025, Month: time.February, Count: 2},
+		},
+		Reading: domain.ReadingTime{
+			Completed: 3,
+			Average:   50 * time.Hour,
+			Shortest:  26 * time.Hour,
+			Longest:   100 * time.Hour,
+		},
+		Genres: []domain.GenreCount{{Genre: "Science Fiction", Count: 4}, {Count: 2}},
+		Authors: []domain.AuthorCount{
+			{Author: &domain.Author{ID: "a-herbert", Name: "Frank Herbert"}, Count: 3},
+		},
+		Suggestions: domain.SuggestionStats{Pending: 2, Accepted: 2, Discarded: 1},
+		Members: []domain.MemberContribution{
+			{
+				Member:    &domain.Member{ID: "m-ada", Name: "Ada", Email: "ada@example.com", CreatedAt: createdAt},
+				Suggested: 3,
+				Accepted:  2,
+				Completed: 1,
+			},
+		},
+	}
+
+	assertGolden(t, "stats", NewReadingStats(stats))
+}
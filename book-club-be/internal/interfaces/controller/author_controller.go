@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// AuthorInteractor defines the application logic for managing authors.
+type AuthorInteractor interface {
+	// ListAuthors retrieves all authors.
+	ListAuthors(ctx context.Context) ([]*domain.Author, error)
+	// ListAuthorBooks retrieves the books of an author.
+	ListAuthorBooks(ctx context.Context, authorID string) ([]*domain.Book, error)
+	// MergeAuthors merges duplicate authors into a target author.
+	MergeAuthors(ctx context.Context, targetID string, sourceIDs []string) error
+}
+
+// MergeAuthorsRequest represents the payload for merging duplicate authors.
+type MergeAuthorsRequest struct {
+	AuthorIDs []string `json:"author_ids"`
+}
+
+// AuthorController implements [webservice.AuthorController] to handle
+// HTTP requests related to authors.
+type AuthorController struct {
+	interactor AuthorInteractor
+	logger     *slog.Logger
+}
+
+// NewAuthorController creates a new AuthorController with the given interactor and logger.
+func NewAuthorController(i AuthorInteractor, l *slog.Logger) *AuthorController {
+	return &AuthorController{
+		interactor: i,
+		logger:     l,
+	}
+}
+
+// List handles HTTP requests for retrieving all authors.
+func (a *AuthorController) List(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), a.logger)
+	authors, err := a.interactor.ListAuthors(r.Context())
+	if err != nil {
+		logger.With("error", err).Error("unable to list authors")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewAuthors(authors))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode authors")
+		return
+	}
+}
+
+// Books handles HTTP requests for retrieving the books of the author
+// identified by the id path value.
+func (a *AuthorController) Books(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), a.logger)
+	books, err := a.interactor.ListAuthorBooks(r.Context(), r.PathValue("id"))
+	if err != nil {
+		logger.With("error", err).Error("unable to list author books")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBooks(books))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode books")
+		return
+	}
+}
+
+// Merge handles HTTP requests for merging the authors listed in the request
+// body into the author identified by the id path value.
+func (a *AuthorController) Merge(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), a.logger)
+	var mar MergeAuthorsRequest
+	err := decodeJSON(w, r, &mar)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	err = a.interactor.MergeAuthors(r.Context(), r.PathValue("id"), mar.AuthorIDs)
+	if err != nil {
+		logger.With("error", err).Error("unable to merge authors")
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// BackupInteractor defines the application logic for backing up the database.
+type BackupInteractor interface {
+	// CreateBackup takes a new snapshot of the database.
+	CreateBackup(ctx context.Context) (*domain.Snapshot, error)
+	// ListBackups retrieves the stored snapshots.
+	ListBackups(ctx context.Context) ([]*domain.Snapshot, error)
+}
+
+// BackupController implements [webservice.BackupController] to handle
+// the administrative HTTP requests related to database backups.
+type BackupController struct {
+	interactor BackupInteractor
+	logger     *slog.Logger
+}
+
+// NewBackupController creates a new BackupController with the given interactor and logger.
+func NewBackupController(i BackupInteractor, l *slog.Logger) *BackupController {
+	return &BackupController{
+		interactor: i,
+		logger:     l,
+	}
+}
+
+// Create handles HTTP requests for taking a new backup on demand and
+// writes the created snapshot as JSON to the response.
+func (b *BackupController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), b.logger)
+	snapshot, err := b.interactor.CreateBackup(r.Context())
+	if err != nil {
+		logger.With("error", err).Error("unable to create backup")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(v1.NewSnapshot(snapshot))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode snapshot")
+		return
+	}
+}
+
+// List handles HTTP requests for retrieving the stored backups.
+func (b *BackupController) List(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), b.logger)
+	snapshots, err := b.interactor.ListBackups(r.Context())
+	if err != nil {
+		logger.With("error", err).Error("unable to list backups")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewSnapshots(snapshots))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode snapshots")
+		writeError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// Batch handles HTTP requests for applying a batch of create, update and
+// delete operations to books. It writes the outcome of each operation as
+// JSON to the response, with 200 OK when the batch was stored. An atomic
+// batch that is rolled back is answered with the status of the failed operation.
+func (b *BookController) Batch(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Batch")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	var bbr BatchBookRequest
+	err := decodeJSON(w, r, &bbr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	ops, err := bbr.toOperations()
+	if err != nil {
+		logger.With("error", err).Error("invalid batch")
+		writeError(w, r, err)
+		return
+	}
+
+	report, err := b.interactor.BatchBooks(ctx, ops, bbr.Atomic)
+	if err != nil {
+		logger.With("error", err).Error("unable to apply batch")
+		writeError(w, r, err)
+		return
+	}
+
+	res := v1.NewBatchReport(report, problemFor)
+	status := http.StatusOK
+	if !res.Committed && len(res.Results) > 0 {
+		status = res.Results[len(res.Results)-1].Status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(res)
+	if err != nil {
+		logger.With("error", err).Error("unable to encode batch report")
+		return
+	}
+}
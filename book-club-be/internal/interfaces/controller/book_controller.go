@@ -3,27 +3,46 @@ package controller
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
 
 	"github.com/Michela-DC/book-club/internal/domain"
-	"github.com/Michela-DC/book-club/internal/infrastructure/db"
-	"github.com/Michela-DC/book-club/pkg/utilities"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
 )
 
+var tracer = otel.Tracer("github.com/Michela-DC/book-club/internal/interfaces/controller")
+
 // BookInteractor defines the application logic for managing books.
 type BookInteractor interface {
 	// CreateBook creates a new book and persists it in the data store.
 	CreateBook(ctx context.Context, book *domain.Book) (*domain.Book, error)
 	// ReadBooks retrieves a Read of books that match the provided filters.
-	ReadBooks(filters *domain.BookFilters) ([]*domain.Book, error)
-	// UpdateBook updates the information of an existing book in the repository.
+	ReadBooks(ctx context.Context, filters *domain.BookFilters) ([]*domain.Book, error)
+	// ExportBooks calls fn for each book that matches the provided filters, grouped by status.
+	ExportBooks(ctx context.Context, filters *domain.BookFilters, fn func(*domain.Book) error) error
+	// UpdateBook applies the fields set in a patch to an existing book in the repository.
 	UpdateBook(ctx context.Context, book *domain.Book) (*domain.Book, error)
-	// DeleteBook removes a book from the repository by its unique ID.
+	// DeleteBook moves a book identified by its unique ID to the trash.
 	DeleteBook(ctx context.Context, id string) error
+	// RestoreBook moves a book identified by its unique ID out of the trash.
+	RestoreBook(ctx context.Context, id string) (*domain.Book, error)
+	// GetBookByISBN retrieves the book with the given normalized ISBN-13.
+	GetBookByISBN(ctx context.Context, isbn string) (*domain.Book, error)
+	// EnrichBook fills the missing fields of a book from its metadata.
+	EnrichBook(ctx context.Context, id string) (*domain.Book, error)
+	// GetBook retrieves the book with the given ID.
+	GetBook(ctx context.Context, id string) (*domain.Book, error)
+	// ReplaceBook creates or replaces the book with the client-chosen ID, reporting whether it was created.
+	ReplaceBook(ctx context.Context, book *domain.Book) (*domain.Book, bool, error)
+	// ImportBooks creates the books read from an external export, skipping duplicates.
+	ImportBooks(ctx context.Context, records []*domain.ImportRecord) (*domain.ImportReport, error)
+	// BatchBooks applies a batch of operations, all together or each on its own.
+	BatchBooks(ctx context.Context, ops []domain.BatchOperation, atomic bool) (*domain.BatchReport, error)
 }
 
 // BookController implements [webservice.CRUDController] to handle
@@ -42,124 +61,248 @@ func NewBookController(i BookInteractor, l *slog.Logger) *BookController {
 }
 
 // Create handles HTTP requests for creating a new book. It decodes
-// the request body, validates the input, creates the book via the
-// interactor and writes the created book as JSON to the response.
+// the request body, creates the book via the interactor, which validates
+// it, and writes the created book as JSON to the response, with its URL in
+// the Location header.
 func (b *BookController) Create(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx, span := tracer.Start(r.Context(), "BookController.Create")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
 	var cbr CreateBookRequest
-	err := json.NewDecoder(r.Body).Decode(&cbr)
+	err := decodeJSON(w, r, &cbr)
 	if err != nil {
-		b.logger.With("error", err).Error("unable to get request body")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
 		return
 	}
 
-	err = cbr.validate()
+	book, err := b.interactor.CreateBook(ctx, cbr.toBook())
 	if err != nil {
-		b.logger.With("error", err).Error("invalid request")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		logger.With("error", err).Error("unable to create book")
+		writeError(w, r, err)
 		return
 	}
 
-	status := domain.StringToBookStatusMap[cbr.Status]
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", bookURL(book.ID))
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode book")
+		writeError(w, r, err)
+		return
+	}
+}
+
+// ReadOne handles HTTP requests for retrieving the book identified in the request path.
+func (b *BookController) ReadOne(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.ReadOne")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	bookID := r.PathValue("id")
 
-	book, err := b.interactor.CreateBook(ctx, &domain.Book{
-		ID:            uuid.NewString(),
-		Title:         cbr.Title,
-		Author:        cbr.Author,
-		Genre:         cbr.Genre,
-		PublishedYear: cbr.Year,
-		Status:        status,
-	})
+	book, err := b.interactor.GetBook(ctx, bookID)
 	if err != nil {
-		b.logger.With("error", err).Error("unable to create book")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		logger.With("error", err, "id", bookID).Error("unable to get book")
+		writeError(w, r, err)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(book)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
 	if err != nil {
-		b.logger.With("error", err).Error("unable to encode book")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		logger.With("error", err).Error("unable to encode book")
+		writeError(w, r, err)
 		return
 	}
 }
 
-// Read handles HTTP requests for retrieving books.
-// Currently, it is not implemented and always returns 501 Not Implemented.
-func (*BookController) Read(w http.ResponseWriter, _ *http.Request) {
-	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+// Replace handles HTTP requests for storing a book under the client-chosen
+// ID in the request path, which must be a UUID. The book is created, with
+// 201 Created and its URL in the Location header, or replaced with the
+// fields in the request body. It writes the stored book as JSON to the response.
+func (b *BookController) Replace(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Replace")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	bookID := r.PathValue("id")
+	if _, err := uuid.Parse(bookID); err != nil {
+		logger.With("error", err, "id", bookID).Error("invalid book id")
+		writeBadRequest(w, r, "id must be a UUID")
+		return
+	}
+
+	var cbr CreateBookRequest
+	err := decodeJSON(w, r, &cbr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	book := cbr.toBook()
+	book.ID = bookID
+	book, created, err := b.interactor.ReplaceBook(ctx, book)
+	if err != nil {
+		logger.With("error", err, "id", bookID).Error("unable to replace book")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.Header().Set("Location", bookURL(book.ID))
+		w.WriteHeader(http.StatusCreated)
+	}
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode book")
+		writeError(w, r, err)
+		return
+	}
 }
 
-// Update handles HTTP requests for updating an existing book.
-// Currently, it is not implemented and always returns 501 Not Implemented.
-func (b *BookController) Update(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var ubr UpdateBookRequest
-	err := json.NewDecoder(r.Body).Decode(&ubr)
+// bookURL returns the URL of the book identified by id.
+func bookURL(id string) string {
+	return "/v1/books/" + url.PathEscape(id)
+}
+
+// Read handles HTTP requests for retrieving books. The books can be
+// filtered with the title, author, genre, year, status and tag query parameters.
+func (b *BookController) Read(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Read")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	filters, err := parseBookFilters(r.URL.Query())
 	if err != nil {
-		b.logger.With("error", err).Error("unable to get request body")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		logger.With("error", err).Error("invalid filters")
+		writeError(w, r, err)
 		return
 	}
 
-	err = ubr.validate()
+	books, err := b.interactor.ReadBooks(ctx, filters)
 	if err != nil {
-		b.logger.With("error", err).Error("invalid request")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		logger.With("error", err).Error("unable to read books")
+		writeError(w, r, err)
 		return
 	}
 
-	var status domain.BookStatus
-	if ubr.Status != nil {
-		var ok bool
-		status, ok = domain.StringToBookStatusMap[*ubr.Status]
-		if !ok {
-			b.logger.With("status", ubr.Status).Error("invalid book status")
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBooks(books))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode books")
+		writeError(w, r, err)
+		return
 	}
+}
 
-	book, err := b.interactor.UpdateBook(ctx, &domain.Book{
-		ID:            uuid.NewString(),
-		Title:         utilities.Optional(ubr.Title),
-		Author:        utilities.Optional(ubr.Author),
-		Genre:         ubr.Genre,
-		PublishedYear: ubr.Year,
-		Status:        status,
-	})
+// ReadByISBN handles HTTP requests for retrieving the book with the ISBN in
+// the request path, which can be given either as ISBN-10 or ISBN-13.
+func (b *BookController) ReadByISBN(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.ReadByISBN")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	isbn, err := domain.NormalizeISBN(r.PathValue("isbn"))
+	if err != nil {
+		logger.With("error", err).Error("invalid isbn")
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+
+	book, err := b.interactor.GetBookByISBN(ctx, isbn)
+	if err != nil {
+		logger.With("error", err, "isbn", isbn).Error("unable to get book")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode book")
+		writeError(w, r, err)
+		return
+	}
+}
+
+// Enrich handles HTTP requests for filling the missing fields of the book
+// identified in the request path with the information found by the metadata
+// provider. It writes the resulting book as JSON to the response.
+func (b *BookController) Enrich(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Enrich")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	bookID := r.PathValue("id")
+
+	book, err := b.interactor.EnrichBook(ctx, bookID)
 	if err != nil {
-		b.logger.With("error", err).Error("unable to update book")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		logger.With("error", err, "id", bookID).Error("unable to enrich book")
+		writeError(w, r, err)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(book)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
 	if err != nil {
-		b.logger.With("error", err).Error("unable to encode book")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		logger.With("error", err).Error("unable to encode book")
+		writeError(w, r, err)
 		return
 	}
 }
 
-// Delete handles HTTP requests for deleting a book by ID.
+// Update handles HTTP requests for updating the book identified in the
+// request path with the fields set in the request body. It writes the
+// updated book as JSON to the response.
+func (b *BookController) Update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Update")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	var ubr UpdateBookRequest
+	err := decodeJSON(w, r, &ubr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	book, err := b.interactor.UpdateBook(ctx, ubr.toBook(r.PathValue("id")))
+	if err != nil {
+		logger.With("error", err).Error("unable to update book")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode book")
+		writeError(w, r, err)
+		return
+	}
+}
+
+// Delete handles HTTP requests for deleting a book by ID, which moves it to the trash.
 func (b *BookController) Delete(w http.ResponseWriter, r *http.Request) {
-	// TODO: handle not found
-	ctx := r.Context()
+	ctx, span := tracer.Start(r.Context(), "BookController.Delete")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
 	bookID := r.PathValue("id")
 
 	err := b.interactor.DeleteBook(ctx, bookID)
 
 	if err != nil {
-		b.logger.With("error", err).Error("unable to delete book")
-		if errors.Is(err, db.ErrorNotFound) {
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-			return
-		}
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		logger.With("error", err).Error("unable to delete book")
+		writeError(w, r, err)
 		return
 	}
 
-	b.logger.With("id", bookID).Info("book deleted")
+	logger.With("id", bookID).Info("book moved to trash")
 }
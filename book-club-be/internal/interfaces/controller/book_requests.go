@@ -1,77 +1,215 @@
 package controller
 
 import (
-	"errors"
 	"fmt"
-	"time"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/utilities"
 )
 
 // CreateBookRequest represents the payload required to create a new book.
 // It is typically decoded from the JSON body of an HTTP request.
 type CreateBookRequest struct {
-	Genre  *string `json:"genre"`
-	Year   *int    `json:"year"`
-	Title  string  `json:"title"`
-	Author string  `json:"author"`
-	Status string  `json:"status"`
+	Genre         *string  `json:"genre"`
+	PublishedYear *int     `json:"published_year"`
+	ISBN          *string  `json:"isbn"`
+	Title         string   `json:"title"`
+	Author        string   `json:"author"`
+	Status        string   `json:"status"`
+	Tags          []string `json:"tags"`
+	SuggestedBy   *string  `json:"suggested_by"`
 }
 
 // UpdateBookRequest represents the payload required to update a book.
+// Tags are left unchanged when omitted.
 type UpdateBookRequest struct {
-	Title  *string `json:"title"`
-	Author *string `json:"author"`
-	Status *string `json:"status"`
-	Genre  *string `json:"genre"`
-	Year   *int    `json:"year"`
+	Title         *string  `json:"title"`
+	Author        *string  `json:"author"`
+	Status        *string  `json:"status"`
+	Genre         *string  `json:"genre"`
+	PublishedYear *int     `json:"published_year"`
+	ISBN          *string  `json:"isbn"`
+	Tags          []string `json:"tags"`
+	SuggestedBy   *string  `json:"suggested_by"`
 }
 
-// validate checks the fields of CreateBookRequest for correctness.
-// It returns an error if any required field is invalid.
-func (r *CreateBookRequest) validate() error {
-	_, isValidStatus := domain.StringToBookStatusMap[r.Status]
-
-	switch {
-	case r.Title == "":
-		return errors.New("title cannot be empty")
-	case r.Author == "":
-		return errors.New("author cannot be empty")
-	case r.Genre != nil && *r.Genre == "":
-		return errors.New("if specified, genre cannot be empty")
-	case !isValidStatus:
-		validStatuses := make([]string, 0, len(domain.StringToBookStatusMap))
-		for k := range domain.StringToBookStatusMap {
-			validStatuses = append(validStatuses, k)
+// maxBatchOperations is the maximum number of operations in a batch.
+const maxBatchOperations = 100
+
+// BatchBookRequest represents the payload of a batch of book operations.
+// When Atomic is set, the operations are applied all together or not at all.
+type BatchBookRequest struct {
+	Atomic     bool                    `json:"atomic"`
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// BatchOperationRequest represents a single operation of a batch. A "create"
+// takes the fields of a [CreateBookRequest] in Book, an "update" takes the ID
+// of the book and the fields of an [UpdateBookRequest], and a "delete" takes
+// the ID alone.
+type BatchOperationRequest struct {
+	Op   string             `json:"op"`
+	ID   string             `json:"id"`
+	Book *UpdateBookRequest `json:"book"`
+}
+
+// toOperations converts the request into the operations to apply. It returns
+// a [domain.ValidationError] listing the malformed operations, while the
+// books are validated by the interactor.
+func (r *BatchBookRequest) toOperations() ([]domain.BatchOperation, error) {
+	var v domain.ValidationError
+	if len(r.Operations) == 0 {
+		v.Add("operations", domain.ValidationEmpty, "operations cannot be empty")
+	}
+	if len(r.Operations) > maxBatchOperations {
+		v.Add("operations", domain.ValidationInvalid,
+			fmt.Sprintf("a batch cannot have more than %d operations", maxBatchOperations))
+	}
+
+	ops := make([]domain.BatchOperation, len(r.Operations))
+	for i, o := range r.Operations {
+		field := fmt.Sprintf("operations[%d]", i)
+		op := domain.BatchOp(o.Op)
+		switch op {
+		case domain.BatchOpCreate:
+			if o.ID != "" {
+				v.Add(field+".id", domain.ValidationInvalid, "id cannot be set when creating a book")
+			}
+		case domain.BatchOpUpdate, domain.BatchOpDelete:
+			if o.ID == "" {
+				v.Add(field+".id", domain.ValidationRequired, "id is required")
+			}
+		default:
+			v.Add(field+".op", domain.ValidationInvalid, fmt.Sprintf("op must be create, update or delete, got %q", o.Op))
+			continue
+		}
+
+		switch {
+		case op == domain.BatchOpDelete && o.Book != nil:
+			v.Add(field+".book", domain.ValidationInvalid, "book cannot be set when deleting a book")
+		case op != domain.BatchOpDelete && o.Book == nil:
+			v.Add(field+".book", domain.ValidationRequired, "book is required")
 		}
-		return fmt.Errorf("status must be one of %v", validStatuses)
-	case r.Year != nil && *r.Year > time.Now().Year():
-		return errors.New("if specified, year cannot be in the future")
+
+		ops[i] = domain.BatchOperation{Op: op, Book: &domain.Book{ID: o.ID}}
+		if o.Book != nil {
+			ops[i].Book = o.Book.toBook(o.ID)
+		}
+	}
+
+	err := v.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
+// toBook converts the request into the book to create. The book is
+// validated by the interactor.
+func (r *CreateBookRequest) toBook() *domain.Book {
+	return &domain.Book{
+		Title:         r.Title,
+		Author:        r.Author,
+		Genre:         r.Genre,
+		PublishedYear: r.PublishedYear,
+		ISBN:          r.ISBN,
+		Status:        domain.BookStatus(r.Status),
+		Tags:          tagsByName(r.Tags),
+		SuggestedBy:   r.SuggestedBy,
 	}
+}
 
-	return nil
+// toBook converts the request into the patch of the book identified by id.
+// The patched book is validated by the interactor.
+func (r *UpdateBookRequest) toBook(id string) *domain.Book {
+	return &domain.Book{
+		ID:            id,
+		Title:         utilities.Optional(r.Title),
+		Author:        utilities.Optional(r.Author),
+		Genre:         r.Genre,
+		PublishedYear: r.PublishedYear,
+		ISBN:          r.ISBN,
+		Status:        domain.BookStatus(utilities.Optional(r.Status)),
+		Tags:          tagsByName(r.Tags),
+		SuggestedBy:   r.SuggestedBy,
+	}
 }
 
-// validate checks the fields of UpdateBookRequest for correctness.
-func (r *UpdateBookRequest) validate() error {
-	_, isValidStatus := domain.StringToBookStatusMap[*r.Status]
-
-	switch {
-	case r.Title != nil && *r.Title == "":
-		return errors.New("if specified, title cannot be empty")
-	case r.Author != nil && *r.Author == "":
-		return errors.New("if specified, author cannot be empty")
-	case r.Genre != nil && *r.Genre == "":
-		return errors.New("if specified, genre cannot be empty")
-	case !isValidStatus:
-		validStatuses := make([]string, 0, len(domain.StringToBookStatusMap))
-		for k := range domain.StringToBookStatusMap {
-			validStatuses = append(validStatuses, k)
+// tagsByName returns the tags with the given names, to be resolved against
+// the vocabulary by the interactor, or nil if names is nil.
+func tagsByName(names []string) []*domain.Tag {
+	if names == nil {
+		return nil
+	}
+
+	tags := make([]*domain.Tag, len(names))
+	for i, name := range names {
+		tags[i] = &domain.Tag{Name: name}
+	}
+
+	return tags
+}
+
+// parseBookFilters reads the book filters from the query parameters of a
+// listing request. It returns a [ValidationError] listing all the invalid parameters.
+func parseBookFilters(q url.Values) (*domain.BookFilters, error) {
+	var (
+		filters domain.BookFilters
+		v       domain.ValidationError
+	)
+	optional := func(key string) *string {
+		if !q.Has(key) {
+			return nil
+		}
+		v := q.Get(key)
+		return &v
+	}
+
+	filters.Title = optional("title")
+	filters.Author = optional("author")
+	filters.Genre = optional("genre")
+
+	// Every tag parameter must match, while any of its comma separated tags can.
+	for _, group := range q["tag"] {
+		names := make([]string, 0)
+		for _, name := range strings.Split(group, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
 		}
-		return fmt.Errorf("status must be one of %v", validStatuses)
-	case r.Year != nil && *r.Year > time.Now().Year():
-		return errors.New("if specified, year cannot be in the future")
+		if len(names) == 0 {
+			v.Add("tag", domain.ValidationEmpty, "tag cannot be empty")
+			continue
+		}
+		filters.Tags = append(filters.Tags, names)
+	}
+
+	if y := optional("year"); y != nil {
+		year, err := strconv.Atoi(*y)
+		if err != nil {
+			v.Add("year", domain.ValidationInvalid, "year must be a number")
+		} else {
+			filters.PublishedYear = &year
+		}
+	}
+
+	if s := optional("status"); s != nil {
+		status, ok := domain.StringToBookStatusMap[*s]
+		if !ok {
+			v.Add("status", domain.ValidationInvalid, fmt.Sprintf("unknown status %q", *s))
+		} else {
+			filters.Status = &status
+		}
+	}
+
+	err := v.Err()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &filters, nil
 }
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// maxCoverSize is the maximum size of an uploaded cover image.
+const maxCoverSize = 5 << 20
+
+// CoverInteractor defines the application logic for book cover images.
+type CoverInteractor interface {
+	// SetCover stores the image read from r as the cover of a book.
+	SetCover(ctx context.Context, bookID string, r io.Reader) (*domain.Book, error)
+	// GetCover opens the cover of a book in the given size.
+	GetCover(ctx context.Context, bookID, size string) (io.ReadSeekCloser, *domain.BlobInfo, error)
+}
+
+// CoverController implements [webservice.CoverController] to handle
+// HTTP requests related to book cover images.
+type CoverController struct {
+	interactor CoverInteractor
+	logger     *slog.Logger
+}
+
+// NewCoverController creates a new CoverController with the given interactor and logger.
+func NewCoverController(i CoverInteractor, l *slog.Logger) *CoverController {
+	return &CoverController{
+		interactor: i,
+		logger:     l,
+	}
+}
+
+// Upload handles HTTP requests for setting the cover of the book identified
+// in the request path, uploaded as the "cover" field of a multipart form.
+// It writes the updated book as JSON to the response. The upload may take
+// up to uploadTimeout.
+func (c *CoverController) Upload(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	bookID := r.PathValue("id")
+	extendDeadlines(w, logger)
+	r.Body = http.MaxBytesReader(w, r.Body, maxCoverSize)
+
+	file, _, err := r.FormFile("cover")
+	if err != nil {
+		logger.With("error", err).Error("unable to get uploaded cover")
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, r, err)
+			return
+		}
+		writeBadRequest(w, r, "an image must be uploaded in the cover field")
+		return
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			logger.With("error", err).Error("unable to close uploaded cover")
+		}
+	}()
+
+	book, err := c.interactor.SetCover(r.Context(), bookID, file)
+	if err != nil {
+		logger.With("error", err, "id", bookID).Error("unable to set cover")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode book")
+		return
+	}
+}
+
+// Read handles HTTP requests for the cover of the book identified in the
+// request path. The size query parameter selects a thumbnail (small, medium
+// or large) instead of the original image. Covers are served with caching
+// headers, and with a long-lived cache when the URL carries the version
+// parameter set by Upload.
+func (c *CoverController) Read(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	bookID := r.PathValue("id")
+	size := r.URL.Query().Get("size")
+	if size == "" {
+		size = domain.CoverSizeOriginal
+	}
+	if _, ok := domain.CoverSizes[size]; !ok && size != domain.CoverSizeOriginal {
+		writeBadRequest(w, r, "size must be one of small, medium, large or original")
+		return
+	}
+
+	cover, info, err := c.interactor.GetCover(r.Context(), bookID, size)
+	if err != nil {
+		logger.With("error", err, "id", bookID, "size", size).Error("unable to get cover")
+		writeError(w, r, err)
+		return
+	}
+	defer func() {
+		err := cover.Close()
+		if err != nil {
+			logger.With("error", err).Error("unable to close cover")
+		}
+	}()
+
+	if r.URL.Query().Has("v") {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+	}
+	w.Header().Set("ETag", `"`+strconv.FormatInt(info.ModTime.UnixNano(), 36)+"-"+strconv.FormatInt(info.Size, 36)+`"`)
+	if size != domain.CoverSizeOriginal {
+		w.Header().Set("Content-Type", "image/jpeg")
+	}
+
+	// ServeContent sniffs the type of the original and answers conditional and range requests.
+	http.ServeContent(w, r, "", info.ModTime, cover)
+}
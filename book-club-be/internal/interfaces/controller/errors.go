@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/interfaces/importer"
+	"github.com/Michela-DC/book-club/internal/interfaces/problem"
+)
+
+// errorMalformedBody is returned when a request body is not valid JSON or
+// does not match the expected payload.
+var errorMalformedBody = errors.New("malformed request body")
+
+// errorUnsupportedMediaType is returned when a request body is not declared as JSON.
+var errorUnsupportedMediaType = errors.New("the request body must be application/json")
+
+// maxJSONBodySize is the maximum size of a JSON request body.
+const maxJSONBodySize = 1 << 20
+
+// unknownFieldPrefix prefixes the error returned by [json.Decoder] for a
+// field that is not part of the payload.
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeJSON decodes the JSON body of r into v. The body must be declared as
+// application/json, hold a single JSON value with only the fields of v and
+// not exceed maxJSONBodySize. It returns errorUnsupportedMediaType, an
+// [http.MaxBytesError] or an error wrapping errorMalformedBody otherwise.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return errorUnsupportedMediaType
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodySize))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(v)
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: the request body cannot be empty", errorMalformedBody)
+	}
+	if err != nil {
+		if quoted, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix); ok {
+			field, uerr := strconv.Unquote(quoted)
+			if uerr != nil {
+				field = quoted
+			}
+			return &unknownFieldError{field: field}
+		}
+		return fmt.Errorf("%w: %w", errorMalformedBody, err)
+	}
+
+	err = dec.Decode(&json.RawMessage{})
+	if !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return fmt.Errorf("%w: unexpected data after the JSON value", errorMalformedBody)
+	}
+
+	return nil
+}
+
+// unknownFieldError is returned when a request body has a field that is not
+// part of the expected payload. It wraps errorMalformedBody.
+type unknownFieldError struct {
+	field string
+}
+
+func (e *unknownFieldError) Error() string {
+	return fmt.Sprintf("%s: unknown field %q", errorMalformedBody, e.field)
+}
+
+func (e *unknownFieldError) Unwrap() error {
+	return errorMalformedBody
+}
+
+// writeError writes the problem details matching err as the response to r.
+// Errors that are not known to the API are reported as internal errors,
+// without details.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var dupErr *domain.DuplicateISBNError
+	if errors.As(err, &dupErr) {
+		w.Header().Set("Link", `</v1/books/`+dupErr.ExistingID+`>; rel="duplicate"`)
+	}
+
+	problem.Write(w, r, problemFor(err))
+}
+
+// problemFor returns the problem details matching err. Errors that are not
+// known to the API are reported as internal errors, without details.
+func problemFor(err error) *problem.Problem {
+	var (
+		validationErr *domain.ValidationError
+		dupErr        *domain.DuplicateISBNError
+		typeErr       *json.UnmarshalTypeError
+		maxBytesErr   *http.MaxBytesError
+		fieldErr      *unknownFieldError
+		csvErr        *csv.ParseError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		p := problem.New(http.StatusBadRequest, problem.CodeValidation, "the request has invalid fields")
+		for _, f := range validationErr.Fields {
+			p.Errors = append(p.Errors, problem.FieldError{Field: f.Field, Code: string(f.Code), Message: f.Message})
+		}
+		return p
+	case errors.As(err, &maxBytesErr):
+		return problem.New(http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge,
+			fmt.Sprintf("the request body must not exceed %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, errorMalformedBody):
+		p := problem.New(http.StatusBadRequest, problem.CodeMalformedBody, err.Error())
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			p.Errors = []problem.FieldError{{
+				Field:   typeErr.Field,
+				Code:    "invalid_type",
+				Message: fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type),
+			}}
+		}
+		if errors.As(err, &fieldErr) {
+			p.Errors = []problem.FieldError{{
+				Field:   fieldErr.field,
+				Code:    "unknown",
+				Message: fmt.Sprintf("unknown field %q", fieldErr.field),
+			}}
+		}
+		return p
+	case errors.Is(err, importer.ErrorUnknownFormat):
+		p := problem.New(http.StatusBadRequest, problem.CodeMalformedBody, "the uploaded file is not a supported export")
+		p.Errors = []problem.FieldError{{Field: "file", Code: "unknown_format", Message: err.Error()}}
+		return p
+	case errors.As(err, &csvErr):
+		p := problem.New(http.StatusBadRequest, problem.CodeMalformedBody, "the uploaded file is not a valid CSV file")
+		p.Errors = []problem.FieldError{{
+			Field:   "file",
+			Code:    "invalid_csv",
+			Message: fmt.Sprintf("line %d, column %d: %v", csvErr.Line, csvErr.Column, csvErr.Err),
+		}}
+		return p
+	case errors.Is(err, errorUnsupportedMediaType):
+		return problem.New(http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia, err.Error())
+	case errors.Is(err, domain.ErrorNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, "")
+	case errors.Is(err, domain.ErrorForbidden):
+		return problem.New(http.StatusForbidden, problem.CodeForbidden, err.Error())
+	case errors.As(err, &dupErr):
+		return problem.New(http.StatusConflict, problem.CodeDuplicateISBN, dupErr.Error())
+	case errors.Is(err, domain.ErrorDuplicateTag):
+		return problem.New(http.StatusConflict, problem.CodeDuplicateTag, err.Error())
+	case errors.Is(err, domain.ErrorDuplicateEmail):
+		return problem.New(http.StatusConflict, problem.CodeDuplicateEmail, err.Error())
+	case errors.Is(err, domain.ErrorUnsupportedImage):
+		return problem.New(http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia, err.Error())
+	case errors.Is(err, domain.ErrorImageTooLarge):
+		return problem.New(http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge, err.Error())
+	default:
+		return problem.New(http.StatusInternalServerError, problem.CodeInternal, "")
+	}
+}
+
+// writeBadRequest writes a 400 Bad Request problem with the given detail.
+func writeBadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeBadRequest, detail))
+}
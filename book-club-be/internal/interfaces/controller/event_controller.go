@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// heartbeatInterval is the time between the comments written to an idle
+// event stream, so that proxies do not close it.
+const heartbeatInterval = 20 * time.Second
+
+// reconnectDelay is the time browsers wait before reconnecting to a closed event stream.
+const reconnectDelay = 3 * time.Second
+
+// EventSubscriber defines the subscription to the changes to books.
+type EventSubscriber interface {
+	// Subscribe returns a channel receiving the events published after the
+	// one with ID lastID, if not nil, or from now on otherwise. The channel
+	// is closed when ctx is canceled or the subscriber falls behind.
+	Subscribe(ctx context.Context, lastID *int64) <-chan *domain.Event
+}
+
+// EventController implements [webservice.EventController] to stream the
+// changes to books as Server-Sent Events.
+type EventController struct {
+	events EventSubscriber
+	logger *slog.Logger
+}
+
+// NewEventController creates a new EventController with the given subscriber and logger.
+func NewEventController(events EventSubscriber, l *slog.Logger) *EventController {
+	return &EventController{
+		events: events,
+		logger: l,
+	}
+}
+
+// Stream handles HTTP requests for following the changes to books. Events
+// are written as Server-Sent Events, with their ID, until the client goes
+// away. The events following the one in the Last-Event-ID header, or in the
+// last_event_id query parameter, are sent first.
+func (e *EventController) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logctx.FromContext(ctx, e.logger)
+
+	lastID, err := parseLastEventID(r)
+	if err != nil {
+		logger.With("error", err).Error("invalid last event id")
+		writeBadRequest(w, r, err.Error())
+		return
+	}
+
+	// The stream outlives the write timeout of the server.
+	rc := http.NewResponseController(w)
+	err = rc.SetWriteDeadline(time.Time{})
+	if err != nil {
+		logger.With("error", err).Warn("unable to disable write deadline")
+	}
+
+	events := e.events.Subscribe(ctx, lastID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, err = fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+	if err == nil {
+		err = rc.Flush()
+	}
+	if err != nil {
+		logger.With("error", err).Error("unable to start event stream")
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, event)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			logger.With("error", err).Info("event stream closed")
+			return
+		}
+	}
+}
+
+// writeEvent writes event in the Server-Sent Events format.
+func writeEvent(w http.ResponseWriter, event *domain.Event) error {
+	data, err := json.Marshal(v1.NewEvent(event))
+	if err != nil {
+		return err
+	}
+
+	if event.ID > 0 {
+		_, err = fmt.Fprintf(w, "id: %d\n", event.ID)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// parseLastEventID reads the ID of the last event received by the client,
+// or returns nil if the client did not send one.
+func parseLastEventID(r *http.Request) (*int64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return nil, fmt.Errorf("invalid last event id %q", v)
+	}
+
+	return &id, nil
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/interfaces/exporter"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// Export handles HTTP requests for exporting the books in the format chosen
+// with the format query parameter: csv, jsonl or md. The books can be
+// filtered with the same query parameters accepted by Read. The export is
+// streamed: the status is only sent with the first chunk written, so that a
+// failure before it, such as one fetching the first book, is answered with a
+// problem, while the errors occurring afterwards can only be logged. It may
+// outlast the write timeout of the server: the deadline is extended by
+// exportWriteTimeout for every chunk written.
+func (b *BookController) Export(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Export")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	q := r.URL.Query()
+
+	format, ok := exporter.StringToFormatMap[q.Get("format")]
+	if !ok {
+		logger.With("format", q.Get("format")).Error("invalid export format")
+		writeBadRequest(w, r, "format must be one of csv, jsonl or md")
+		return
+	}
+	q.Del("format")
+
+	filters, err := parseBookFilters(q)
+	if err != nil {
+		logger.With("error", err).Error("invalid filters")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="books.`+string(format)+`"`)
+
+	out := &deadlineWriter{
+		w:       w,
+		rc:      http.NewResponseController(w),
+		timeout: exportWriteTimeout,
+	}
+	enc, err := exporter.NewEncoder(format, out)
+	if err != nil {
+		logger.With("error", err).Error("unable to create encoder")
+		writeError(w, r, err)
+		return
+	}
+
+	err = b.interactor.ExportBooks(ctx, filters, enc.Encode)
+	if err != nil {
+		logger.With("error", err).Error("unable to export books")
+		if !out.written {
+			w.Header().Del("Content-Disposition")
+			writeError(w, r, err)
+		}
+		return
+	}
+
+	err = enc.Close()
+	if err != nil {
+		logger.With("error", err).Error("unable to flush export")
+		return
+	}
+}
+
+// exportWriteTimeout is the time allowed to write each chunk of an export.
+const exportWriteTimeout = 10 * time.Second
+
+// deadlineWriter writes to a response, extending its write deadline by
+// timeout before every write, so that a long response is not cut off while
+// a stalled client still is.
+type deadlineWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+	// written reports whether the response, and so its status, was written.
+	written bool
+}
+
+// Write extends the write deadline and writes b to the response.
+func (d *deadlineWriter) Write(b []byte) (int, error) {
+	err := d.rc.SetWriteDeadline(time.Now().Add(d.timeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, err
+	}
+
+	d.written = true
+	return d.w.Write(b)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/internal/interfaces/importer"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// maxImportSize is the maximum size of an uploaded library export.
+const maxImportSize = 10 << 20
+
+// uploadTimeout is the time allowed to read an uploaded file and write the
+// response, which the timeouts of the server are too short for.
+const uploadTimeout = time.Minute
+
+// extendDeadlines allows the body of the request served with w to be read,
+// and the response to be written, for uploadTimeout from now. The write
+// deadline of the server starts when the request is read, so it would
+// otherwise expire while a slow upload is still arriving.
+func extendDeadlines(w http.ResponseWriter, logger *slog.Logger) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(uploadTimeout)
+	err := rc.SetReadDeadline(deadline)
+	if err == nil {
+		err = rc.SetWriteDeadline(deadline)
+	}
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger.With("error", err).Warn("unable to extend deadlines")
+	}
+}
+
+// Import handles HTTP requests for importing books from a Goodreads or
+// StoryGraph CSV export, uploaded as the "file" field of a multipart form.
+// It writes the import report as JSON to the response. The upload may take
+// up to uploadTimeout.
+func (b *BookController) Import(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Import")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	extendDeadlines(w, logger)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		logger.With("error", err).Error("unable to get uploaded file")
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, r, err)
+			return
+		}
+		writeBadRequest(w, r, "a CSV export must be uploaded in the file field")
+		return
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			logger.With("error", err).Error("unable to close uploaded file")
+		}
+	}()
+
+	records, err := importer.ParseCSV(file)
+	if err != nil {
+		logger.With("error", err).Error("unable to parse export")
+		writeError(w, r, err)
+		return
+	}
+
+	report, err := b.interactor.ImportBooks(ctx, records)
+	if err != nil {
+		logger.With("error", err).Error("unable to import books")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewImportReport(report))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode import report")
+		return
+	}
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// MemberInteractor defines the application logic for managing the members of the club.
+type MemberInteractor interface {
+	// CreateMember validates and stores a new member.
+	CreateMember(ctx context.Context, member *domain.Member) (*domain.Member, error)
+	// ListMembers retrieves all the members.
+	ListMembers(ctx context.Context) ([]*domain.Member, error)
+	// UpdateMember applies a patch to a member.
+	UpdateMember(ctx context.Context, id string, patch *domain.MemberPatch) (*domain.Member, error)
+	// DeleteMember removes a member.
+	DeleteMember(ctx context.Context, id string) error
+}
+
+// NotificationPreferencesRequest represents the emails a member wants to
+// receive. Omitted fields default to true when a member is created and are
+// left unchanged when a member is updated.
+type NotificationPreferencesRequest struct {
+	Suggestions *bool `json:"suggestions"`
+	Decisions   *bool `json:"decisions"`
+	Digest      *bool `json:"digest"`
+}
+
+// CreateMemberRequest represents the payload required to add a member.
+type CreateMemberRequest struct {
+	Name          string                          `json:"name"`
+	Email         string                          `json:"email"`
+	Notifications *NotificationPreferencesRequest `json:"notifications"`
+}
+
+// UpdateMemberRequest represents the payload for changing a member: omitted
+// fields are left unchanged.
+type UpdateMemberRequest struct {
+	Name          *string                         `json:"name"`
+	Email         *string                         `json:"email"`
+	Notifications *NotificationPreferencesRequest `json:"notifications"`
+}
+
+// MemberController implements [webservice.MemberController] to handle HTTP
+// requests related to the members of the club.
+type MemberController struct {
+	interactor MemberInteractor
+	logger     *slog.Logger
+}
+
+// NewMemberController creates a new MemberController with the given interactor and logger.
+func NewMemberController(i MemberInteractor, l *slog.Logger) *MemberController {
+	return &MemberController{
+		interactor: i,
+		logger:     l,
+	}
+}
+
+// Create handles HTTP requests for adding a member and writes the created
+// member as JSON to the response.
+func (c *MemberController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	var mr CreateMemberRequest
+	err := decodeJSON(w, r, &mr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	member := &domain.Member{
+		Name:          mr.Name,
+		Email:         mr.Email,
+		Notifications: domain.NotificationPreferences{Suggestions: true, Decisions: true, Digest: true},
+	}
+	if mr.Notifications != nil {
+		mr.Notifications.patch().Apply(member)
+	}
+
+	member, err = c.interactor.CreateMember(r.Context(), member)
+	if err != nil {
+		logger.With("error", err).Error("unable to create member")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/v1/admin/members/"+member.ID)
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(v1.NewMember(member))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode member")
+		return
+	}
+}
+
+// List handles HTTP requests for retrieving all the members.
+func (c *MemberController) List(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	members, err := c.interactor.ListMembers(r.Context())
+	if err != nil {
+		logger.With("error", err).Error("unable to list members")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewMembers(members))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode members")
+		return
+	}
+}
+
+// Update handles HTTP requests for changing the member identified by the id
+// path value, including their notification preferences.
+func (c *MemberController) Update(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	var mr UpdateMemberRequest
+	err := decodeJSON(w, r, &mr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	patch := &domain.MemberPatch{}
+	if mr.Notifications != nil {
+		patch = mr.Notifications.patch()
+	}
+	patch.Name = mr.Name
+	patch.Email = mr.Email
+
+	member, err := c.interactor.UpdateMember(r.Context(), r.PathValue("id"), patch)
+	if err != nil {
+		logger.With("error", err).Error("unable to update member")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewMember(member))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode member")
+		return
+	}
+}
+
+// Delete handles HTTP requests for removing the member identified by the id
+// path value. The emails not yet sent to them are discarded.
+func (c *MemberController) Delete(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	err := c.interactor.DeleteMember(r.Context(), r.PathValue("id"))
+	if err != nil {
+		logger.With("error", err).Error("unable to delete member")
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// patch returns the changes to the notification preferences of a member.
+func (p *NotificationPreferencesRequest) patch() *domain.MemberPatch {
+	return &domain.MemberPatch{
+		Suggestions: p.Suggestions,
+		Decisions:   p.Decisions,
+		Digest:      p.Digest,
+	}
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// StatsInteractor defines the application logic for reporting the reading statistics.
+type StatsInteractor interface {
+	// ReadingStats computes the reading statistics of the club.
+	ReadingStats(ctx context.Context) (*domain.ReadingStats, error)
+}
+
+// StatsController implements [webservice.StatsController] to handle HTTP
+// requests for the reading statistics.
+type StatsController struct {
+	interactor StatsInteractor
+	logger     *slog.Logger
+}
+
+// NewStatsController creates a new StatsController with the given interactor and logger.
+func NewStatsController(i StatsInteractor, l *slog.Logger) *StatsController {
+	return &StatsController{
+		interactor: i,
+		logger:     l,
+	}
+}
+
+// Get handles HTTP requests for the reading statistics of the club and
+// writes them as JSON to the response.
+func (c *StatsController) Get(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	stats, err := c.interactor.ReadingStats(r.Context())
+	if err != nil {
+		logger.With("error", err).Error("unable to compute reading stats")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewReadingStats(stats))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode reading stats")
+		return
+	}
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// TagInteractor defines the application logic for managing the tag vocabulary.
+type TagInteractor interface {
+	// ListTags retrieves all tags.
+	ListTags(ctx context.Context) ([]*domain.Tag, error)
+	// CreateTag adds a new tag to the vocabulary.
+	CreateTag(ctx context.Context, tag *domain.Tag) (*domain.Tag, error)
+	// UpdateTag renames or moves an existing tag.
+	UpdateTag(ctx context.Context, tag *domain.Tag) (*domain.Tag, error)
+	// MergeTags merges duplicate tags into a target tag.
+	MergeTags(ctx context.Context, targetID string, sourceIDs []string) error
+}
+
+// TagRequest represents the payload required to create, rename or move a tag.
+// A tag without parent is a top level tag.
+type TagRequest struct {
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+// MergeTagsRequest represents the payload for merging duplicate tags.
+type MergeTagsRequest struct {
+	TagIDs []string `json:"tag_ids"`
+}
+
+// TagController implements [webservice.TagController] to handle
+// HTTP requests related to the tag vocabulary.
+type TagController struct {
+	interactor TagInteractor
+	logger     *slog.Logger
+}
+
+// NewTagController creates a new TagController with the given interactor and logger.
+func NewTagController(i TagInteractor, l *slog.Logger) *TagController {
+	return &TagController{
+		interactor: i,
+		logger:     l,
+	}
+}
+
+// List handles HTTP requests for retrieving all tags.
+func (t *TagController) List(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), t.logger)
+	tags, err := t.interactor.ListTags(r.Context())
+	if err != nil {
+		logger.With("error", err).Error("unable to list tags")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewTags(tags))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode tags")
+		return
+	}
+}
+
+// Create handles HTTP requests for adding a tag to the vocabulary and
+// writes the created tag as JSON to the response.
+func (t *TagController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), t.logger)
+	var tr TagRequest
+	err := decodeJSON(w, r, &tr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	tag, err := t.interactor.CreateTag(r.Context(), &domain.Tag{Name: tr.Name, ParentID: tr.ParentID})
+	if err != nil {
+		logger.With("error", err).Error("unable to create tag")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(v1.NewTag(tag))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode tag")
+		return
+	}
+}
+
+// Update handles HTTP requests for renaming or moving the tag identified by
+// the id path value. The books tagged with it keep the tag.
+func (t *TagController) Update(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), t.logger)
+	var tr TagRequest
+	err := decodeJSON(w, r, &tr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	tag, err := t.interactor.UpdateTag(r.Context(), &domain.Tag{
+		ID:       r.PathValue("id"),
+		Name:     tr.Name,
+		ParentID: tr.ParentID,
+	})
+	if err != nil {
+		logger.With("error", err).Error("unable to update tag")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewTag(tag))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode tag")
+		return
+	}
+}
+
+// Merge handles HTTP requests for merging the tags listed in the request
+// body into the tag identified by the id path value.
+func (t *TagController) Merge(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), t.logger)
+	var mtr MergeTagsRequest
+	err := decodeJSON(w, r, &mtr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	err = t.interactor.MergeTags(r.Context(), r.PathValue("id"), mtr.TagIDs)
+	if err != nil {
+		logger.With("error", err).Error("unable to merge tags")
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
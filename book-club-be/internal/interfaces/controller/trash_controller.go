@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// Trash handles HTTP requests for retrieving the books in the trash. The
+// books can be filtered with the same query parameters as [BookController.Read].
+func (b *BookController) Trash(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Trash")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	filters, err := parseBookFilters(r.URL.Query())
+	if err != nil {
+		logger.With("error", err).Error("invalid filters")
+		writeError(w, r, err)
+		return
+	}
+	filters.Trashed = true
+
+	books, err := b.interactor.ReadBooks(ctx, filters)
+	if err != nil {
+		logger.With("error", err).Error("unable to read trash")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBooks(books))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode books")
+		writeError(w, r, err)
+		return
+	}
+}
+
+// Restore handles HTTP requests for moving the book identified in the
+// request path out of the trash. It writes the restored book as JSON to the response.
+func (b *BookController) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "BookController.Restore")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	bookID := r.PathValue("id")
+
+	book, err := b.interactor.RestoreBook(ctx, bookID)
+	if err != nil {
+		logger.With("error", err, "id", bookID).Error("unable to restore book")
+		writeError(w, r, err)
+		return
+	}
+
+	logger.With("id", bookID).Info("book restored")
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewBook(book))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode book")
+		writeError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// WebhookInteractor defines the application logic for managing the webhooks.
+type WebhookInteractor interface {
+	// CreateWebhook validates and stores a new webhook.
+	CreateWebhook(ctx context.Context, hook *domain.Webhook) (*domain.Webhook, error)
+	// ListWebhooks retrieves all the webhooks.
+	ListWebhooks(ctx context.Context) ([]*domain.Webhook, error)
+	// DeleteWebhook removes a webhook together with its deliveries.
+	DeleteWebhook(ctx context.Context, id string) error
+	// ListDeliveries retrieves the latest deliveries of a webhook.
+	ListDeliveries(ctx context.Context, webhookID string) ([]*domain.WebhookDelivery, error)
+	// Redeliver queues a new delivery of the event of a past delivery.
+	Redeliver(ctx context.Context, webhookID, deliveryID string) (*domain.WebhookDelivery, error)
+}
+
+// CreateWebhookRequest represents the payload required to register a webhook.
+// A random secret is generated when Secret is omitted.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret *string  `json:"secret"`
+}
+
+// WebhookController implements [webservice.WebhookController] to handle
+// HTTP requests related to the webhooks.
+type WebhookController struct {
+	interactor WebhookInteractor
+	logger     *slog.Logger
+}
+
+// NewWebhookController creates a new WebhookController with the given interactor and logger.
+func NewWebhookController(i WebhookInteractor, l *slog.Logger) *WebhookController {
+	return &WebhookController{
+		interactor: i,
+		logger:     l,
+	}
+}
+
+// Create handles HTTP requests for registering a webhook and writes the
+// created webhook as JSON to the response, including its secret, which is
+// not returned afterwards.
+func (c *WebhookController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	var wr CreateWebhookRequest
+	err := decodeJSON(w, r, &wr)
+	if err != nil {
+		logger.With("error", err).Error("unable to get request body")
+		writeError(w, r, err)
+		return
+	}
+
+	hook := &domain.Webhook{URL: wr.URL}
+	for _, e := range wr.Events {
+		hook.Events = append(hook.Events, domain.WebhookEvent(e))
+	}
+	if wr.Secret != nil {
+		hook.Secret = *wr.Secret
+	}
+
+	hook, err = c.interactor.CreateWebhook(r.Context(), hook)
+	if err != nil {
+		logger.With("error", err).Error("unable to create webhook")
+		writeError(w, r, err)
+		return
+	}
+
+	res := v1.NewWebhook(hook)
+	res.Secret = hook.Secret
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/v1/admin/webhooks/"+hook.ID)
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(res)
+	if err != nil {
+		logger.With("error", err).Error("unable to encode webhook")
+		return
+	}
+}
+
+// List handles HTTP requests for retrieving all the webhooks.
+func (c *WebhookController) List(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	hooks, err := c.interactor.ListWebhooks(r.Context())
+	if err != nil {
+		logger.With("error", err).Error("unable to list webhooks")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewWebhooks(hooks))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode webhooks")
+		return
+	}
+}
+
+// Delete handles HTTP requests for removing the webhook identified by the
+// id path value. Its pending deliveries are discarded.
+func (c *WebhookController) Delete(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	err := c.interactor.DeleteWebhook(r.Context(), r.PathValue("id"))
+	if err != nil {
+		logger.With("error", err).Error("unable to delete webhook")
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries handles HTTP requests for retrieving the delivery log of the
+// webhook identified by the id path value.
+func (c *WebhookController) Deliveries(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	deliveries, err := c.interactor.ListDeliveries(r.Context(), r.PathValue("id"))
+	if err != nil {
+		logger.With("error", err).Error("unable to list webhook deliveries")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(v1.NewWebhookDeliveries(deliveries))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode webhook deliveries")
+		return
+	}
+}
+
+// Redeliver handles HTTP requests for sending again the delivery identified
+// by the delivery_id path value to the webhook identified by the id path
+// value. It writes the new delivery, which is queued, as JSON to the response.
+func (c *WebhookController) Redeliver(w http.ResponseWriter, r *http.Request) {
+	logger := logctx.FromContext(r.Context(), c.logger)
+	delivery, err := c.interactor.Redeliver(r.Context(), r.PathValue("id"), r.PathValue("delivery_id"))
+	if err != nil {
+		logger.With("error", err).Error("unable to redeliver webhook delivery")
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(w).Encode(v1.NewWebhookDelivery(delivery))
+	if err != nil {
+		logger.With("error", err).Error("unable to encode webhook delivery")
+		return
+	}
+}
@@ -0,0 +1,200 @@
+// Package exporter writes the book club library in formats meant for other tools and people.
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+)
+
+// Format is a supported export format.
+type Format string
+
+const (
+	// FormatCSV writes one book per row, with a header row.
+	FormatCSV Format = "csv"
+	// FormatJSONLines writes one JSON encoded book per line.
+	FormatJSONLines Format = "jsonl"
+	// FormatMarkdown writes a reading list with a section per status.
+	FormatMarkdown Format = "md"
+)
+
+// StringToFormatMap maps a valid string representation of an export format to the correct [Format] variable.
+var StringToFormatMap = map[string]Format{
+	"csv":   FormatCSV,
+	"jsonl": FormatJSONLines,
+	"md":    FormatMarkdown,
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONLines:
+		return "application/jsonl; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// Encoder writes books, one at a time, in an export format.
+type Encoder interface {
+	// Encode writes a book.
+	Encode(book *domain.Book) error
+	// Close writes any trailing content and flushes the buffered output.
+	// It does not close the underlying writer.
+	Close() error
+}
+
+// NewEncoder returns an Encoder writing the given format to w.
+func NewEncoder(f Format, w io.Writer) (Encoder, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatJSONLines:
+		return newJSONLinesEncoder(w), nil
+	case FormatMarkdown:
+		return newMarkdownEncoder(w), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// csvHeader lists the columns written by the CSV encoder.
+var csvHeader = []string{"id", "title", "author", "genre", "published_year", "isbn", "status"}
+
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Encode(book *domain.Book) error {
+	if !e.wroteHeader {
+		e.wroteHeader = true
+		err := e.w.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+	}
+
+	var genre, year, isbn string
+	if book.Genre != nil {
+		genre = *book.Genre
+	}
+	if book.PublishedYear != nil {
+		year = strconv.Itoa(*book.PublishedYear)
+	}
+	if book.ISBN != nil {
+		isbn = *book.ISBN
+	}
+
+	return e.w.Write([]string{book.ID, book.Title, book.Author, genre, year, isbn, string(book.Status)})
+}
+
+func (e *csvEncoder) Close() error {
+	if !e.wroteHeader {
+		e.wroteHeader = true
+		err := e.w.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonLinesEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLinesEncoder(w io.Writer) *jsonLinesEncoder {
+	bw := bufio.NewWriter(w)
+	return &jsonLinesEncoder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (e *jsonLinesEncoder) Encode(book *domain.Book) error {
+	return e.enc.Encode(v1.NewBook(book))
+}
+
+func (e *jsonLinesEncoder) Close() error {
+	return e.w.Flush()
+}
+
+// markdownSectionTitles are the section titles of the reading list, by status.
+var markdownSectionTitles = map[domain.BookStatus]string{
+	domain.BookStatusReading:   "Currently reading",
+	domain.BookStatusSuggested: "Suggestions",
+	domain.BookStatusSaved:     "Saved for later",
+	domain.BookStatusCompleted: "Read",
+	domain.BookStatusDiscarded: "Discarded",
+}
+
+// markdownEncoder writes a reading list. It expects the books grouped by
+// status, and starts a new section every time the status changes.
+type markdownEncoder struct {
+	w       *bufio.Writer
+	current domain.BookStatus
+	empty   bool
+}
+
+func newMarkdownEncoder(w io.Writer) *markdownEncoder {
+	return &markdownEncoder{w: bufio.NewWriter(w), empty: true}
+}
+
+func (e *markdownEncoder) Encode(book *domain.Book) error {
+	if e.empty {
+		e.empty = false
+		e.w.WriteString("# Book club reading list\n")
+	}
+
+	if book.Status != e.current {
+		e.current = book.Status
+		title, ok := markdownSectionTitles[book.Status]
+		if !ok {
+			title = string(book.Status)
+		}
+		fmt.Fprintf(e.w, "\n## %s\n\n", title)
+	}
+
+	fmt.Fprintf(e.w, "- *%s* by %s", escapeMarkdown(book.Title), escapeMarkdown(book.Author))
+	if book.PublishedYear != nil {
+		fmt.Fprintf(e.w, " (%d)", *book.PublishedYear)
+	}
+	if book.Genre != nil {
+		fmt.Fprintf(e.w, " — %s", escapeMarkdown(*book.Genre))
+	}
+
+	_, err := e.w.WriteString("\n")
+	return err
+}
+
+func (e *markdownEncoder) Close() error {
+	if e.empty {
+		e.w.WriteString("# Book club reading list\n\nNo books yet.\n")
+	}
+
+	return e.w.Flush()
+}
+
+// markdownEscaper escapes the characters that would change the formatting of a list item.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, `*`, `\*`, `_`, `\_`, "`", "\\`", `[`, `\[`, `]`, `\]`, `<`, `\<`, `#`, `\#`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
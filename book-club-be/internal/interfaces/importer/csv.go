@@ -0,0 +1,180 @@
+// Package importer reads the library exports of other book tracking services.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// ErrorUnknownFormat is returned when the CSV header does not match any supported export.
+var ErrorUnknownFormat = errors.New("unknown export format: expected a Goodreads or StoryGraph CSV export")
+
+// ShelfToBookStatusMap maps the Goodreads shelves and StoryGraph read statuses to a [domain.BookStatus].
+var ShelfToBookStatusMap = map[string]domain.BookStatus{
+	"to-read":           domain.BookStatusSaved,
+	"currently-reading": domain.BookStatusReading,
+	"read":              domain.BookStatusCompleted,
+}
+
+// format describes the columns of a supported CSV export.
+type format struct {
+	title      string
+	authors    []string
+	isbns      []string
+	years      []string
+	shelf      string
+	authorsSep string
+	required   []string
+}
+
+var (
+	goodreads = format{
+		title:      "Title",
+		authors:    []string{"Author", "Additional Authors"},
+		isbns:      []string{"ISBN13", "ISBN"},
+		years:      []string{"Original Publication Year", "Year Published"},
+		shelf:      "Exclusive Shelf",
+		authorsSep: ",",
+		required:   []string{"Title", "Author", "Exclusive Shelf"},
+	}
+	storyGraph = format{
+		title:      "Title",
+		authors:    []string{"Authors"},
+		isbns:      []string{"ISBN/UID"},
+		shelf:      "Read Status",
+		authorsSep: ",",
+		required:   []string{"Title", "Authors", "Read Status"},
+	}
+)
+
+// ParseCSV reads a Goodreads or StoryGraph CSV export, detecting the format
+// from its header. Rows that cannot be converted into a book are returned as
+// records with Err set, so that they can be reported without stopping the import.
+func ParseCSV(r io.Reader) ([]*domain.ImportRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrorUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))] = i
+	}
+
+	f, err := detectFormat(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*domain.ImportRecord, 0)
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			records = append(records, &domain.ImportRecord{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		records = append(records, f.record(line, columns, row))
+	}
+
+	return records, nil
+}
+
+// detectFormat returns the format whose required columns are all present.
+func detectFormat(columns map[string]int) (*format, error) {
+	for _, f := range []*format{&goodreads, &storyGraph} {
+		found := true
+		for _, c := range f.required {
+			if _, ok := columns[c]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return f, nil
+		}
+	}
+
+	return nil, ErrorUnknownFormat
+}
+
+// record converts a CSV row into an import record.
+func (f *format) record(line int, columns map[string]int, row []string) *domain.ImportRecord {
+	get := func(col string) string {
+		i, ok := columns[col]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	rec := &domain.ImportRecord{Line: line}
+
+	title := get(f.title)
+	authors := make([]string, 0, len(f.authors))
+	for _, col := range f.authors {
+		for _, a := range strings.Split(get(col), f.authorsSep) {
+			if a = strings.TrimSpace(a); a != "" {
+				authors = append(authors, a)
+			}
+		}
+	}
+
+	for _, col := range f.isbns {
+		if isbn, err := domain.NormalizeISBN(strings.Trim(get(col), `="`)); err == nil {
+			rec.ISBN = isbn
+			break
+		}
+	}
+
+	var year *int
+	for _, col := range f.years {
+		if y, err := strconv.Atoi(get(col)); err == nil && y > 0 {
+			year = &y
+			break
+		}
+	}
+
+	shelf := get(f.shelf)
+	status, ok := ShelfToBookStatusMap[shelf]
+
+	switch {
+	case title == "":
+		rec.Err = errors.New("missing title")
+	case len(authors) == 0:
+		rec.Err = errors.New("missing author")
+	case !ok:
+		rec.Err = fmt.Errorf("unsupported shelf %q", shelf)
+	default:
+		rec.Book = &domain.Book{
+			Title:         title,
+			Author:        strings.Join(authors, " & "),
+			PublishedYear: year,
+			Status:        status,
+		}
+	}
+
+	return rec
+}
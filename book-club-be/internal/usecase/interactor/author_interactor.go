@@ -0,0 +1,60 @@
+package interactor
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// AuthorInteractor provides the application logic for authors.
+type AuthorInteractor struct {
+	authors domain.AuthorRepository
+	books   domain.BookRepository
+	logger  *slog.Logger
+}
+
+// NewAuthorInteractor creates a new AuthorInteractor with the given repositories and logger.
+func NewAuthorInteractor(
+	authors domain.AuthorRepository,
+	books domain.BookRepository,
+	logger *slog.Logger,
+) *AuthorInteractor {
+	return &AuthorInteractor{
+		authors: authors,
+		books:   books,
+		logger:  logger,
+	}
+}
+
+// ListAuthors retrieves all authors, ordered by name.
+func (a *AuthorInteractor) ListAuthors(ctx context.Context) ([]*domain.Author, error) {
+	return a.authors.List(ctx)
+}
+
+// ListAuthorBooks retrieves the books written, alone or with others, by the
+// author identified by authorID.
+func (a *AuthorInteractor) ListAuthorBooks(ctx context.Context, authorID string) ([]*domain.Book, error) {
+	return a.books.List(ctx, &domain.BookFilters{AuthorID: &authorID})
+}
+
+// MergeAuthors merges the authors identified by sourceIDs into the author
+// identified by targetID, which takes over their books.
+func (a *AuthorInteractor) MergeAuthors(ctx context.Context, targetID string, sourceIDs []string) error {
+	logger := logctx.FromContext(ctx, a.logger)
+	if len(sourceIDs) == 0 {
+		var v domain.ValidationError
+		v.Add("author_ids", domain.ValidationRequired, "author_ids is required")
+		return &v
+	}
+
+	err := a.authors.Merge(ctx, targetID, sourceIDs)
+	if err != nil {
+		return err
+	}
+
+	logger.With("id", targetID, "merged", sourceIDs).Info("authors merged")
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// RetentionPolicy decides which snapshots are kept after a new backup is taken.
+// A zero value field disables the corresponding rule.
+type RetentionPolicy struct {
+	// Keep is the maximum number of snapshots to keep.
+	Keep int
+	// MaxAge is the maximum age of a snapshot before it is deleted.
+	MaxAge time.Duration
+}
+
+// BackupInteractor provides the application logic for backing up the database.
+type BackupInteractor struct {
+	repo      domain.SnapshotRepository
+	retention RetentionPolicy
+	logger    *slog.Logger
+}
+
+// NewBackupInteractor creates a new BackupInteractor with the given repository, retention policy and logger.
+func NewBackupInteractor(repo domain.SnapshotRepository, retention RetentionPolicy, logger *slog.Logger) *BackupInteractor {
+	return &BackupInteractor{
+		repo:      repo,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// CreateBackup takes a new snapshot of the database and then deletes the
+// snapshots that fall outside the retention policy. A failure while pruning
+// is logged but does not invalidate the new snapshot.
+func (b *BackupInteractor) CreateBackup(ctx context.Context) (*domain.Snapshot, error) {
+	logger := logctx.FromContext(ctx, b.logger)
+	snapshot, err := b.repo.Create(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.With("name", snapshot.Name, "size", snapshot.Size).Info("backup created")
+
+	err = b.prune(ctx)
+	if err != nil {
+		logger.With("error", err).Error("unable to apply backup retention policy")
+	}
+
+	return snapshot, nil
+}
+
+// ListBackups retrieves the stored snapshots, newest first.
+func (b *BackupInteractor) ListBackups(ctx context.Context) ([]*domain.Snapshot, error) {
+	return b.repo.List(ctx)
+}
+
+// Schedule takes a backup every interval until ctx is canceled.
+func (b *BackupInteractor) Schedule(ctx context.Context, interval time.Duration) {
+	logger := logctx.FromContext(ctx, b.logger)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := b.CreateBackup(ctx)
+			if err != nil {
+				logger.With("error", err).Error("scheduled backup failed")
+			}
+		}
+	}
+}
+
+// prune deletes the snapshots exceeding the retention policy.
+func (b *BackupInteractor) prune(ctx context.Context) error {
+	logger := logctx.FromContext(ctx, b.logger)
+	snapshots, err := b.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	for i, s := range snapshots {
+		tooMany := b.retention.Keep > 0 && i >= b.retention.Keep
+		tooOld := b.retention.MaxAge > 0 && now.Sub(s.CreatedAt) > b.retention.MaxAge
+		// The newest snapshot is always kept, whatever its age.
+		if i == 0 || (!tooMany && !tooOld) {
+			continue
+		}
+
+		err = b.repo.Delete(ctx, s.Name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logger.With("name", s.Name).Info("backup deleted by retention policy")
+	}
+
+	return errors.Join(errs...)
+}
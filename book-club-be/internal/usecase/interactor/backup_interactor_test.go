@@ -0,0 +1,94 @@
+package interactor
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+)
+
+// snapshotName returns the name of a snapshot taken age ago.
+func snapshotName(age time.Duration) string {
+	return "books-" + time.Now().Add(-age).UTC().Format("20060102T150405.000Z") + ".db"
+}
+
+func TestPrune(t *testing.T) {
+	day := 24 * time.Hour
+	defaultAges := []time.Duration{0, day, 2 * day, 10 * day, 40 * day}
+	// foreign are files of the backup directory which are not snapshots.
+	foreign := []string{"books.db", "notes.txt"}
+
+	tests := []struct {
+		name      string
+		retention RetentionPolicy
+		// ages are the ages of the snapshots to create, defaultAges if nil.
+		ages []time.Duration
+		// kept are the indexes in ages of the snapshots left by prune.
+		kept []int
+	}{
+		{name: "no policy", kept: []int{0, 1, 2, 3, 4}},
+		{name: "keep N", retention: RetentionPolicy{Keep: 3}, kept: []int{0, 1, 2}},
+		{name: "keep more than stored", retention: RetentionPolicy{Keep: 10}, kept: []int{0, 1, 2, 3, 4}},
+		{name: "max age", retention: RetentionPolicy{MaxAge: 7 * day}, kept: []int{0, 1, 2}},
+		{name: "keep N and max age", retention: RetentionPolicy{Keep: 2, MaxAge: 30 * day}, kept: []int{0, 1}},
+		{
+			name:      "newest kept whatever its age",
+			retention: RetentionPolicy{Keep: 1, MaxAge: day},
+			ages:      []time.Duration{10 * day, 40 * day},
+			kept:      []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.DiscardHandler)
+			dir := t.TempDir()
+			snapshots, err := db.NewSQLiteSnapshotRepository(newTestRepo(t), dir, logger)
+			if err != nil {
+				t.Fatalf("NewSQLiteSnapshotRepository() error = %v", err)
+			}
+
+			ages := tt.ages
+			if ages == nil {
+				ages = defaultAges
+			}
+			names := make([]string, len(ages))
+			for i, age := range ages {
+				names[i] = snapshotName(age)
+			}
+			for _, name := range append(slices.Clone(names), foreign...) {
+				err = os.WriteFile(filepath.Join(dir, name), nil, 0o600)
+				if err != nil {
+					t.Fatalf("unable to write %s: %v", name, err)
+				}
+			}
+
+			err = NewBackupInteractor(snapshots, tt.retention, logger).prune(context.Background())
+			if err != nil {
+				t.Fatalf("prune() error = %v", err)
+			}
+
+			want := slices.Clone(foreign)
+			for _, i := range tt.kept {
+				want = append(want, names[i])
+			}
+			slices.Sort(want)
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("unable to list backups: %v", err)
+			}
+			got := make([]string, len(entries))
+			for i, e := range entries {
+				got[i] = e.Name()
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("backup directory = %v, want %v", got, want)
+			}
+		})
+	}
+}
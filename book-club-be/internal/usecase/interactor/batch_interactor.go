@@ -0,0 +1,93 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// errorBatchFailed rolls back an atomic batch when one of its operations fails.
+var errorBatchFailed = errors.New("batch operation failed")
+
+// BatchBooks applies ops in order through CreateBook, UpdateBook and
+// DeleteBook, so that each operation is validated as if it was made alone.
+// In an atomic batch, the books to create are enriched before the
+// transaction starts.
+// When atomic is set, the operations run in a single transaction, which is
+// rolled back at the first failure; otherwise each operation is stored on
+// its own and failures do not stop the batch. The outcome of each operation
+// is reported, while the returned error is set only if the batch could not
+// be run.
+func (b *BookInteractor) BatchBooks(
+	ctx context.Context,
+	ops []domain.BatchOperation,
+	atomic bool,
+) (*domain.BatchReport, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.BatchBooks")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	report := &domain.BatchReport{Results: make([]domain.BatchResult, 0, len(ops))}
+	if !atomic {
+		for _, op := range ops {
+			report.Results = append(report.Results, b.applyOperation(ctx, op, true))
+		}
+		report.Committed = true
+
+		return report, nil
+	}
+
+	// The books to create are looked up with the metadata provider first,
+	// as the transaction holds the write lock of the database until it ends.
+	for _, op := range ops {
+		if op.Op == domain.BatchOpCreate && checkCreatable(op.Book) == nil {
+			b.prepareCreate(ctx, op.Book)
+		}
+	}
+
+	// The operations join the transaction, and so do their events.
+	err := withinTransaction(ctx, b.repo, b.events, func(ctx context.Context) error {
+		for _, op := range ops {
+			res := b.applyOperation(ctx, op, false)
+			report.Results = append(report.Results, res)
+			if res.Err != nil {
+				return errorBatchFailed
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errorBatchFailed) {
+		logger.With("operations", len(ops), "failed_index", len(report.Results)-1).Info("batch rolled back")
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Committed = true
+
+	return report, nil
+}
+
+// applyOperation applies a single batch operation and reports its outcome.
+// A book to create is prepared as by CreateBook only if prepare is set.
+func (b *BookInteractor) applyOperation(ctx context.Context, op domain.BatchOperation, prepare bool) domain.BatchResult {
+	res := domain.BatchResult{Op: op.Op}
+	switch op.Op {
+	case domain.BatchOpCreate:
+		res.Book, res.Err = b.createBook(ctx, op.Book, prepare)
+	case domain.BatchOpUpdate:
+		res.Book, res.Err = b.UpdateBook(ctx, op.Book)
+	case domain.BatchOpDelete:
+		res.Err = b.DeleteBook(ctx, op.Book.ID)
+	default:
+		res.Err = fmt.Errorf("unknown batch operation %q", op.Op)
+	}
+	if res.Err != nil {
+		res.Book = nil
+	}
+
+	return res
+}
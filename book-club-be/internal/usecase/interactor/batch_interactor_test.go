@@ -0,0 +1,139 @@
+package interactor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+	"github.com/Michela-DC/book-club/internal/infrastructure/metadata"
+)
+
+// committedPublisher publishes the events to an event log and records, on
+// each notification, whether every published event was committed.
+type committedPublisher struct {
+	t         *testing.T
+	log       *db.SQLiteEventLog
+	published int
+	notified  []int
+}
+
+func (p *committedPublisher) Publish(ctx context.Context, event *domain.Event) error {
+	p.published++
+	return p.log.Append(ctx, event)
+}
+
+func (p *committedPublisher) Notify() {
+	// Without a transaction in the context, only the committed events are read.
+	events, err := p.log.ListUndispatched(context.Background(), 100)
+	if err != nil {
+		p.t.Fatalf("ListUndispatched() error = %v", err)
+	}
+	if len(events) != p.published {
+		p.t.Errorf("notified with %d committed events out of %d published", len(events), p.published)
+	}
+	p.notified = append(p.notified, len(events))
+}
+
+func TestBatchBooksNotifiesOnceCommitted(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	repo := newTestRepo(t)
+	events := &committedPublisher{t: t, log: db.NewSQLiteEventLog(repo, time.Hour, logger)}
+	b := NewBookInteractor(
+		repo,
+		db.NewSQLiteTagRepository(repo, logger),
+		db.NewSQLiteMemberRepository(repo, logger),
+		metadata.NewFakeProvider(),
+		nil,
+		events,
+		logger,
+	)
+	ops := func(titles ...string) []domain.BatchOperation {
+		out := make([]domain.BatchOperation, len(titles))
+		for i, title := range titles {
+			out[i] = domain.BatchOperation{
+				Op:   domain.BatchOpCreate,
+				Book: &domain.Book{Title: title, Author: "Jane Austen", Status: domain.BookStatusSaved},
+			}
+		}
+		return out
+	}
+
+	report, err := b.BatchBooks(context.Background(), ops("Emma", "Persuasion"), true)
+	if err != nil || !report.Committed {
+		t.Fatalf("BatchBooks() = %v, %v, want a committed batch", report, err)
+	}
+	if len(events.notified) != 1 || events.notified[0] != 2 {
+		t.Errorf("notifications = %v, want one after the 2 events are committed", events.notified)
+	}
+
+	events.notified = nil
+	report, err = b.BatchBooks(context.Background(), ops("Sanditon", ""), true)
+	if err != nil || report.Committed {
+		t.Fatalf("BatchBooks() = %v, %v, want a rolled back batch", report, err)
+	}
+	events.published = 2
+	if len(events.notified) != 0 {
+		t.Errorf("notifications = %v, want none for a rolled back batch", events.notified)
+	}
+
+	report, err = b.BatchBooks(context.Background(), ops("Sanditon", "Lady Susan"), false)
+	if err != nil || !report.Committed {
+		t.Fatalf("BatchBooks() = %v, %v, want a committed batch", report, err)
+	}
+	if len(events.notified) != 2 {
+		t.Errorf("notifications = %v, want one for each operation of a non atomic batch", events.notified)
+	}
+}
+
+// callLog records the order of the calls made to the metadata provider and
+// to the event publisher.
+type callLog struct {
+	calls []string
+}
+
+func (l *callLog) Name() string { return "log" }
+
+func (l *callLog) Lookup(_ context.Context, query domain.MetadataQuery) (*domain.BookMetadata, error) {
+	l.calls = append(l.calls, "lookup "+query.Title)
+	return nil, domain.ErrorNotFound
+}
+
+func (l *callLog) Publish(_ context.Context, event *domain.Event) error {
+	l.calls = append(l.calls, "publish "+event.Book.Title)
+	return nil
+}
+
+func (l *callLog) Notify() {}
+
+func TestAtomicBatchEnrichesBeforeTheTransaction(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	repo := newTestRepo(t)
+	calls := &callLog{}
+	b := NewBookInteractor(
+		repo,
+		db.NewSQLiteTagRepository(repo, logger),
+		db.NewSQLiteMemberRepository(repo, logger),
+		calls,
+		nil,
+		calls,
+		logger,
+	)
+
+	report, err := b.BatchBooks(context.Background(), []domain.BatchOperation{
+		{Op: domain.BatchOpCreate, Book: &domain.Book{Title: "Emma", Author: "Jane Austen", Status: domain.BookStatusSaved}},
+		{Op: domain.BatchOpCreate, Book: &domain.Book{Title: "Persuasion", Author: "Jane Austen", Status: domain.BookStatusSaved}},
+	}, true)
+	if err != nil || !report.Committed {
+		t.Fatalf("BatchBooks() = %v, %v, want a committed batch", report, err)
+	}
+
+	// The events are published in the transaction, which must not wait for the provider.
+	want := []string{"lookup Emma", "lookup Persuasion", "publish Emma", "publish Persuasion"}
+	if !slices.Equal(calls.calls, want) {
+		t.Errorf("calls = %v, want %v", calls.calls, want)
+	}
+}
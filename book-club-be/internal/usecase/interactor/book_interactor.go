@@ -3,58 +3,493 @@ package interactor
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
 
 	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
 )
 
+var tracer = otel.Tracer("github.com/Michela-DC/book-club/internal/usecase/interactor")
+
+// defaultEnrichTimeout bounds the time spent looking up a book with the
+// metadata provider, so that a slow catalog does not hold up its creation.
+const defaultEnrichTimeout = 10 * time.Second
+
 // BookInteractor provides the application logic for managing books.
 // It coordinates between the domain layer and repositories.
 type BookInteractor struct {
-	repo   domain.BookRepository
-	logger *slog.Logger
+	repo     domain.BookRepository
+	tags     domain.TagRepository
+	members  domain.MemberRepository
+	metadata domain.MetadataProvider
+	blobs    domain.BlobStore
+	events   domain.EventPublisher
+	logger   *slog.Logger
+	// enrichTimeout bounds each lookup of the metadata provider.
+	enrichTimeout time.Duration
 }
 
-// NewBookInteractor creates a new BookInteractor with the given repository and logger.
-func NewBookInteractor(repo domain.BookRepository, logger *slog.Logger) *BookInteractor {
+// NewBookInteractor creates a new BookInteractor with the given repositories,
+// metadata provider, blob store of the covers, event publisher and logger.
+// The metadata provider, the blob store and the event publisher are optional:
+// when nil, books are never enriched, the covers of the purged books are left
+// in place and changes are not published.
+func NewBookInteractor(
+	repo domain.BookRepository,
+	tags domain.TagRepository,
+	members domain.MemberRepository,
+	metadata domain.MetadataProvider,
+	blobs domain.BlobStore,
+	events domain.EventPublisher,
+	logger *slog.Logger,
+) *BookInteractor {
 	return &BookInteractor{
-		repo:   repo,
-		logger: logger,
+		repo:     repo,
+		tags:     tags,
+		members:  members,
+		metadata: metadata,
+		blobs:    blobs,
+		events:   events,
+		logger:   logger,
+
+		enrichTimeout: defaultEnrichTimeout,
 	}
 }
 
 // CreateBook validates the provided book and delegates its creation
 // to the underlying repository. It returns an error if the book is nil,
 // or if the status is invalid for creation (e.g., "completed" or "discarded").
+// Missing fields are filled in from the metadata provider, if any, before
+// the book is validated, so that a book can be created from its ISBN alone.
 func (b *BookInteractor) CreateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.CreateBook")
+	defer span.End()
+
+	return b.createBook(ctx, book, true)
+}
+
+// checkCreatable returns an error if book cannot be created.
+func checkCreatable(book *domain.Book) error {
 	if book == nil {
-		return nil, errors.New("empty book info")
+		return errors.New("empty book info")
 	}
 	if book.Status == domain.BookStatusCompleted || book.Status == domain.BookStatusDiscarded {
-		return nil, errors.New("cannot create book with status " + string(book.Status))
+		return fmt.Errorf("%w: cannot create book with status %s", domain.ErrorForbidden, book.Status)
 	}
-	return b.repo.Create(ctx, book)
+
+	return nil
 }
 
-// ReadBooks retrieves a list of books that match the provided filters.
-// TODO: servirá modificare la firma dei metodi con (b *BookInteractor).
-func (*BookInteractor) ReadBooks(_ *domain.BookFilters) ([]*domain.Book, error) {
-	return nil, errors.New("not implemented")
+// prepareCreate normalizes book and fills in its missing fields from the
+// metadata provider. A failed lookup is only logged.
+func (b *BookInteractor) prepareCreate(ctx context.Context, book *domain.Book) {
+	logger := logctx.FromContext(ctx, b.logger)
+	book.Normalize()
+	_, err := b.enrich(ctx, book)
+	if err != nil {
+		logger.With("error", err).Warn("unable to enrich book, creating it as is")
+	}
+}
+
+// createBook creates book as described by CreateBook. When prepare is not
+// set, book must already have been prepared with prepareCreate.
+func (b *BookInteractor) createBook(ctx context.Context, book *domain.Book, prepare bool) (*domain.Book, error) {
+	err := checkCreatable(book)
+	if err != nil {
+		return nil, err
+	}
+	if prepare {
+		b.prepareCreate(ctx, book)
+	}
+
+	err = b.validate(ctx, book)
+	if err != nil {
+		return nil, err
+	}
+
+	err = withEvent(ctx, b.repo, b.events, func(ctx context.Context) (*domain.Event, error) {
+		err := b.checkISBNAvailable(ctx, book)
+		if err != nil {
+			return nil, err
+		}
+		created, err := b.repo.Create(ctx, book)
+		if err != nil {
+			return nil, err
+		}
+		book = created
+		return &domain.Event{Type: domain.EventBookCreated, BookID: book.ID, Book: book}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
 }
 
-// UpdateBook updates the information of an existing book in the repository.
-func (b *BookInteractor) UpdateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
+// GetBook retrieves the book identified by bookID, or returns [domain.ErrorNotFound].
+func (b *BookInteractor) GetBook(ctx context.Context, bookID string) (*domain.Book, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.GetBook")
+	defer span.End()
+
+	return b.getBook(ctx, bookID)
+}
+
+// ReplaceBook stores book under its ID, which is chosen by the client: the
+// book is created if the ID is unknown, as by CreateBook, and replaced
+// otherwise. Replacing keeps the fields that are not set by clients, such as
+// the cover, and is forbidden for a book in the trash. It reports whether
+// the book was created.
+func (b *BookInteractor) ReplaceBook(ctx context.Context, book *domain.Book) (*domain.Book, bool, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.ReplaceBook")
+	defer span.End()
+
 	if book == nil {
+		return nil, false, errors.New("empty book info")
+	}
+
+	existing, err := b.getBook(ctx, book.ID)
+	if errors.Is(err, domain.ErrorNotFound) {
+		_, err = b.getTrashedBook(ctx, book.ID)
+		if err == nil {
+			return nil, false, fmt.Errorf("%w: book %s is in the trash and must be restored first",
+				domain.ErrorForbidden, book.ID)
+		}
+		created, err := b.CreateBook(ctx, book)
+		return created, err == nil, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	previous := existing.Status
+	existing.Title = book.Title
+	existing.Author = book.Author
+	existing.Genre = book.Genre
+	existing.PublishedYear = book.PublishedYear
+	existing.ISBN = book.ISBN
+	existing.Status = book.Status
+	existing.Tags = book.Tags
+	if existing.Tags == nil {
+		// Nil tags would be left unchanged by the repository.
+		existing.Tags = make([]*domain.Tag, 0)
+	}
+	existing.Normalize()
+
+	err = b.validate(ctx, existing)
+	if err != nil {
+		return nil, false, err
+	}
+
+	book, err = b.update(ctx, existing, previous)
+	return book, false, err
+}
+
+// GetBookByISBN retrieves the book with the given ISBN, which must be in its
+// normalized ISBN-13 form. It returns [domain.ErrorNotFound] if there is none.
+func (b *BookInteractor) GetBookByISBN(ctx context.Context, isbn string) (*domain.Book, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.GetBookByISBN")
+	defer span.End()
+
+	books, err := b.repo.List(ctx, &domain.BookFilters{ISBN: &isbn})
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, domain.ErrorNotFound
+	}
+
+	return books[0], nil
+}
+
+// EnrichBook fills the missing fields of the book identified by bookID with
+// the information found by the metadata provider, and stores the result.
+func (b *BookInteractor) EnrichBook(ctx context.Context, bookID string) (*domain.Book, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.EnrichBook")
+	defer span.End()
+
+	if b.metadata == nil {
+		return nil, errors.New("no metadata provider configured")
+	}
+
+	book, err := b.getBook(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := b.enrich(ctx, book)
+	if err != nil || !changed {
+		return book, err
+	}
+
+	return b.update(ctx, book, book.Status)
+}
+
+// enrich looks up book with the metadata provider, by ISBN or by title and
+// author, and fills its missing fields. It reports whether book was changed.
+// The lookup fails if the provider does not answer within b.enrichTimeout.
+func (b *BookInteractor) enrich(ctx context.Context, book *domain.Book) (bool, error) {
+	logger := logctx.FromContext(ctx, b.logger)
+	if b.metadata == nil {
+		return false, nil
+	}
+
+	query := domain.MetadataQuery{Title: book.Title, Author: book.Author}
+	if book.ISBN != nil {
+		query.ISBN = *book.ISBN
+	}
+	if query.ISBN == "" && query.Title == "" {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.enrichTimeout)
+	defer cancel()
+
+	m, err := b.metadata.Lookup(ctx, query)
+	if errors.Is(err, domain.ErrorNotFound) {
+		logger.With("provider", b.metadata.Name(), "isbn", query.ISBN, "title", query.Title).
+			Info("book not found by metadata provider")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return book.Enrich(m), nil
+}
+
+// checkISBNAvailable returns a [domain.DuplicateISBNError] if another book
+// already has the ISBN of book. It must run in the transaction storing book,
+// whose repository reports the books stored concurrently with the same ISBN.
+func (b *BookInteractor) checkISBNAvailable(ctx context.Context, book *domain.Book) error {
+	if book.ISBN == nil {
+		return nil
+	}
+
+	existing, err := b.GetBookByISBN(ctx, *book.ISBN)
+	if errors.Is(err, domain.ErrorNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != book.ID {
+		return &domain.DuplicateISBNError{ISBN: *book.ISBN, ExistingID: existing.ID}
+	}
+
+	return nil
+}
+
+// ReadBooks retrieves a list of books that match the provided filters.
+func (b *BookInteractor) ReadBooks(ctx context.Context, filters *domain.BookFilters) ([]*domain.Book, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.ReadBooks")
+	defer span.End()
+
+	return b.repo.List(ctx, filters)
+}
+
+// exportStatusOrder is the order in which ExportBooks groups books by status.
+var exportStatusOrder = []domain.BookStatus{
+	domain.BookStatusReading,
+	domain.BookStatusSuggested,
+	domain.BookStatusSaved,
+	domain.BookStatusCompleted,
+	domain.BookStatusDiscarded,
+}
+
+// ExportBooks calls fn for each book that matches the provided filters,
+// grouped by status and ordered by title within each group. Books are
+// streamed from the repository, so the library is never loaded in memory.
+func (b *BookInteractor) ExportBooks(
+	ctx context.Context,
+	filters *domain.BookFilters,
+	fn func(*domain.Book) error,
+) error {
+	ctx, span := tracer.Start(ctx, "BookInteractor.ExportBooks")
+	defer span.End()
+
+	var f domain.BookFilters
+	if filters != nil {
+		f = *filters
+	}
+
+	for _, status := range exportStatusOrder {
+		if filters != nil && filters.Status != nil && *filters.Status != status {
+			continue
+		}
+
+		f.Status = &status
+		err := b.repo.Stream(ctx, &f, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateBook applies the fields set in patch to the book identified by
+// patch.ID, validates the result and stores it. Empty strings, nil pointers
+// and nil tags leave the corresponding fields unchanged. It returns
+// [domain.ErrorNotFound] if the book does not exist.
+func (b *BookInteractor) UpdateBook(ctx context.Context, patch *domain.Book) (*domain.Book, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.UpdateBook")
+	defer span.End()
+
+	if patch == nil {
 		return nil, errors.New("book not found")
 	}
 
-	return book, b.repo.Update(ctx, book)
+	book, err := b.getBook(ctx, patch.ID)
+	if err != nil {
+		return nil, err
+	}
+	previous := book.Status
+	applyPatch(book, patch)
+	book.Normalize()
+
+	err = b.validate(ctx, book)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.update(ctx, book, previous)
+}
+
+// update stores the changes to book, whose status was previous, together with
+// their event. It returns a [domain.DuplicateISBNError] if another book has
+// the ISBN of book.
+func (b *BookInteractor) update(
+	ctx context.Context,
+	book *domain.Book,
+	previous domain.BookStatus,
+) (*domain.Book, error) {
+	err := withEvent(ctx, b.repo, b.events, func(ctx context.Context) (*domain.Event, error) {
+		err := b.checkISBNAvailable(ctx, book)
+		if err != nil {
+			return nil, err
+		}
+		err = b.repo.Update(ctx, book)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.Event{
+			Type:           domain.EventBookUpdated,
+			BookID:         book.ID,
+			Book:           book,
+			PreviousStatus: previous,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
 }
 
-// DeleteBook removes a book from the repository by its unique ID.
+// applyPatch copies the fields set in patch to book.
+func applyPatch(book, patch *domain.Book) {
+	if patch.Title != "" {
+		book.Title = patch.Title
+	}
+	if patch.Author != "" {
+		book.Author = patch.Author
+	}
+	if patch.Status != "" {
+		book.Status = patch.Status
+	}
+	if patch.Genre != nil {
+		book.Genre = patch.Genre
+	}
+	if patch.PublishedYear != nil {
+		book.PublishedYear = patch.PublishedYear
+	}
+	if patch.ISBN != nil {
+		book.ISBN = patch.ISBN
+	}
+	if patch.Tags != nil {
+		book.Tags = patch.Tags
+	}
+	if patch.SuggestedBy != nil {
+		book.SuggestedBy = patch.SuggestedBy
+	}
+}
+
+// validate checks book against the domain rules, resolves its tags, which
+// are given by name, against the tag vocabulary and checks that the member
+// who suggested it exists. It returns a [domain.ValidationError] listing all
+// the invalid fields.
+func (b *BookInteractor) validate(ctx context.Context, book *domain.Book) error {
+	var (
+		v       domain.ValidationError
+		bookErr *domain.ValidationError
+	)
+	if errors.As(book.Validate(), &bookErr) {
+		v.Fields = append(v.Fields, bookErr.Fields...)
+	}
+
+	if len(book.Tags) > 0 {
+		tags, err := b.tags.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(book.Tags))
+		for i, t := range book.Tags {
+			names[i] = t.Name
+		}
+		resolved, err := domain.NewTagVocabulary(tags).Resolve(names)
+		if err != nil {
+			v.Add("tags", domain.ValidationUnknown, err.Error())
+		} else {
+			book.Tags = resolved
+		}
+	}
+
+	if book.SuggestedBy != nil && *book.SuggestedBy != "" {
+		_, err := b.members.Get(ctx, *book.SuggestedBy)
+		switch {
+		case errors.Is(err, domain.ErrorNotFound):
+			v.Add("suggested_by", domain.ValidationUnknown, fmt.Sprintf("unknown member %q", *book.SuggestedBy))
+		case err != nil:
+			return err
+		}
+	}
+
+	return v.Err()
+}
+
+// getBook retrieves the book identified by bookID, or returns [domain.ErrorNotFound].
+func (b *BookInteractor) getBook(ctx context.Context, bookID string) (*domain.Book, error) {
+	books, err := b.repo.List(ctx, &domain.BookFilters{ID: &bookID})
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, domain.ErrorNotFound
+	}
+
+	return books[0], nil
+}
+
+// DeleteBook moves a book identified by its unique ID to the trash, from
+// which it can be restored until it is purged.
 func (b *BookInteractor) DeleteBook(ctx context.Context, bookID string) error {
+	ctx, span := tracer.Start(ctx, "BookInteractor.DeleteBook")
+	defer span.End()
+
 	if bookID == "" {
 		return errors.New("id cannot be empty")
 	}
-	return b.repo.Delete(ctx, bookID)
+
+	return withEvent(ctx, b.repo, b.events, func(ctx context.Context) (*domain.Event, error) {
+		err := b.repo.Delete(ctx, bookID)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.Event{Type: domain.EventBookDeleted, BookID: bookID}, nil
+	})
 }
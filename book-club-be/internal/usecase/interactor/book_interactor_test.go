@@ -0,0 +1,176 @@
+package interactor
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+	"github.com/Michela-DC/book-club/internal/infrastructure/metadata"
+)
+
+// migrationsPath is the folder of the database migrations, relative to this package.
+const migrationsPath = "../../../database/migrations"
+
+// newTestRepo returns a book repository backed by a new, migrated database.
+func newTestRepo(t *testing.T) *db.SQLiteBookRepository {
+	t.Helper()
+
+	logger := slog.New(slog.DiscardHandler)
+	repo, err := db.NewSQLiteBookRepository(filepath.Join(t.TempDir(), "books.db"), logger)
+	if err != nil {
+		t.Fatalf("unable to open database: %v", err)
+	}
+	err = repo.ApplyMigrations(context.Background(), migrationsPath)
+	if err != nil {
+		t.Fatalf("unable to apply migrations: %v", err)
+	}
+
+	return repo
+}
+
+// newTestBookInteractor returns a BookInteractor backed by a new database
+// and looking books up with provider.
+func newTestBookInteractor(t *testing.T, provider domain.MetadataProvider) *BookInteractor {
+	t.Helper()
+
+	logger := slog.New(slog.DiscardHandler)
+	repo := newTestRepo(t)
+
+	return NewBookInteractor(
+		repo,
+		db.NewSQLiteTagRepository(repo, logger),
+		db.NewSQLiteMemberRepository(repo, logger),
+		provider,
+		nil,
+		nil,
+		logger,
+	)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCreateBookEnrichment(t *testing.T) {
+	dune := &domain.BookMetadata{
+		ISBN:          "9780441172719",
+		Title:         "Dune",
+		Author:        "Frank Herbert",
+		PublishedYear: ptr(1965),
+		PageCount:     ptr(617),
+		Description:   "A desert planet.",
+	}
+
+	tests := []struct {
+		name  string
+		book  *domain.Book
+		delay time.Duration
+		want  *domain.Book
+	}{
+		{
+			name: "provider hit fills the missing fields",
+			book: &domain.Book{ISBN: ptr("9780441172719"), Status: domain.BookStatusSuggested},
+			want: &domain.Book{
+				Title:         "Dune",
+				Author:        "Frank Herbert",
+				ISBN:          ptr("9780441172719"),
+				PublishedYear: ptr(1965),
+				PageCount:     ptr(617),
+				Description:   ptr("A desert planet."),
+				Status:        domain.BookStatusSuggested,
+			},
+		},
+		{
+			name: "provider miss creates the book as is",
+			book: &domain.Book{Title: "Unknown", Author: "Nobody", Status: domain.BookStatusSaved},
+			want: &domain.Book{Title: "Unknown", Author: "Nobody", Status: domain.BookStatusSaved},
+		},
+		{
+			name:  "provider timeout creates the book as is",
+			book:  &domain.Book{Title: "Dune", Author: "Frank Herbert", Status: domain.BookStatusSaved},
+			delay: time.Second,
+			want:  &domain.Book{Title: "Dune", Author: "Frank Herbert", Status: domain.BookStatusSaved},
+		},
+		{
+			name: "fields supplied by the user are kept",
+			book: &domain.Book{
+				Title:         "Dune (Deluxe Edition)",
+				Author:        "F. Herbert",
+				ISBN:          ptr("9780441172719"),
+				PublishedYear: ptr(2019),
+				Status:        domain.BookStatusSaved,
+			},
+			want: &domain.Book{
+				Title:         "Dune (Deluxe Edition)",
+				Author:        "F. Herbert",
+				ISBN:          ptr("9780441172719"),
+				PublishedYear: ptr(2019),
+				PageCount:     ptr(617),
+				Description:   ptr("A desert planet."),
+				Status:        domain.BookStatusSaved,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := metadata.NewFakeProvider(dune)
+			provider.SetDelay(tt.delay)
+			b := newTestBookInteractor(t, provider)
+			b.enrichTimeout = 50 * time.Millisecond
+
+			start := time.Now()
+			got, err := b.CreateBook(context.Background(), tt.book)
+			if err != nil {
+				t.Fatalf("CreateBook() error = %v", err)
+			}
+			if elapsed := time.Since(start); tt.delay > 0 && elapsed >= tt.delay {
+				t.Errorf("CreateBook() took %v, want the lookup to time out", elapsed)
+			}
+
+			assertBook(t, got, tt.want)
+
+			stored, err := b.GetBook(context.Background(), got.ID)
+			if err != nil {
+				t.Fatalf("GetBook() error = %v", err)
+			}
+			assertBook(t, stored, tt.want)
+		})
+	}
+}
+
+// assertBook compares the fields of got that can be enriched with the ones of want.
+func assertBook(t *testing.T, got, want *domain.Book) {
+	t.Helper()
+
+	if got.Title != want.Title {
+		t.Errorf("Title = %q, want %q", got.Title, want.Title)
+	}
+	if got.Author != want.Author {
+		t.Errorf("Author = %q, want %q", got.Author, want.Author)
+	}
+	if got.Status != want.Status {
+		t.Errorf("Status = %q, want %q", got.Status, want.Status)
+	}
+	assertOptional(t, "ISBN", got.ISBN, want.ISBN)
+	assertOptional(t, "PublishedYear", got.PublishedYear, want.PublishedYear)
+	assertOptional(t, "PageCount", got.PageCount, want.PageCount)
+	assertOptional(t, "Description", got.Description, want.Description)
+	assertOptional(t, "Genre", got.Genre, want.Genre)
+}
+
+func assertOptional[T comparable](t *testing.T, field string, got, want *T) {
+	t.Helper()
+
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s = %v, want %v", field, got, want)
+	case *got != *want:
+		t.Errorf("%s = %v, want %v", field, *got, *want)
+	}
+}
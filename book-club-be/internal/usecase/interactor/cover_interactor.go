@@ -0,0 +1,306 @@
+package interactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+
+	_ "golang.org/x/image/webp" // register WebP decoder
+	_ "image/gif"               // register GIF decoder
+	_ "image/png"               // register PNG decoder
+)
+
+// maxCoverPixels bounds the decoded size of a cover, to reject decompression bombs.
+const maxCoverPixels = 40_000_000
+
+// supportedCoverFormats lists the image formats accepted as covers, as named by [image.DecodeConfig].
+var supportedCoverFormats = map[string]struct{}{
+	"jpeg": {},
+	"png":  {},
+	"gif":  {},
+	"webp": {},
+}
+
+// CoverInteractor provides the application logic for book cover images.
+type CoverInteractor struct {
+	repo   domain.BookRepository
+	blobs  domain.BlobStore
+	events domain.EventPublisher
+	logger *slog.Logger
+}
+
+// NewCoverInteractor creates a new CoverInteractor with the given repository,
+// blob store, optional event publisher and logger.
+func NewCoverInteractor(
+	repo domain.BookRepository,
+	blobs domain.BlobStore,
+	events domain.EventPublisher,
+	logger *slog.Logger,
+) *CoverInteractor {
+	return &CoverInteractor{
+		repo:   repo,
+		blobs:  blobs,
+		events: events,
+		logger: logger,
+	}
+}
+
+// SetCover stores the image read from r as the cover of the book identified
+// by bookID, replacing the previous cover and its thumbnails. The image format
+// is detected from its content. The book cover URL is updated to point to the
+// stored cover, with a version parameter so that cached copies are not reused.
+// The cover is stored under its version, and the previous one is deleted only
+// once the book points to the new one.
+func (c *CoverInteractor) SetCover(ctx context.Context, bookID string, r io.Reader) (*domain.Book, error) {
+	logger := logctx.FromContext(ctx, c.logger)
+	_, err := c.getBook(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, domain.ErrorUnsupportedImage
+	}
+	if _, ok := supportedCoverFormats[format]; !ok {
+		return nil, domain.ErrorUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxCoverPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", domain.ErrorImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	err = c.blobs.Put(ctx, coverKey(bookID, version, domain.CoverSizeOriginal), bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	// The book is read again within the transaction updating it, so that
+	// the changes made meanwhile are kept, and so that of two concurrent
+	// uploads, each one replaces the cover set by the other one.
+	var (
+		book     *domain.Book
+		previous string
+	)
+	coverURL := coverPath(bookID) + "?v=" + version
+	err = withinTransaction(ctx, c.repo, c.events, func(ctx context.Context) error {
+		return withEvent(ctx, c.repo, c.events, func(ctx context.Context) (*domain.Event, error) {
+			var err error
+			book, err = c.getBook(ctx, bookID)
+			if err != nil {
+				return nil, err
+			}
+			previous = coverVersion(book)
+			book.CoverURL = &coverURL
+
+			err = c.repo.Update(ctx, book)
+			if err != nil {
+				return nil, err
+			}
+			return &domain.Event{
+				Type:           domain.EventBookUpdated,
+				BookID:         book.ID,
+				Book:           book,
+				PreviousStatus: book.Status,
+			}, nil
+		})
+	})
+	if err != nil {
+		c.deleteCover(ctx, bookID, version)
+		return nil, err
+	}
+
+	c.deleteCover(ctx, bookID, previous)
+	if previous != "" {
+		// The previous cover may have been uploaded before covers were versioned.
+		c.deleteCover(ctx, bookID, "")
+	}
+	logger.With("id", bookID, "format", format, "size", len(content)).Info("cover uploaded")
+
+	return book, nil
+}
+
+// deleteCover removes the blobs of the given version of the cover of a book,
+// which must no longer be referenced by the book. Failures are only logged.
+func (c *CoverInteractor) deleteCover(ctx context.Context, bookID, version string) {
+	logger := logctx.FromContext(ctx, c.logger)
+	keys := []string{coverDir(bookID, version)}
+	if version == "" {
+		// The blobs of unversioned covers share the directory of the book
+		// with the versioned ones, so they are deleted one by one.
+		keys = []string{coverKey(bookID, "", domain.CoverSizeOriginal)}
+		for size := range domain.CoverSizes {
+			keys = append(keys, coverKey(bookID, "", size))
+		}
+	}
+
+	for _, key := range keys {
+		err := c.blobs.Delete(ctx, key)
+		if err != nil {
+			logger.With("error", err, "id", bookID, "key", key).Warn("unable to delete cover")
+		}
+	}
+}
+
+// GetCover opens the cover of the book identified by bookID in the given
+// size, one of [domain.CoverSizes] or [domain.CoverSizeOriginal]. Thumbnails
+// are generated as JPEG on first request and stored for later ones.
+func (c *CoverInteractor) GetCover(
+	ctx context.Context,
+	bookID, size string,
+) (io.ReadSeekCloser, *domain.BlobInfo, error) {
+	book, err := c.getBook(ctx, bookID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	version := coverVersion(book)
+	if size == domain.CoverSizeOriginal {
+		return c.getOriginal(ctx, bookID, version)
+	}
+
+	width, ok := domain.CoverSizes[size]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown cover size %q", size)
+	}
+
+	key := coverKey(bookID, version, size)
+	blob, info, err := c.blobs.Get(ctx, key)
+	if !errors.Is(err, domain.ErrorNotFound) {
+		return blob, info, err
+	}
+
+	err = c.generateThumbnail(ctx, bookID, version, key, width)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.blobs.Get(ctx, key)
+}
+
+// getOriginal opens the given version of the uploaded cover of a book.
+// Covers uploaded before they were versioned are found without a version.
+func (c *CoverInteractor) getOriginal(
+	ctx context.Context,
+	bookID, version string,
+) (io.ReadSeekCloser, *domain.BlobInfo, error) {
+	blob, info, err := c.blobs.Get(ctx, coverKey(bookID, version, domain.CoverSizeOriginal))
+	if errors.Is(err, domain.ErrorNotFound) && version != "" {
+		return c.blobs.Get(ctx, coverKey(bookID, "", domain.CoverSizeOriginal))
+	}
+
+	return blob, info, err
+}
+
+// generateThumbnail scales the given version of the original cover of a book
+// down to width and stores it under key.
+func (c *CoverInteractor) generateThumbnail(ctx context.Context, bookID, version, key string, width int) error {
+	logger := logctx.FromContext(ctx, c.logger)
+	original, _, err := c.getOriginal(ctx, bookID, version)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := original.Close()
+		if err != nil {
+			logger.With("error", err, "id", bookID).Error("unable to close cover")
+		}
+	}()
+
+	src, _, err := image.Decode(original)
+	if err != nil {
+		return err
+	}
+
+	b := src.Bounds()
+	if b.Dx() < width {
+		width = b.Dx()
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+
+	// JPEG has no transparency: transparent areas are drawn over white.
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	if err != nil {
+		return err
+	}
+
+	logger.With("id", bookID, "key", key).Info("cover thumbnail generated")
+
+	return c.blobs.Put(ctx, key, &buf)
+}
+
+// getBook retrieves the book identified by bookID, or [domain.ErrorNotFound].
+func (c *CoverInteractor) getBook(ctx context.Context, bookID string) (*domain.Book, error) {
+	books, err := c.repo.List(ctx, &domain.BookFilters{ID: &bookID})
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, domain.ErrorNotFound
+	}
+
+	return books[0], nil
+}
+
+// coverPath returns the path the cover of a book is served at.
+func coverPath(bookID string) string {
+	return "/v1/books/" + bookID + "/cover"
+}
+
+// coverVersion returns the version of the cover book.CoverURL points to, or
+// an empty string if the cover is not stored or was stored without a version.
+func coverVersion(book *domain.Book) string {
+	if book.CoverURL == nil {
+		return ""
+	}
+
+	u, err := url.Parse(*book.CoverURL)
+	if err != nil || u.Path != coverPath(book.ID) {
+		return ""
+	}
+
+	return u.Query().Get("v")
+}
+
+// coverPrefix returns the prefix of the blob keys of the covers of a book.
+func coverPrefix(bookID string) string {
+	return "covers/" + bookID + "/"
+}
+
+// coverDir returns the prefix of the blob keys of a version of the cover of a
+// book. Covers stored without a version are directly below [coverPrefix].
+func coverDir(bookID, version string) string {
+	if version == "" {
+		return coverPrefix(bookID)
+	}
+	return coverPrefix(bookID) + version + "/"
+}
+
+// coverKey returns the blob key of a version of the cover of a book in the given size.
+func coverKey(bookID, version, size string) string {
+	return coverDir(bookID, version) + size
+}
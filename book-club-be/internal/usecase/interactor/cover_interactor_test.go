@@ -0,0 +1,130 @@
+package interactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/blob"
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+)
+
+// newTestCovers returns a CoverInteractor storing the covers of the books of
+// repo below dir, together with its blob store and a new book.
+func newTestCovers(t *testing.T, repo *db.SQLiteBookRepository, dir string) (*CoverInteractor, *blob.LocalStore, *domain.Book) {
+	t.Helper()
+
+	logger := slog.New(slog.DiscardHandler)
+	blobs, err := blob.NewLocalStore(dir, logger)
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	book, err := repo.Create(context.Background(), &domain.Book{
+		Title: "Emma", Author: "Jane Austen", Status: domain.BookStatusSaved,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	return NewCoverInteractor(repo, blobs, nil, logger), blobs, book
+}
+
+// pngCover returns a small PNG image.
+func pngCover(t *testing.T) []byte {
+	t.Helper()
+
+	var img bytes.Buffer
+	err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 60, 90)))
+	if err != nil {
+		t.Fatalf("unable to encode image: %v", err)
+	}
+
+	return img.Bytes()
+}
+
+func TestConcurrentCoverUploadsKeepOneVersion(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	covers, _, book := newTestCovers(t, newTestRepo(t), dir)
+	img := pngCover(t)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := covers.SetCover(ctx, book.ID, bytes.NewReader(img))
+			if err != nil {
+				t.Errorf("SetCover() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := covers.getBook(ctx, book.ID)
+	if err != nil {
+		t.Fatalf("getBook() error = %v", err)
+	}
+	version := coverVersion(got)
+	if version == "" || got.Title != "Emma" {
+		t.Fatalf("book = %+v, want Emma with a versioned cover", got)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "covers", book.ID))
+	if err != nil {
+		t.Fatalf("unable to list the covers: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != version {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+		t.Errorf("stored covers = %v, want only the version %s the book points to", names, version)
+	}
+}
+
+func TestPurgeTrashDeletesCovers(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+	repo := newTestRepo(t)
+	dir := t.TempDir()
+	covers, blobs, book := newTestCovers(t, repo, dir)
+	b := NewBookInteractor(
+		repo,
+		db.NewSQLiteTagRepository(repo, logger),
+		db.NewSQLiteMemberRepository(repo, logger),
+		nil,
+		blobs,
+		nil,
+		logger,
+	)
+
+	_, err := covers.SetCover(ctx, book.ID, bytes.NewReader(pngCover(t)))
+	if err != nil {
+		t.Fatalf("SetCover() error = %v", err)
+	}
+	err = b.DeleteBook(ctx, book.ID)
+	if err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "covers", book.ID)); err != nil {
+		t.Fatalf("cover of the book in the trash: %v, want it kept until the book is purged", err)
+	}
+
+	count, err := b.PurgeTrash(ctx, -time.Minute)
+	if err != nil || count != 1 {
+		t.Fatalf("PurgeTrash() = %d, %v, want 1 book purged", count, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "covers", book.ID)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("cover of the purged book: %v, want it deleted", err)
+	}
+}
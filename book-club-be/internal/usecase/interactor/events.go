@@ -0,0 +1,70 @@
+package interactor
+
+import (
+	"context"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// outerTxKey is the context key marking the calls made within a transaction
+// started by withinTransaction.
+type outerTxKey struct{}
+
+// withinTransaction runs fn within a transaction of repo, which the
+// withEvent calls made by fn join, and notifies events, if not nil, once the
+// transaction commits.
+func withinTransaction(
+	ctx context.Context,
+	repo domain.BookRepository,
+	events domain.EventPublisher,
+	fn func(ctx context.Context) error,
+) error {
+	err := repo.WithinTransaction(context.WithValue(ctx, outerTxKey{}, true), fn)
+	if err != nil {
+		return err
+	}
+	if events != nil {
+		events.Notify()
+	}
+
+	return nil
+}
+
+// withEvent runs change within a transaction of repo and publishes the event
+// it returns in the same transaction, so that the event reaches the outbox
+// if and only if the change is stored. The publisher is notified once the
+// transaction commits: when ctx is bound to a transaction started by
+// withinTransaction, which change joins, it is notified by
+// withinTransaction instead, as the event is only committed with the outer
+// transaction. When events is nil, change runs alone and its event is dropped.
+func withEvent(
+	ctx context.Context,
+	repo domain.BookRepository,
+	events domain.EventPublisher,
+	change func(ctx context.Context) (*domain.Event, error),
+) error {
+	if events == nil {
+		_, err := change(ctx)
+		return err
+	}
+
+	err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		event, err := change(ctx)
+		if err != nil {
+			return err
+		}
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now()
+		}
+		return events.Publish(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.Value(outerTxKey{}) == nil {
+		events.Notify()
+	}
+
+	return nil
+}
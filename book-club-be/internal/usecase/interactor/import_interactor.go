@@ -0,0 +1,130 @@
+package interactor
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// parenthesized matches the series information Goodreads appends to titles, e.g. "Dune (Dune #1)".
+var parenthesized = regexp.MustCompile(`\s*\([^)]*\)\s*$`)
+
+// ImportBooks creates the books described by records, skipping the ones
+// already in the repository or repeated in the import. Two books are the
+// same when they share an ISBN or when their normalized title and author
+// match. Books breaking the domain rules are reported as failed. Unlike
+// CreateBook, imported books may have any status, as they reflect the
+// members' reading history.
+func (b *BookInteractor) ImportBooks(ctx context.Context, records []*domain.ImportRecord) (*domain.ImportReport, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.ImportBooks")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	existing, err := b.repo.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	seenKeys := make(map[string]string, len(existing))
+	seenISBNs := make(map[string]string, len(existing))
+	for _, book := range existing {
+		seenKeys[dedupKey(book)] = book.ID
+		if book.ISBN != nil {
+			seenISBNs[*book.ISBN] = book.ID
+		}
+	}
+
+	report := &domain.ImportReport{
+		Created: make([]domain.ImportResult, 0),
+		Skipped: make([]domain.ImportResult, 0),
+		Failed:  make([]domain.ImportResult, 0),
+	}
+
+	for _, rec := range records {
+		if rec.Err != nil {
+			report.Failed = append(report.Failed, domain.ImportResult{Line: rec.Line, Reason: rec.Err.Error()})
+			continue
+		}
+
+		if rec.ISBN != "" {
+			rec.Book.ISBN = &rec.ISBN
+		}
+		rec.Book.Normalize()
+		res := domain.ImportResult{Line: rec.Line, Title: rec.Book.Title, Author: rec.Book.Author}
+		err := rec.Book.Validate()
+		if err != nil {
+			res.Reason = err.Error()
+			report.Failed = append(report.Failed, res)
+			continue
+		}
+
+		key := dedupKey(rec.Book)
+		if id, ok := seenKeys[key]; ok {
+			res.BookID, res.Reason = id, "duplicate title and author"
+			report.Skipped = append(report.Skipped, res)
+			continue
+		}
+		if id, ok := seenISBNs[rec.ISBN]; ok && rec.ISBN != "" {
+			res.BookID, res.Reason = id, "duplicate ISBN"
+			report.Skipped = append(report.Skipped, res)
+			continue
+		}
+
+		var book *domain.Book
+		err = withEvent(ctx, b.repo, b.events, func(ctx context.Context) (*domain.Event, error) {
+			created, err := b.repo.Create(ctx, rec.Book)
+			if err != nil {
+				return nil, err
+			}
+			book = created
+			return &domain.Event{Type: domain.EventBookCreated, BookID: book.ID, Book: book}, nil
+		})
+		if err != nil {
+			logger.With("error", err, "line", rec.Line).Error("unable to import book")
+			res.Reason = "unable to store book"
+			report.Failed = append(report.Failed, res)
+			continue
+		}
+
+		res.BookID = book.ID
+		seenKeys[key] = book.ID
+		if rec.ISBN != "" {
+			seenISBNs[rec.ISBN] = book.ID
+		}
+		report.Created = append(report.Created, res)
+	}
+
+	logger.With(
+		"created", len(report.Created),
+		"skipped", len(report.Skipped),
+		"failed", len(report.Failed),
+	).Info("books imported")
+
+	return report, nil
+}
+
+// dedupKey returns the normalized title and author of book, so that
+// "Invisible Cities" by "Calvino, Italo" and "invisible cities" by
+// "Italo Calvino" are recognized as the same book.
+func dedupKey(book *domain.Book) string {
+	titleWords := strings.Fields(normalizeWords(parenthesized.ReplaceAllString(book.Title, "")))
+	authorWords := strings.Fields(normalizeWords(book.Author))
+	slices.Sort(authorWords)
+
+	return strings.Join(titleWords, " ") + "|" + strings.Join(authorWords, " ")
+}
+
+// normalizeWords lowercases s and replaces every character that is not a letter or a digit with a space.
+func normalizeWords(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+}
@@ -0,0 +1,84 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// MemberInteractor provides the application logic for managing the members
+// of the club and their notification preferences.
+type MemberInteractor struct {
+	members domain.MemberRepository
+	logger  *slog.Logger
+}
+
+// NewMemberInteractor creates a new MemberInteractor with the given repository and logger.
+func NewMemberInteractor(members domain.MemberRepository, logger *slog.Logger) *MemberInteractor {
+	return &MemberInteractor{
+		members: members,
+		logger:  logger,
+	}
+}
+
+// CreateMember validates and stores a new member.
+func (m *MemberInteractor) CreateMember(ctx context.Context, member *domain.Member) (*domain.Member, error) {
+	ctx, span := tracer.Start(ctx, "MemberInteractor.CreateMember")
+	defer span.End()
+
+	if member == nil {
+		return nil, errors.New("empty member info")
+	}
+	member.Name = strings.TrimSpace(member.Name)
+	member.Email = domain.NormalizeEmail(member.Email)
+	err := member.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.members.Create(ctx, member)
+}
+
+// ListMembers retrieves all the members, ordered by name.
+func (m *MemberInteractor) ListMembers(ctx context.Context) ([]*domain.Member, error) {
+	return m.members.List(ctx)
+}
+
+// UpdateMember applies patch to the member identified by id, validates the
+// result and stores it. It returns [domain.ErrorNotFound] if the member does not exist.
+func (m *MemberInteractor) UpdateMember(
+	ctx context.Context,
+	id string,
+	patch *domain.MemberPatch,
+) (*domain.Member, error) {
+	ctx, span := tracer.Start(ctx, "MemberInteractor.UpdateMember")
+	defer span.End()
+
+	member, err := m.members.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patch.Apply(member)
+	member.Name = strings.TrimSpace(member.Name)
+	member.Email = domain.NormalizeEmail(member.Email)
+	err = member.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.members.Update(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// DeleteMember removes the member identified by id. The emails not yet sent
+// to them are discarded.
+func (m *MemberInteractor) DeleteMember(ctx context.Context, id string) error {
+	return m.members.Delete(ctx, id)
+}
@@ -0,0 +1,315 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// notificationPage is the number of due notifications sent at once.
+const notificationPage = 20
+
+// NotificationInteractor provides the application logic for emailing the
+// members: a notification when a book is suggested and when the club decides
+// on a suggested book, and a weekly digest of the activity of the club.
+// Failed emails are retried like the webhook deliveries.
+type NotificationInteractor struct {
+	members   domain.MemberRepository
+	queue     domain.NotificationRepository
+	events    domain.EventLog
+	templates domain.EmailTemplates
+	mailer    domain.Mailer
+	logger    *slog.Logger
+	// wake signals Run that new notifications are due.
+	wake chan struct{}
+	// digestWeek is the last week whose digests were queued, used by Run
+	// to queue the digests once a week.
+	digestWeek string
+}
+
+// NewNotificationInteractor creates a new NotificationInteractor emailing the
+// members with mailer. The notifications are rendered with templates and
+// queued in queue, and the digests are built from the events of events.
+func NewNotificationInteractor(
+	members domain.MemberRepository,
+	queue domain.NotificationRepository,
+	events domain.EventLog,
+	templates domain.EmailTemplates,
+	mailer domain.Mailer,
+	logger *slog.Logger,
+) *NotificationInteractor {
+	return &NotificationInteractor{
+		members:   members,
+		queue:     queue,
+		events:    events,
+		templates: templates,
+		mailer:    mailer,
+		logger:    logger,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// HandleEvent queues a notification to the interested members when event
+// suggests a book, by creating it with or updating it to the SUGGESTED
+// status, or closes the vote on a suggested book, by updating it from the
+// SUGGESTED status to READING or DISCARDED. An event handled again is not
+// notified twice. It implements [domain.EventHandler].
+func (n *NotificationInteractor) HandleEvent(ctx context.Context, event *domain.Event) error {
+	if event.Book == nil {
+		return nil
+	}
+
+	var kind domain.NotificationKind
+	switch {
+	case event.Type == domain.EventBookCreated && event.Book.Status == domain.BookStatusSuggested:
+		kind = domain.NotificationSuggestion
+	case event.Type != domain.EventBookUpdated:
+		return nil
+	case event.PreviousStatus != domain.BookStatusSuggested && event.Book.Status == domain.BookStatusSuggested:
+		kind = domain.NotificationSuggestion
+	case event.PreviousStatus == domain.BookStatusSuggested &&
+		(event.Book.Status == domain.BookStatusReading || event.Book.Status == domain.BookStatusDiscarded):
+		kind = domain.NotificationDecision
+	default:
+		return nil
+	}
+
+	members, err := n.members.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	queued := false
+	for _, member := range members {
+		if !member.Notifications.Wants(kind) {
+			continue
+		}
+
+		var email *domain.Email
+		if kind == domain.NotificationSuggestion {
+			email, err = n.templates.Suggestion(member, event.Book)
+		} else {
+			email, err = n.templates.Decision(member, event.Book)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = n.queue.Enqueue(ctx, &domain.Notification{
+			MemberID: member.ID,
+			Kind:     kind,
+			DedupKey: fmt.Sprintf("%s:%d:%s", kind, event.ID, member.ID),
+			Email:    email,
+		})
+		if err != nil {
+			return err
+		}
+		queued = true
+	}
+	if queued {
+		n.signal()
+	}
+
+	return nil
+}
+
+// signal wakes Run up, unless it is already due to wake up.
+func (n *NotificationInteractor) signal() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// EnqueueDigests queues the digest of the last full week before now, from
+// Monday to Sunday in UTC, to the members who want it. Nothing is queued if
+// nothing happened that week, and a member is sent the digest of a week once.
+// It returns the ISO week of the digest, such as "2025-W07".
+func (n *NotificationInteractor) EnqueueDigests(ctx context.Context, now time.Time) (string, error) {
+	ctx, span := tracer.Start(ctx, "NotificationInteractor.EnqueueDigests")
+	defer span.End()
+
+	from, to, name := lastWeek(now)
+	digest := &domain.Digest{From: from, To: to}
+
+	events, err := n.events.ListSince(ctx, digest.From)
+	if err != nil {
+		return "", err
+	}
+	collectDigest(digest, events)
+	if digest.Empty() {
+		return name, nil
+	}
+
+	members, err := n.members.List(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	queued := false
+	for _, member := range members {
+		if !member.Notifications.Wants(domain.NotificationDigest) {
+			continue
+		}
+
+		email, err := n.templates.Digest(member, digest)
+		if err != nil {
+			return "", err
+		}
+		err = n.queue.Enqueue(ctx, &domain.Notification{
+			MemberID: member.ID,
+			Kind:     domain.NotificationDigest,
+			DedupKey: fmt.Sprintf("%s:%s:%s", domain.NotificationDigest, name, member.ID),
+			Email:    email,
+		})
+		if err != nil {
+			return "", err
+		}
+		queued = true
+	}
+	if queued {
+		n.signal()
+	}
+
+	return name, nil
+}
+
+// lastWeek returns the start and the end, in UTC, and the ISO name of the
+// last full week before now.
+func lastWeek(now time.Time) (time.Time, time.Time, string) {
+	now = now.UTC()
+	days := (int(now.Weekday()) + 6) % 7
+	to := time.Date(now.Year(), now.Month(), now.Day()-days, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -7)
+	year, week := from.ISOWeek()
+
+	return from, to, fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// collectDigest adds to digest the books whose status changed with the
+// events occurred over its period. A book appears once in each list.
+func collectDigest(digest *domain.Digest, events []*domain.Event) {
+	seen := make(map[domain.BookStatus]map[string]bool)
+	for _, event := range events {
+		if event.Book == nil || !event.OccurredAt.Before(digest.To) {
+			continue
+		}
+		if event.Type != domain.EventBookCreated &&
+			(event.Type != domain.EventBookUpdated || event.PreviousStatus == event.Book.Status) {
+			continue
+		}
+
+		status := event.Book.Status
+		if seen[status] == nil {
+			seen[status] = make(map[string]bool)
+		}
+		if seen[status][event.BookID] {
+			continue
+		}
+		seen[status][event.BookID] = true
+
+		switch status {
+		case domain.BookStatusSuggested:
+			digest.Suggested = append(digest.Suggested, event.Book)
+		case domain.BookStatusReading:
+			digest.Started = append(digest.Started, event.Book)
+		case domain.BookStatusCompleted:
+			digest.Completed = append(digest.Completed, event.Book)
+		case domain.BookStatusDiscarded:
+			digest.Discarded = append(digest.Discarded, event.Book)
+		}
+	}
+}
+
+// SendDue sends the notifications whose next attempt is due. Failed
+// attempts are retried with the backoff of the webhook deliveries until
+// maxDeliveryAttempts is reached, when the notification is marked as failed.
+func (n *NotificationInteractor) SendDue(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "NotificationInteractor.SendDue")
+	defer span.End()
+
+	for {
+		due, err := n.queue.Due(ctx, time.Now(), notificationPage)
+		if err != nil {
+			return err
+		}
+
+		for _, notification := range due {
+			err = n.send(ctx, notification)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(due) < notificationPage {
+			return nil
+		}
+	}
+}
+
+// send emails notification and stores the outcome.
+func (n *NotificationInteractor) send(ctx context.Context, notification *domain.Notification) error {
+	logger := logctx.FromContext(ctx, n.logger).With("notification_id", notification.ID, "kind", notification.Kind)
+	now := time.Now()
+	notification.Attempts++
+	notification.NextAttemptAt = nil
+
+	err := n.mailer.Send(ctx, notification.Email)
+	switch {
+	case err == nil:
+		notification.Status = domain.DeliverySucceeded
+		notification.SentAt = &now
+		notification.LastError = ""
+	case notification.Attempts >= maxDeliveryAttempts:
+		logger.With("error", err, "attempts", notification.Attempts).Warn("giving up notification")
+		notification.Status = domain.DeliveryFailed
+		notification.LastError = err.Error()
+	default:
+		logger.With("error", err, "attempts", notification.Attempts).Info("notification failed, retrying")
+		next := now.Add(deliveryDelay(notification.Attempts))
+		notification.NextAttemptAt = &next
+		notification.LastError = err.Error()
+	}
+
+	return n.queue.Update(ctx, notification)
+}
+
+// Run queues the weekly digests once a week and sends the due notifications
+// every interval, and as soon as new notifications are queued, until ctx is
+// canceled.
+func (n *NotificationInteractor) Run(ctx context.Context, interval time.Duration) {
+	logger := logctx.FromContext(ctx, n.logger)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, _, week := lastWeek(time.Now()); week != n.digestWeek {
+			name, err := n.EnqueueDigests(ctx, time.Now())
+			if err != nil {
+				logger.With("error", err).Error("failed to queue digests")
+			} else {
+				n.digestWeek = name
+			}
+		}
+
+		err := n.SendDue(ctx)
+		if err != nil {
+			logger.With("error", err).Error("failed to send notifications")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-n.wake:
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package interactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+	"github.com/Michela-DC/book-club/internal/infrastructure/email"
+)
+
+// smtpMessage is a message received by an smtpServer.
+type smtpMessage struct {
+	// Auth is the decoded PLAIN authentication of the session.
+	Auth string
+	From string
+	To   []string
+	Data []byte
+}
+
+// smtpServer is a fake SMTP server accepting the messages, except for the
+// first reject ones whose recipients are refused with a transient error.
+type smtpServer struct {
+	t        *testing.T
+	listener net.Listener
+
+	mu       sync.Mutex
+	reject   int
+	messages []smtpMessage
+}
+
+// newSMTPServer starts an smtpServer on a local port, stopped when the test ends.
+func newSMTPServer(t *testing.T, reject int) *smtpServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	s := &smtpServer{t: t, listener: l, reject: reject}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// serve talks SMTP over conn until the client quits.
+func (s *smtpServer) serve(conn net.Conn) {
+	c := textproto.NewConn(conn)
+	defer c.Close()
+
+	var msg smtpMessage
+	_ = c.PrintfLine("220 localhost fake ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			_ = c.PrintfLine("250-localhost\r\n250 AUTH PLAIN")
+		case "AUTH":
+			mechanism, response, _ := strings.Cut(arg, " ")
+			auth, err := base64.StdEncoding.DecodeString(response)
+			if mechanism != "PLAIN" || err != nil {
+				_ = c.PrintfLine("504 unsupported authentication")
+				continue
+			}
+			msg.Auth = string(auth)
+			_ = c.PrintfLine("235 authenticated")
+		case "MAIL":
+			msg.From = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			_ = c.PrintfLine("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			rejected := s.reject > 0
+			if rejected {
+				s.reject--
+			}
+			s.mu.Unlock()
+			if rejected {
+				_ = c.PrintfLine("451 try again later")
+				continue
+			}
+			msg.To = append(msg.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = c.PrintfLine("250 ok")
+		case "DATA":
+			_ = c.PrintfLine("354 end with a dot")
+			msg.Data, err = io.ReadAll(c.DotReader())
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = smtpMessage{Auth: msg.Auth}
+			_ = c.PrintfLine("250 queued")
+		case "QUIT":
+			_ = c.PrintfLine("221 bye")
+			return
+		default:
+			_ = c.PrintfLine("250 ok")
+		}
+	}
+}
+
+// received returns the messages received.
+func (s *smtpServer) received() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+// parseEmail parses data as sent by an [email.SMTPMailer], returning its
+// headers and its plain text and HTML parts.
+func parseEmail(t *testing.T, data []byte) (mail.Header, string, string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unable to parse email: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	parts := make(map[string]string)
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unable to read email part: %v", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("unable to read email part: %v", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(content)
+	}
+
+	return msg.Header, parts["text/plain"], parts["text/html"]
+}
+
+func TestNotificationsAreEmailedThroughSMTP(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+	repo := newTestRepo(t)
+	members := db.NewSQLiteMemberRepository(repo, logger)
+	queue := db.NewSQLiteNotificationRepository(repo, time.Hour, logger)
+	templates, err := email.NewTemplates()
+	if err != nil {
+		t.Fatalf("NewTemplates() error = %v", err)
+	}
+
+	for _, m := range []*domain.Member{
+		{Name: "Ada", Email: "ada@example.com", Notifications: domain.NotificationPreferences{Suggestions: true}},
+		{Name: "Grace", Email: "grace@example.com", Notifications: domain.NotificationPreferences{Decisions: true}},
+	} {
+		_, err = members.Create(ctx, m)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	server := newSMTPServer(t, 1)
+	mailer, err := email.NewSMTPMailer(server.listener.Addr().String(), "club", "hunter2", "Book Club <club@example.com>")
+	if err != nil {
+		t.Fatalf("NewSMTPMailer() error = %v", err)
+	}
+	n := NewNotificationInteractor(members, queue, db.NewSQLiteEventLog(repo, time.Hour, logger), templates, mailer, logger)
+
+	// The event is handled twice when the dispatcher fails to record it.
+	event := suggested(3)
+	for range 2 {
+		err = n.HandleEvent(ctx, event)
+		if err != nil {
+			t.Fatalf("HandleEvent() error = %v", err)
+		}
+	}
+
+	// The server refuses the first attempt with a transient error.
+	err = n.SendDue(ctx)
+	if err != nil {
+		t.Fatalf("SendDue() error = %v", err)
+	}
+	if got := server.received(); len(got) != 0 {
+		t.Fatalf("server received %d emails, want the first attempt refused", len(got))
+	}
+	retries, err := queue.Due(ctx, time.Now().Add(deliveryBackoff+time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Due() error = %v", err)
+	}
+	if len(retries) != 1 || retries[0].Attempts != 1 || !strings.Contains(retries[0].LastError, "451") {
+		t.Fatalf("notifications to retry = %+v, want one after a first attempt refused with 451", retries)
+	}
+	if due, _ := queue.Due(ctx, time.Now(), 10); len(due) != 0 {
+		t.Fatalf("due notifications = %d, want the retry delayed by the backoff", len(due))
+	}
+
+	past := time.Now().Add(-time.Second)
+	retries[0].NextAttemptAt = &past
+	err = queue.Update(ctx, retries[0])
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	for range 2 {
+		err = n.SendDue(ctx)
+		if err != nil {
+			t.Fatalf("SendDue() error = %v", err)
+		}
+	}
+
+	got := server.received()
+	if len(got) != 1 {
+		t.Fatalf("server received %d emails, want one to the member interested in suggestions", len(got))
+	}
+	msg := got[0]
+	if msg.Auth != "\x00club\x00hunter2" {
+		t.Errorf("authentication = %q, want PLAIN with the configured credentials", msg.Auth)
+	}
+	if msg.From != "club@example.com" || len(msg.To) != 1 || msg.To[0] != "ada@example.com" {
+		t.Errorf("envelope from %q to %v, want from club@example.com to ada@example.com", msg.From, msg.To)
+	}
+
+	header, text, html := parseEmail(t, msg.Data)
+	if from, err := header.AddressList("From"); err != nil || from[0].Address != "club@example.com" {
+		t.Errorf("From = %q, want the club address", header.Get("From"))
+	}
+	if to := header.Get("To"); to != "ada@example.com" {
+		t.Errorf("To = %q, want ada@example.com", to)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if err != nil || subject != "New suggestion: Emma" {
+		t.Errorf("Subject = %q, want %q", subject, "New suggestion: Emma")
+	}
+	if !strings.Contains(text, "Emma") || !strings.Contains(text, "Jane Austen") {
+		t.Errorf("text part = %q, want the suggested book", text)
+	}
+	if !strings.Contains(html, "<") || !strings.Contains(html, "Emma") {
+		t.Errorf("HTML part = %q, want the suggested book in HTML", html)
+	}
+}
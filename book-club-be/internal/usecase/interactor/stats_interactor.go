@@ -0,0 +1,31 @@
+package interactor
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+)
+
+// StatsInteractor provides the application logic for reporting the reading
+// statistics of the club.
+type StatsInteractor struct {
+	stats  domain.StatsRepository
+	logger *slog.Logger
+}
+
+// NewStatsInteractor creates a new StatsInteractor with the given repository and logger.
+func NewStatsInteractor(stats domain.StatsRepository, logger *slog.Logger) *StatsInteractor {
+	return &StatsInteractor{
+		stats:  stats,
+		logger: logger,
+	}
+}
+
+// ReadingStats computes the reading statistics of the books not in the trash.
+func (s *StatsInteractor) ReadingStats(ctx context.Context) (*domain.ReadingStats, error) {
+	ctx, span := tracer.Start(ctx, "StatsInteractor.ReadingStats")
+	defer span.End()
+
+	return s.stats.ReadingStats(ctx)
+}
@@ -0,0 +1,105 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// TagInteractor provides the application logic for managing the tag vocabulary.
+type TagInteractor struct {
+	tags   domain.TagRepository
+	logger *slog.Logger
+}
+
+// NewTagInteractor creates a new TagInteractor with the given repository and logger.
+func NewTagInteractor(tags domain.TagRepository, logger *slog.Logger) *TagInteractor {
+	return &TagInteractor{
+		tags:   tags,
+		logger: logger,
+	}
+}
+
+// ListTags retrieves all tags, ordered by name.
+func (t *TagInteractor) ListTags(ctx context.Context) ([]*domain.Tag, error) {
+	return t.tags.List(ctx)
+}
+
+// CreateTag adds a new tag to the vocabulary, optionally under a parent tag.
+func (t *TagInteractor) CreateTag(ctx context.Context, tag *domain.Tag) (*domain.Tag, error) {
+	if tag == nil {
+		return nil, errors.New("empty tag info")
+	}
+	tag.Name = strings.TrimSpace(tag.Name)
+	err := tag.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err = t.tags.Create(ctx, tag)
+	if err != nil {
+		return nil, parentError(err)
+	}
+
+	return tag, nil
+}
+
+// UpdateTag renames or moves an existing tag. The books tagged with it are
+// not affected, as they refer to the tag itself rather than to its name.
+func (t *TagInteractor) UpdateTag(ctx context.Context, tag *domain.Tag) (*domain.Tag, error) {
+	if tag == nil {
+		return nil, errors.New("empty tag info")
+	}
+	tag.Name = strings.TrimSpace(tag.Name)
+	err := tag.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.tags.Update(ctx, tag)
+	if err != nil {
+		return nil, parentError(err)
+	}
+
+	return tag, nil
+}
+
+// parentError reports the errors caused by the parent of a tag as a
+// [domain.ValidationError] of its parent_id field.
+func parentError(err error) error {
+	var v domain.ValidationError
+	switch {
+	case errors.Is(err, domain.ErrorUnknownTag):
+		v.Add("parent_id", domain.ValidationUnknown, "parent_id must be an existing tag")
+	case errors.Is(err, domain.ErrorTagCycle):
+		v.Add("parent_id", domain.ValidationCycle, err.Error())
+	default:
+		return err
+	}
+
+	return &v
+}
+
+// MergeTags merges the tags identified by sourceIDs into the tag identified
+// by targetID, which takes over their books and children.
+func (t *TagInteractor) MergeTags(ctx context.Context, targetID string, sourceIDs []string) error {
+	logger := logctx.FromContext(ctx, t.logger)
+	if len(sourceIDs) == 0 {
+		var v domain.ValidationError
+		v.Add("tag_ids", domain.ValidationRequired, "tag_ids is required")
+		return &v
+	}
+
+	err := t.tags.Merge(ctx, targetID, sourceIDs)
+	if err != nil {
+		return err
+	}
+
+	logger.With("id", targetID, "merged", sourceIDs).Info("tags merged")
+
+	return nil
+}
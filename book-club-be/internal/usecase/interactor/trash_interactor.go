@@ -0,0 +1,106 @@
+package interactor
+
+import (
+	"context"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+// RestoreBook moves the book identified by bookID out of the trash. It
+// returns [domain.ErrorNotFound] if the book is not in the trash, and a
+// [domain.DuplicateISBNError] if its ISBN was taken by another book meanwhile.
+func (b *BookInteractor) RestoreBook(ctx context.Context, bookID string) (*domain.Book, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.RestoreBook")
+	defer span.End()
+
+	book, err := b.getTrashedBook(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = withEvent(ctx, b.repo, b.events, func(ctx context.Context) (*domain.Event, error) {
+		err := b.checkISBNAvailable(ctx, book)
+		if err != nil {
+			return nil, err
+		}
+		err = b.repo.Restore(ctx, bookID)
+		if err != nil {
+			return nil, err
+		}
+		book.DeletedAt = nil
+		return &domain.Event{Type: domain.EventBookRestored, BookID: book.ID, Book: book}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// getTrashedBook retrieves the book in the trash identified by bookID, or
+// returns [domain.ErrorNotFound].
+func (b *BookInteractor) getTrashedBook(ctx context.Context, bookID string) (*domain.Book, error) {
+	books, err := b.repo.List(ctx, &domain.BookFilters{ID: &bookID, Trashed: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, domain.ErrorNotFound
+	}
+
+	return books[0], nil
+}
+
+// PurgeTrash permanently removes the books that have been in the trash for
+// longer than retention, together with their covers, and returns how many
+// were removed. Failures to delete the covers are only logged.
+func (b *BookInteractor) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	ctx, span := tracer.Start(ctx, "BookInteractor.PurgeTrash")
+	defer span.End()
+
+	logger := logctx.FromContext(ctx, b.logger)
+	ids, err := b.repo.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) > 0 {
+		logger.With("count", len(ids), "retention", retention.String()).Info("trash purged")
+	}
+
+	if b.blobs != nil {
+		for _, id := range ids {
+			err = b.blobs.Delete(ctx, coverPrefix(id))
+			if err != nil {
+				logger.With("error", err, "id", id, "key", coverPrefix(id)).Warn("unable to delete cover")
+			}
+		}
+	}
+
+	return len(ids), nil
+}
+
+// SchedulePurge purges the books older than retention from the trash every
+// interval until ctx is canceled.
+func (b *BookInteractor) SchedulePurge(ctx context.Context, interval, retention time.Duration) {
+	logger := logctx.FromContext(ctx, b.logger)
+	if interval <= 0 || retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := b.PurgeTrash(ctx, retention)
+			if err != nil {
+				logger.With("error", err).Error("scheduled trash purge failed")
+			}
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package interactor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/pkg/logctx"
+)
+
+const (
+	// maxDeliveryAttempts is the number of attempts after which a delivery is given up.
+	maxDeliveryAttempts = 8
+	// deliveryBackoff is the wait before the second attempt of a delivery,
+	// doubled after each further failure up to maxDeliveryBackoff.
+	deliveryBackoff    = 30 * time.Second
+	maxDeliveryBackoff = 6 * time.Hour
+	// deliveryPage is the number of due deliveries attempted at once.
+	deliveryPage = 20
+)
+
+// WebhookInteractor provides the application logic for managing the webhooks
+// and delivering to them the lifecycle events of the books.
+type WebhookInteractor struct {
+	hooks  domain.WebhookRepository
+	sender domain.WebhookSender
+	logger *slog.Logger
+	// wake signals Run that new deliveries are due.
+	wake chan struct{}
+}
+
+// NewWebhookInteractor creates a new WebhookInteractor storing the webhooks
+// and their deliveries in hooks and sending the deliveries with sender.
+func NewWebhookInteractor(
+	hooks domain.WebhookRepository,
+	sender domain.WebhookSender,
+	logger *slog.Logger,
+) *WebhookInteractor {
+	return &WebhookInteractor{
+		hooks:  hooks,
+		sender: sender,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// CreateWebhook validates and stores a new webhook. A random secret is
+// generated when none is given.
+func (w *WebhookInteractor) CreateWebhook(ctx context.Context, hook *domain.Webhook) (*domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookInteractor.CreateWebhook")
+	defer span.End()
+
+	if hook == nil {
+		return nil, errors.New("empty webhook info")
+	}
+	hook.URL = strings.TrimSpace(hook.URL)
+	err := hook.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if hook.Secret == "" {
+		secret := make([]byte, 32)
+		_, err = rand.Read(secret)
+		if err != nil {
+			return nil, err
+		}
+		hook.Secret = hex.EncodeToString(secret)
+	}
+
+	return w.hooks.Create(ctx, hook)
+}
+
+// ListWebhooks retrieves all the webhooks, oldest first.
+func (w *WebhookInteractor) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	return w.hooks.List(ctx)
+}
+
+// DeleteWebhook removes the webhook identified by id together with its
+// deliveries, including the pending ones.
+func (w *WebhookInteractor) DeleteWebhook(ctx context.Context, id string) error {
+	return w.hooks.Delete(ctx, id)
+}
+
+// ListDeliveries retrieves the latest deliveries of the webhook identified
+// by webhookID, newest first.
+func (w *WebhookInteractor) ListDeliveries(ctx context.Context, webhookID string) ([]*domain.WebhookDelivery, error) {
+	_, err := w.hooks.Get(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.hooks.ListDeliveries(ctx, webhookID)
+}
+
+// Redeliver queues a new delivery of the same event as the delivery
+// identified by deliveryID, which must belong to the webhook identified by
+// webhookID, and returns it. The original delivery is left unchanged.
+func (w *WebhookInteractor) Redeliver(
+	ctx context.Context,
+	webhookID, deliveryID string,
+) (*domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookInteractor.Redeliver")
+	defer span.End()
+
+	original, err := w.hooks.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original.WebhookID != webhookID {
+		return nil, domain.ErrorNotFound
+	}
+
+	delivery := &domain.WebhookDelivery{
+		WebhookID:    original.WebhookID,
+		EventID:      original.EventID,
+		RedeliveryOf: original.ID,
+		Event:        original.Event,
+		BookID:       original.BookID,
+		Book:         original.Book,
+		OccurredAt:   original.OccurredAt,
+	}
+	err = w.hooks.Enqueue(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	w.signal()
+
+	return delivery, nil
+}
+
+// HandleEvent queues a delivery to the subscribed webhooks when event starts
+// a step of the life of a book: a book created with, or updated to, one of
+// the statuses of [domain.WebhookEvents]. An event handled again is not
+// delivered twice. It implements [domain.EventHandler].
+func (w *WebhookInteractor) HandleEvent(ctx context.Context, event *domain.Event) error {
+	if event.Book == nil {
+		return nil
+	}
+	switch {
+	case event.Type == domain.EventBookCreated:
+	case event.Type == domain.EventBookUpdated && event.PreviousStatus != event.Book.Status:
+	default:
+		return nil
+	}
+	webhookEvent, ok := domain.WebhookEvents[event.Book.Status]
+	if !ok {
+		return nil
+	}
+
+	hooks, err := w.hooks.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	queued := false
+	for _, hook := range hooks {
+		if !hook.Subscribed(webhookEvent) {
+			continue
+		}
+		err = w.hooks.Enqueue(ctx, &domain.WebhookDelivery{
+			WebhookID:  hook.ID,
+			EventID:    event.ID,
+			Event:      webhookEvent,
+			BookID:     event.BookID,
+			Book:       event.Book,
+			OccurredAt: event.OccurredAt,
+		})
+		if err != nil {
+			return err
+		}
+		queued = true
+	}
+	if queued {
+		w.signal()
+	}
+
+	return nil
+}
+
+// signal wakes Run up, unless it is already due to wake up.
+func (w *WebhookInteractor) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// DeliverDue attempts the deliveries whose next attempt is due. Failed
+// attempts are retried with an exponential backoff until
+// maxDeliveryAttempts is reached, when the delivery is marked as failed.
+func (w *WebhookInteractor) DeliverDue(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "WebhookInteractor.DeliverDue")
+	defer span.End()
+
+	hooks := make(map[string]*domain.Webhook)
+	for {
+		due, err := w.hooks.DueDeliveries(ctx, time.Now(), deliveryPage)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range due {
+			hook, ok := hooks[d.WebhookID]
+			if !ok {
+				hook, err = w.hooks.Get(ctx, d.WebhookID)
+				if err != nil && !errors.Is(err, domain.ErrorNotFound) {
+					return err
+				}
+				hooks[d.WebhookID] = hook
+			}
+
+			err = w.attempt(ctx, hook, d)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(due) < deliveryPage {
+			return nil
+		}
+	}
+}
+
+// attempt sends delivery to hook and stores the outcome. A delivery whose
+// webhook no longer exists is marked as failed.
+func (w *WebhookInteractor) attempt(ctx context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) error {
+	logger := logctx.FromContext(ctx, w.logger).With("delivery_id", delivery.ID, "webhook_id", delivery.WebhookID)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.NextAttemptAt = nil
+
+	var err error
+	if hook == nil {
+		delivery.ResponseStatus = 0
+		err = errors.New("webhook deleted")
+		delivery.Attempts = maxDeliveryAttempts
+	} else {
+		delivery.ResponseStatus, err = w.sender.Send(ctx, hook, delivery)
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+	case delivery.Attempts >= maxDeliveryAttempts:
+		logger.With("error", err, "attempts", delivery.Attempts).Warn("giving up webhook delivery")
+		delivery.Status = domain.DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		logger.With("error", err, "attempts", delivery.Attempts).Info("webhook delivery failed, retrying")
+		next := now.Add(deliveryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+	}
+
+	return w.hooks.UpdateDelivery(ctx, delivery)
+}
+
+// deliveryDelay returns the wait before the next attempt of a delivery
+// after the given number of failed attempts.
+func deliveryDelay(attempts int) time.Duration {
+	delay := deliveryBackoff
+	for i := 1; i < attempts && delay < maxDeliveryBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDeliveryBackoff)
+}
+
+// Run attempts the due deliveries every interval, and as soon as new
+// deliveries are queued, until ctx is canceled.
+func (w *WebhookInteractor) Run(ctx context.Context, interval time.Duration) {
+	logger := logctx.FromContext(ctx, w.logger)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := w.DeliverDue(ctx)
+		if err != nil {
+			logger.With("error", err).Error("webhook delivery failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package interactor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Michela-DC/book-club/internal/domain"
+	"github.com/Michela-DC/book-club/internal/infrastructure/db"
+	"github.com/Michela-DC/book-club/internal/infrastructure/webhook"
+	v1 "github.com/Michela-DC/book-club/internal/interfaces/api/v1"
+)
+
+// testWebhookSecret is the secret of the webhooks of the tests.
+const testWebhookSecret = "0123456789abcdef0123456789abcdef"
+
+// receiver is a webhook receiver answering the deliveries with the given
+// statuses, then with 204, and recording the deliveries whose signature
+// is valid.
+type receiver struct {
+	t      *testing.T
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	payloads []v1.WebhookPayload
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rc.t.Errorf("unable to read delivery: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	timestamp := r.Header.Get(webhook.HeaderTimestamp)
+	if timestamp == "" {
+		rc.t.Errorf("%s is missing", webhook.HeaderTimestamp)
+	}
+	want := "sha256=" + webhook.Sign(rc.secret, timestamp, body)
+	if got := r.Header.Get(webhook.HeaderSignature); got != want {
+		rc.t.Errorf("%s = %q, want %q", webhook.HeaderSignature, got, want)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload v1.WebhookPayload
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		rc.t.Errorf("unable to decode delivery: %v", err)
+	}
+	if got := r.Header.Get(webhook.HeaderEvent); got != payload.Event {
+		rc.t.Errorf("%s = %q, want %q", webhook.HeaderEvent, got, payload.Event)
+	}
+	if got := r.Header.Get(webhook.HeaderDelivery); got != payload.ID {
+		rc.t.Errorf("%s = %q, want %q", webhook.HeaderDelivery, got, payload.ID)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.payloads = append(rc.payloads, payload)
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// received returns the number of deliveries received.
+func (rc *receiver) received() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.payloads)
+}
+
+// newTestWebhook returns a WebhookInteractor delivering with an [webhook.HTTPSender]
+// to a webhook, subscribed to the suggested and reading books, posting to rc.
+func newTestWebhook(t *testing.T, rc *receiver) (*WebhookInteractor, *db.SQLiteWebhookRepository, *domain.Webhook) {
+	t.Helper()
+
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	logger := slog.New(slog.DiscardHandler)
+	hooks := db.NewSQLiteWebhookRepository(newTestRepo(t), time.Hour, logger)
+	w := NewWebhookInteractor(hooks, webhook.NewHTTPSender(), logger)
+	hook, err := w.CreateWebhook(context.Background(), &domain.Webhook{
+		URL:    server.URL + "/hooks/book-club",
+		Secret: rc.secret,
+		Events: []domain.WebhookEvent{domain.WebhookBookSuggested, domain.WebhookBookReading},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	return w, hooks, hook
+}
+
+// suggested returns the event of the creation of a suggested book.
+func suggested(id int64) *domain.Event {
+	return &domain.Event{
+		ID:         id,
+		Type:       domain.EventBookCreated,
+		BookID:     "b-emma",
+		Book:       &domain.Book{ID: "b-emma", Title: "Emma", Author: "Jane Austen", Status: domain.BookStatusSuggested},
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// onlyDelivery returns the only delivery of hook, failing the test when there is not exactly one.
+func onlyDelivery(t *testing.T, w *WebhookInteractor, hook *domain.Webhook) *domain.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := w.ListDeliveries(context.Background(), hook.ID)
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("ListDeliveries() = %d deliveries, want 1", len(deliveries))
+	}
+
+	return deliveries[0]
+}
+
+func TestDeliverDueRetriesUntilAccepted(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t, secret: testWebhookSecret, statuses: []int{http.StatusServiceUnavailable}}
+	w, hooks, hook := newTestWebhook(t, rc)
+
+	err := w.HandleEvent(ctx, suggested(1))
+	if err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	before := time.Now()
+	err = w.DeliverDue(ctx)
+	if err != nil {
+		t.Fatalf("DeliverDue() error = %v", err)
+	}
+	d := onlyDelivery(t, w, hook)
+	if d.Status != domain.DeliveryPending || d.Attempts != 1 || d.ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("after a 503 delivery = %s, %d attempts, status %d, want pending after 1 attempt answered with 503",
+			d.Status, d.Attempts, d.ResponseStatus)
+	}
+	if d.NextAttemptAt == nil || d.NextAttemptAt.Before(before.Add(deliveryBackoff)) ||
+		d.NextAttemptAt.After(time.Now().Add(deliveryBackoff)) {
+		t.Fatalf("next attempt at %v, want %v after the attempt", d.NextAttemptAt, deliveryBackoff)
+	}
+
+	// The retry is not due yet.
+	err = w.DeliverDue(ctx)
+	if err != nil {
+		t.Fatalf("DeliverDue() error = %v", err)
+	}
+	if got := rc.received(); got != 1 {
+		t.Fatalf("receiver got %d deliveries before the retry is due, want 1", got)
+	}
+
+	past := time.Now().Add(-time.Second)
+	d.NextAttemptAt = &past
+	err = hooks.UpdateDelivery(ctx, d)
+	if err != nil {
+		t.Fatalf("UpdateDelivery() error = %v", err)
+	}
+	err = w.DeliverDue(ctx)
+	if err != nil {
+		t.Fatalf("DeliverDue() error = %v", err)
+	}
+	d = onlyDelivery(t, w, hook)
+	if d.Status != domain.DeliverySucceeded || d.Attempts != 2 || d.ResponseStatus != http.StatusNoContent {
+		t.Fatalf("after a 204 delivery = %s, %d attempts, status %d, want succeeded after 2 attempts answered with 204",
+			d.Status, d.Attempts, d.ResponseStatus)
+	}
+	if d.NextAttemptAt != nil || d.LastError != "" {
+		t.Errorf("succeeded delivery next attempt at %v, last error %q, want neither", d.NextAttemptAt, d.LastError)
+	}
+
+	// A succeeded delivery is not attempted again.
+	err = w.DeliverDue(ctx)
+	if err != nil {
+		t.Fatalf("DeliverDue() error = %v", err)
+	}
+	if got := rc.received(); got != 2 {
+		t.Errorf("receiver got %d deliveries, want 2", got)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, p := range rc.payloads {
+		if p.ID != d.ID || p.Event != string(domain.WebhookBookSuggested) || p.Book.Title != "Emma" {
+			t.Errorf("payload = %+v, want delivery %s of Emma suggested", p, d.ID)
+		}
+	}
+}
+
+func TestHandleEventDeliversOnce(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t, secret: testWebhookSecret}
+	w, _, hook := newTestWebhook(t, rc)
+
+	// The dispatcher hands an event again when it failed to mark it as dispatched.
+	event := suggested(7)
+	for range 2 {
+		err := w.HandleEvent(ctx, event)
+		if err != nil {
+			t.Fatalf("HandleEvent() error = %v", err)
+		}
+		err = w.DeliverDue(ctx)
+		if err != nil {
+			t.Fatalf("DeliverDue() error = %v", err)
+		}
+	}
+
+	if got := rc.received(); got != 1 {
+		t.Errorf("receiver got %d deliveries of the same event, want 1", got)
+	}
+	d := onlyDelivery(t, w, hook)
+	if d.EventID != event.ID || d.Status != domain.DeliverySucceeded {
+		t.Errorf("delivery of event %d is %s, want event %d succeeded", d.EventID, d.Status, event.ID)
+	}
+
+	// A redelivery is sent again on demand.
+	_, err := w.Redeliver(ctx, hook.ID, d.ID)
+	if err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	err = w.DeliverDue(ctx)
+	if err != nil {
+		t.Fatalf("DeliverDue() error = %v", err)
+	}
+	if got := rc.received(); got != 2 {
+		t.Errorf("receiver got %d deliveries after a redelivery, want 2", got)
+	}
+}
+
+func TestDeliveryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 20, want: maxDeliveryBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := deliveryDelay(tt.attempts); got != tt.want {
+			t.Errorf("deliveryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+#!/bin/sh
+# Downloads the Swagger UI assets embedded in the server, so that the
+# documentation page at /docs works offline. The version is read from
+# internal/infrastructure/webservice/swaggerui/VERSION.
+set -eu
+
+dir="$(dirname "$0")/../internal/infrastructure/webservice/swaggerui"
+version="$(cat "$dir/VERSION")"
+
+curl -fsSL "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$version.tgz" |
+	tar -xzf - -C "$dir" --strip-components=1 \
+		package/LICENSE package/swagger-ui.css package/swagger-ui-bundle.js
+
+echo "Swagger UI $version vendored in $dir"
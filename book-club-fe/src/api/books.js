@@ -1,5 +1,25 @@
 const API_BASE = import.meta.env.VITE_API_URL || 'http://localhost:8080';
 
+// ApiError carries the problem details (RFC 7807) returned by the API:
+// a machine-readable `code` and, for validation problems, the invalid `fields`.
+export class ApiError extends Error {
+  constructor(status, problem) {
+    super(problem.detail || problem.title || `HTTP ${status}`);
+    this.status = status;
+    this.code = problem.code;
+    this.fields = Object.fromEntries((problem.errors || []).map((e) => [e.field, e.message]));
+  }
+}
+
+async function toApiError(res) {
+  const type = res.headers.get('content-type') || '';
+  if (type.startsWith('application/problem+json')) {
+    return new ApiError(res.status, await res.json().catch(() => ({})));
+  }
+  const text = await res.text().catch(() => res.statusText);
+  return new ApiError(res.status, { detail: text });
+}
+
 async function request(method, path, body) {
   const opts = {
     method,
@@ -9,10 +29,7 @@ async function request(method, path, body) {
 
   const res = await fetch(`${API_BASE}${path}`, opts);
 
-  if (!res.ok) {
-    const text = await res.text().catch(() => res.statusText);
-    throw new Error(text || `HTTP ${res.status}`);
-  }
+  if (!res.ok) throw await toApiError(res);
 
   if (res.status === 204 || res.headers.get('content-length') === '0') return null;
   return res.json();
@@ -23,9 +40,9 @@ export function getBooks() {
   return request('GET', '/v1/books');
 }
 
-// PUT /v1/books
+// POST /v1/books
 export function createBook(data) {
-  return request('PUT', '/v1/books', data);
+  return request('POST', '/v1/books', data);
 }
 
 // PATCH /v1/books/:id
@@ -33,7 +50,40 @@ export function updateBook(id, data) {
   return request('PATCH', `/v1/books/${id}`, data);
 }
 
+// PUT /v1/books/:id/cover (multipart)
+export async function uploadCover(id, file) {
+  const body = new FormData();
+  body.append('cover', file);
+
+  const res = await fetch(`${API_BASE}/v1/books/${id}/cover`, { method: 'PUT', body });
+  if (!res.ok) throw await toApiError(res);
+  return res.json();
+}
+
+// Covers uploaded to the API are served as thumbnails; external covers are used as they are.
+export function coverSrc(book, size = 'medium') {
+  const url = book.cover_url;
+  if (!url) return null;
+  if (!url.startsWith('/')) return url;
+  const sep = url.includes('?') ? '&' : '?';
+  return `${API_BASE}${url}${sep}size=${size}`;
+}
+
 // DELETE /v1/books/:id
 export function deleteBook(id) {
   return request('DELETE', `/v1/books/${id}`);
 }
+
+// Types of the events sent by GET /v1/events.
+const EVENT_TYPES = ['book.created', 'book.updated', 'book.deleted', 'book.restored', 'reset'];
+
+// GET /v1/events (Server-Sent Events)
+// Calls onEvent(type, event) for each change to the books. The browser
+// reconnects on its own, resuming after the last event received. Returns
+// a function that closes the stream.
+export function subscribeToEvents(onEvent) {
+  const source = new EventSource(`${API_BASE}/v1/events`);
+  const handle = (e) => onEvent(e.type, JSON.parse(e.data));
+  EVENT_TYPES.forEach((type) => source.addEventListener(type, handle));
+  return () => source.close();
+}
@@ -1,10 +1,21 @@
 import StatusBadge from '../ui/StatusBadge';
 import Button from '../ui/Button';
+import { coverSrc } from '../../api/books';
 import styles from './BookCard.module.css';
 
 export default function BookCard({ book, onEdit, onDelete }) {
+  const cover = coverSrc(book);
+
   return (
     <article className={styles.card} data-status={book.status}>
+      {cover && (
+        <img
+          className={styles.cover}
+          src={cover}
+          alt={`Cover of ${book.title}`}
+          loading="lazy"
+        />
+      )}
       {book.genre && <span className={styles.genre}>{book.genre}</span>}
       <h3 className={styles.title}>{book.title}</h3>
       <p className={styles.author}>{book.author}</p>
@@ -45,7 +45,7 @@ export default function BookForm({ book, onSubmit, onCancel, isSaving }) {
       title: fields.title.trim(),
       author: fields.author.trim(),
       genre: fields.genre.trim() || undefined,
-      year: fields.year ? parseInt(fields.year, 10) : undefined,
+      published_year: fields.year ? parseInt(fields.year, 10) : undefined,
       status: fields.status,
     });
   }
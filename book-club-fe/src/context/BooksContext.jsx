@@ -1,4 +1,4 @@
-import { createContext, useContext, useReducer, useCallback } from 'react';
+import { createContext, useContext, useReducer, useCallback, useEffect } from 'react';
 import * as booksApi from '../api/books';
 import { DEMO_BOOKS } from '../api/constants';
 
@@ -14,6 +14,16 @@ function booksReducer(state, action) {
     case 'SET_BOOKS':
       return { ...state, books: action.payload, loading: false, error: null };
     case 'ADD_BOOK':
+    case 'UPSERT_BOOK':
+      // The book may already be listed when its change event arrives first.
+      if (state.books.some((b) => b.id === action.payload.id)) {
+        return {
+          ...state,
+          books: state.books.map((b) =>
+            b.id === action.payload.id ? action.payload : b
+          ),
+        };
+      }
       return { ...state, books: [action.payload, ...state.books] };
     case 'UPDATE_BOOK':
       return {
@@ -41,6 +51,31 @@ const initialState = {
 export function BooksProvider({ children }) {
   const [state, dispatch] = useReducer(booksReducer, initialState);
 
+  // Keep the books in sync with the changes made by the other members.
+  useEffect(
+    () =>
+      booksApi.subscribeToEvents((type, event) => {
+        switch (type) {
+          case 'book.created':
+          case 'book.updated':
+          case 'book.restored':
+            dispatch({ type: 'UPSERT_BOOK', payload: event.book });
+            break;
+          case 'book.deleted':
+            dispatch({ type: 'DELETE_BOOK', payload: event.book_id });
+            break;
+          case 'reset':
+            // Some changes were missed: reload the books.
+            booksApi
+              .getBooks()
+              .then((data) => dispatch({ type: 'SET_BOOKS', payload: data || [] }))
+              .catch(() => {});
+            break;
+        }
+      }),
+    []
+  );
+
   return (
     <BooksContext.Provider value={state}>
       <BooksDispatchContext.Provider value={dispatch}>